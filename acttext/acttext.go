@@ -0,0 +1,305 @@
+package acttext
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Kinds of editorial units of an act, from the outermost
+const (
+	KindDivision  = "dzial"
+	KindChapter   = "rozdzial"
+	KindArticle   = "art"
+	KindParagraph = "ust"
+	KindPoint     = "pkt"
+	KindLetter    = "lit"
+)
+
+// levels orders unit kinds by nesting depth
+var levels = map[string]int{
+	KindDivision:  0,
+	KindChapter:   1,
+	KindArticle:   2,
+	KindParagraph: 3,
+	KindPoint:     4,
+	KindLetter:    5,
+}
+
+// Patterns recognizing the first line of each unit
+var (
+	divisionPattern  = regexp.MustCompile(`^DZIAŁ\s+([IVXLC]+[a-z]*)\.?\s*(.*)$`)
+	chapterPattern   = regexp.MustCompile(`^Rozdział\s+(\d+[a-z]*|[IVXLC]+[a-z]*)\.?\s*(.*)$`)
+	articlePattern   = regexp.MustCompile(`^Art\.\s*(\d+[a-z]*)\.\s*(.*)$`)
+	paragraphPattern = regexp.MustCompile(`^(\d+[a-z]*)\.\s+(.*)$`)
+	pointPattern     = regexp.MustCompile(`^(\d+[a-z]*)\)\s+(.*)$`)
+	letterPattern    = regexp.MustCompile(`^([a-z]{1,2})\)\s+(.*)$`)
+)
+
+// Document is the structured text of an act
+type Document struct {
+	// Preamble holds lines before the first unit, such as the title and the enacting formula
+	Preamble []string `json:"preamble,omitempty"`
+	Units    []*Unit  `json:"units"`
+}
+
+// Unit is an editorial unit of an act: a division, chapter, article, paragraph, point or letter
+type Unit struct {
+	Kind     string  `json:"kind"`
+	Number   string  `json:"number"`
+	Anchor   string  `json:"anchor"`
+	Title    string  `json:"title,omitempty"`
+	Text     string  `json:"text,omitempty"`
+	Children []*Unit `json:"children,omitempty"`
+}
+
+// Label returns the unit designation as written in acts, e.g. "Art. 12" or "ust. 3"
+func (u *Unit) Label() string {
+	switch u.Kind {
+	case KindDivision:
+		return "Dział " + u.Number
+	case KindChapter:
+		return "Rozdział " + u.Number
+	case KindArticle:
+		return "Art. " + u.Number
+	case KindParagraph:
+		return "ust. " + u.Number
+	case KindPoint:
+		return "pkt " + u.Number
+	default:
+		return "lit. " + u.Number
+	}
+}
+
+// Articles returns all articles of the document in order
+func (d *Document) Articles() []*Unit {
+	var articles []*Unit
+	var walk func(units []*Unit)
+	walk = func(units []*Unit) {
+		for _, unit := range units {
+			if unit.Kind == KindArticle {
+				articles = append(articles, unit)
+				continue
+			}
+			walk(unit.Children)
+		}
+	}
+	walk(d.Units)
+	return articles
+}
+
+// Parse builds the structured text of an act from its ELI HTML text
+func Parse(r io.Reader) (*Document, error) {
+	root, err := html.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse act HTML: %w", err)
+	}
+
+	return ParseLines(textLines(root)), nil
+}
+
+// ParseLines builds the structured text of an act from its lines of text
+func ParseLines(lines []string) *Document {
+	p := &parser{doc: &Document{Units: make([]*Unit, 0)}}
+	for _, line := range lines {
+		p.line(line)
+	}
+	return p.doc
+}
+
+// parser keeps the chain of units the next line may belong to
+type parser struct {
+	doc   *Document
+	stack []*Unit
+	// chapterTitle is set while the title of a chapter or division is expected on the next line
+	chapterTitle *Unit
+}
+
+// line classifies a line of text and attaches it to the document
+func (p *parser) line(line string) {
+	if p.chapterTitle != nil {
+		unit := p.chapterTitle
+		p.chapterTitle = nil
+		if !isUnitStart(line) {
+			unit.Title = line
+			return
+		}
+	}
+
+	if m := divisionPattern.FindStringSubmatch(line); m != nil {
+		p.open(KindDivision, m[1], "")
+		p.setTitle(m[2])
+		return
+	}
+	if m := chapterPattern.FindStringSubmatch(line); m != nil {
+		p.open(KindChapter, m[1], "")
+		p.setTitle(m[2])
+		return
+	}
+	if m := articlePattern.FindStringSubmatch(line); m != nil {
+		p.open(KindArticle, m[1], "")
+		// The first paragraph often follows the article number on the same line
+		if pm := paragraphPattern.FindStringSubmatch(m[2]); pm != nil {
+			p.open(KindParagraph, pm[1], pm[2])
+		} else {
+			p.appendText(m[2])
+		}
+		return
+	}
+
+	if p.inArticle() {
+		if m := paragraphPattern.FindStringSubmatch(line); m != nil {
+			p.open(KindParagraph, m[1], m[2])
+			return
+		}
+		if m := pointPattern.FindStringSubmatch(line); m != nil {
+			p.open(KindPoint, m[1], m[2])
+			return
+		}
+		if m := letterPattern.FindStringSubmatch(line); m != nil && p.inPoint() {
+			p.open(KindLetter, m[1], m[2])
+			return
+		}
+	}
+
+	if len(p.stack) == 0 {
+		p.doc.Preamble = append(p.doc.Preamble, line)
+		return
+	}
+	p.appendText(line)
+}
+
+// open starts a unit, closing units at the same or a deeper level
+func (p *parser) open(kind, number, text string) {
+	level := levels[kind]
+	for len(p.stack) > 0 && levels[p.stack[len(p.stack)-1].Kind] >= level {
+		p.stack = p.stack[:len(p.stack)-1]
+	}
+
+	unit := &Unit{Kind: kind, Number: number, Text: text}
+	unit.Anchor = p.anchor(unit)
+	if len(p.stack) == 0 {
+		p.doc.Units = append(p.doc.Units, unit)
+	} else {
+		parent := p.stack[len(p.stack)-1]
+		parent.Children = append(parent.Children, unit)
+	}
+	p.stack = append(p.stack, unit)
+}
+
+// anchor builds a stable fragment for a unit; units inside an article are anchored
+// relative to it, e.g. art-12-ust-3-pkt-2, and chapters relative to their division,
+// e.g. dzial-ii-rozdzial-1, as chapter numbering restarts in every division
+func (p *parser) anchor(unit *Unit) string {
+	own := unit.Kind + "-" + strings.ToLower(unit.Number)
+	if len(p.stack) == 0 {
+		return own
+	}
+	parent := p.stack[len(p.stack)-1]
+	switch {
+	case unit.Kind == KindChapter && parent.Kind == KindDivision:
+		return parent.Anchor + "-" + own
+	case levels[unit.Kind] > levels[KindArticle] && levels[parent.Kind] >= levels[KindArticle]:
+		return parent.Anchor + "-" + own
+	default:
+		return own
+	}
+}
+
+// setTitle sets the title of a just opened division or chapter, expecting it on the next line if empty
+func (p *parser) setTitle(title string) {
+	unit := p.stack[len(p.stack)-1]
+	if title != "" {
+		unit.Title = title
+		return
+	}
+	p.chapterTitle = unit
+}
+
+// appendText adds a continuation line to the innermost open unit
+func (p *parser) appendText(text string) {
+	if text == "" {
+		return
+	}
+	unit := p.stack[len(p.stack)-1]
+	if unit.Text == "" {
+		unit.Text = text
+		return
+	}
+	unit.Text += "\n" + text
+}
+
+// inArticle reports whether lines currently belong to an article
+func (p *parser) inArticle() bool {
+	for _, unit := range p.stack {
+		if unit.Kind == KindArticle {
+			return true
+		}
+	}
+	return false
+}
+
+// inPoint reports whether lines currently belong to a point or letter
+func (p *parser) inPoint() bool {
+	if len(p.stack) == 0 {
+		return false
+	}
+	kind := p.stack[len(p.stack)-1].Kind
+	return kind == KindPoint || kind == KindLetter
+}
+
+// isUnitStart reports whether a line opens a division, chapter or article
+func isUnitStart(line string) bool {
+	return divisionPattern.MatchString(line) || chapterPattern.MatchString(line) || articlePattern.MatchString(line)
+}
+
+// blockElements end a line of text
+var blockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Br: true, atom.Li: true, atom.Tr: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Section: true, atom.Article: true, atom.Table: true, atom.Blockquote: true,
+}
+
+// textLines extracts non-empty lines of visible text from an HTML document
+func textLines(root *html.Node) []string {
+	var lines []string
+	var current strings.Builder
+
+	flush := func() {
+		line := strings.Join(strings.Fields(current.String()), " ")
+		if line != "" {
+			lines = append(lines, line)
+		}
+		current.Reset()
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.ElementNode && (n.DataAtom == atom.Script || n.DataAtom == atom.Style ||
+			n.DataAtom == atom.Head):
+			return
+		case n.Type == html.TextNode:
+			current.WriteString(n.Data)
+		}
+
+		block := n.Type == html.ElementNode && blockElements[n.DataAtom]
+		if block {
+			flush()
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+		if block {
+			flush()
+		}
+	}
+	walk(root)
+	flush()
+
+	return lines
+}
@@ -0,0 +1,108 @@
+package acttext_test
+
+import (
+	"strings"
+	"testing"
+	"ustawka/acttext"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleHTML = `<!DOCTYPE html>
+<html><head><title>DU/2024/1</title><style>p { margin: 0 }</style></head>
+<body>
+<h1>USTAWA</h1>
+<p>z dnia 1 stycznia 2024 r.</p>
+<p>o ochronie&nbsp;przykładów</p>
+<h2>Rozdział 1</h2>
+<p>Przepisy ogólne</p>
+<p><span>Art.</span> <span>1.</span> 1. Ustawa określa zasady</p>
+<p>ochrony przykładów.</p>
+<p>2. Ilekroć w ustawie jest mowa o:</p>
+<p>1) przykładzie – rozumie się przez to:</p>
+<p>a) wzór,</p>
+<p>b) model;</p>
+<p>2) ochronie – rozumie się przez to działania.</p>
+<p>Art. 2. Ustawa nie narusza przepisów odrębnych.</p>
+<h2>Rozdział 2 Przepisy końcowe</h2>
+<p>Art. 3. Ustawa wchodzi w życie z dniem ogłoszenia.</p>
+</body></html>`
+
+func TestParse(t *testing.T) {
+	doc, err := acttext.Parse(strings.NewReader(sampleHTML))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"USTAWA", "z dnia 1 stycznia 2024 r.", "o ochronie przykładów"}, doc.Preamble)
+	require.Len(t, doc.Units, 2)
+
+	chapter := doc.Units[0]
+	assert.Equal(t, acttext.KindChapter, chapter.Kind)
+	assert.Equal(t, "rozdzial-1", chapter.Anchor)
+	assert.Equal(t, "Przepisy ogólne", chapter.Title)
+	require.Len(t, chapter.Children, 2)
+
+	art1 := chapter.Children[0]
+	assert.Equal(t, "art-1", art1.Anchor)
+	assert.Empty(t, art1.Text)
+	require.Len(t, art1.Children, 2)
+	assert.Equal(t, "art-1-ust-1", art1.Children[0].Anchor)
+	assert.Equal(t, "Ustawa określa zasady\nochrony przykładów.", art1.Children[0].Text)
+
+	ust2 := art1.Children[1]
+	assert.Equal(t, "Ilekroć w ustawie jest mowa o:", ust2.Text)
+	require.Len(t, ust2.Children, 2)
+	assert.Equal(t, "art-1-ust-2-pkt-1", ust2.Children[0].Anchor)
+	require.Len(t, ust2.Children[0].Children, 2)
+	assert.Equal(t, "art-1-ust-2-pkt-1-lit-b", ust2.Children[0].Children[1].Anchor)
+	assert.Equal(t, "model;", ust2.Children[0].Children[1].Text)
+	assert.Equal(t, "art-1-ust-2-pkt-2", ust2.Children[1].Anchor)
+
+	art2 := chapter.Children[1]
+	assert.Equal(t, "Ustawa nie narusza przepisów odrębnych.", art2.Text)
+	assert.Empty(t, art2.Children)
+
+	assert.Equal(t, "Przepisy końcowe", doc.Units[1].Title)
+	articles := doc.Articles()
+	require.Len(t, articles, 3)
+	assert.Equal(t, "Art. 3", articles[2].Label())
+}
+
+func TestParseLinesChapterAnchorsUniqueAcrossDivisions(t *testing.T) {
+	doc := acttext.ParseLines([]string{
+		"DZIAŁ I",
+		"Przepisy ogólne",
+		"Rozdział 1",
+		"Zakres",
+		"Art. 1. Tekst.",
+		"DZIAŁ II",
+		"Przepisy szczególne",
+		"Rozdział 1",
+		"Organy",
+		"Art. 2. Tekst.",
+	})
+
+	require.Len(t, doc.Units, 2)
+	require.Len(t, doc.Units[0].Children, 1)
+	require.Len(t, doc.Units[1].Children, 1)
+	assert.Equal(t, "dzial-i", doc.Units[0].Anchor)
+	assert.Equal(t, "dzial-i-rozdzial-1", doc.Units[0].Children[0].Anchor)
+	assert.Equal(t, "dzial-ii-rozdzial-1", doc.Units[1].Children[0].Anchor)
+	assert.Equal(t, "art-2", doc.Units[1].Children[0].Children[0].Anchor)
+}
+
+func TestParseLinesPointsDirectlyInArticle(t *testing.T) {
+	doc := acttext.ParseLines([]string{
+		"Art. 5. W ustawie wprowadza się następujące zmiany:",
+		"1) w art. 2 dodaje się ust. 3;",
+		"2) uchyla się art. 4.",
+		"Art. 5a. Tekst.",
+	})
+
+	require.Len(t, doc.Units, 2)
+	assert.Equal(t, "W ustawie wprowadza się następujące zmiany:", doc.Units[0].Text)
+	require.Len(t, doc.Units[0].Children, 2)
+	assert.Equal(t, "art-5-pkt-2", doc.Units[0].Children[1].Anchor)
+	assert.Equal(t, "pkt 2", doc.Units[0].Children[1].Label())
+	assert.Equal(t, "art-5a", doc.Units[1].Anchor)
+}
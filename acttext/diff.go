@@ -0,0 +1,186 @@
+package acttext
+
+import "strings"
+
+// Statuses of an article between two versions of a document
+const (
+	StatusAdded   = "added"
+	StatusRemoved = "removed"
+	StatusChanged = "changed"
+)
+
+// Kinds of diff operations
+const (
+	OpEqual  = "equal"
+	OpInsert = "insert"
+	OpDelete = "delete"
+)
+
+// maxDiffCells bounds the word comparison table of a single article; larger
+// articles are reported as replaced entirely
+const maxDiffCells = 4_000_000
+
+// ArticleDiff is the word-level difference of an article between two versions
+type ArticleDiff struct {
+	Number string `json:"number"`
+	Anchor string `json:"anchor"`
+	Label  string `json:"label"`
+	Status string `json:"status"`
+	Ops    []Op   `json:"ops"`
+}
+
+// Op is a run of words kept, inserted or deleted
+type Op struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Diff compares the articles of two versions of a document, returning only the
+// articles that were added, removed or changed, in the order of the newer version
+func Diff(from, to *Document) []ArticleDiff {
+	previous := make(map[string]*Unit)
+	for _, article := range from.Articles() {
+		previous[article.Number] = article
+	}
+
+	diffs := make([]ArticleDiff, 0)
+	seen := make(map[string]bool)
+	for _, article := range to.Articles() {
+		seen[article.Number] = true
+		words := strings.Fields(ArticleText(article))
+
+		old, ok := previous[article.Number]
+		if !ok {
+			diffs = append(diffs, newArticleDiff(article, StatusAdded, []Op{{Type: OpInsert, Text: strings.Join(words, " ")}}))
+			continue
+		}
+
+		ops := diffWords(strings.Fields(ArticleText(old)), words)
+		if len(ops) == 1 && ops[0].Type == OpEqual {
+			continue
+		}
+		diffs = append(diffs, newArticleDiff(article, StatusChanged, ops))
+	}
+
+	for _, article := range from.Articles() {
+		if seen[article.Number] {
+			continue
+		}
+		text := strings.Join(strings.Fields(ArticleText(article)), " ")
+		diffs = append(diffs, newArticleDiff(article, StatusRemoved, []Op{{Type: OpDelete, Text: text}}))
+	}
+
+	return diffs
+}
+
+// ArticleText flattens an article with its paragraphs, points and letters into plain text
+func ArticleText(article *Unit) string {
+	var b strings.Builder
+	var walk func(unit *Unit)
+	walk = func(unit *Unit) {
+		if unit != article {
+			b.WriteString("\n" + unit.Number)
+			if unit.Kind == KindParagraph {
+				b.WriteString(". ")
+			} else {
+				b.WriteString(") ")
+			}
+		}
+		b.WriteString(unit.Text)
+		for _, child := range unit.Children {
+			walk(child)
+		}
+	}
+	walk(article)
+	return strings.TrimSpace(b.String())
+}
+
+// newArticleDiff builds the diff entry of an article
+func newArticleDiff(article *Unit, status string, ops []Op) ArticleDiff {
+	return ArticleDiff{
+		Number: article.Number,
+		Anchor: article.Anchor,
+		Label:  article.Label(),
+		Status: status,
+		Ops:    ops,
+	}
+}
+
+// diffWords computes the shortest edit between two word sequences from their
+// longest common subsequence, merging adjacent words of the same operation
+func diffWords(a, b []string) []Op {
+	var ops []Op
+	add := func(kind string, words ...string) {
+		if len(words) == 0 {
+			return
+		}
+		text := strings.Join(words, " ")
+		if n := len(ops); n > 0 && ops[n-1].Type == kind {
+			ops[n-1].Text += " " + text
+			return
+		}
+		ops = append(ops, Op{Type: kind, Text: text})
+	}
+
+	// Common prefix and suffix need no comparison table
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	add(OpEqual, a[:prefix]...)
+	oldWords, newWords := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	if (len(oldWords)+1)*(len(newWords)+1) > maxDiffCells {
+		add(OpDelete, oldWords...)
+		add(OpInsert, newWords...)
+	} else {
+		diffMiddle(oldWords, newWords, add)
+	}
+
+	add(OpEqual, a[len(a)-suffix:]...)
+	if ops == nil {
+		return []Op{{Type: OpEqual}}
+	}
+	return ops
+}
+
+// diffMiddle emits the edit between two word sequences using a longest common subsequence table
+func diffMiddle(a, b []string, add func(kind string, words ...string)) {
+	n, m := len(a), len(b)
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int32, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			add(OpEqual, a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			add(OpDelete, a[i])
+			i++
+		default:
+			add(OpInsert, b[j])
+			j++
+		}
+	}
+	add(OpDelete, a[i:]...)
+	add(OpInsert, b[j:]...)
+}
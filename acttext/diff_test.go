@@ -0,0 +1,61 @@
+package acttext_test
+
+import (
+	"testing"
+	"ustawka/acttext"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	from := acttext.ParseLines([]string{
+		"Art. 1. Ustawa określa zasady ochrony przykładów.",
+		"Art. 2. 1. Przepis pozostaje bez zmian.",
+		"Art. 3. Ustawa wchodzi w życie po 14 dniach.",
+	})
+	to := acttext.ParseLines([]string{
+		"Art. 1. Ustawa określa szczegółowe zasady ochrony przykładów.",
+		"Art. 1a. Nowy przepis.",
+		"Art. 2. 1. Przepis pozostaje bez zmian.",
+	})
+
+	diffs := acttext.Diff(from, to)
+	require.Len(t, diffs, 3)
+
+	assert.Equal(t, acttext.ArticleDiff{
+		Number: "1",
+		Anchor: "art-1",
+		Label:  "Art. 1",
+		Status: acttext.StatusChanged,
+		Ops: []acttext.Op{
+			{Type: acttext.OpEqual, Text: "Ustawa określa"},
+			{Type: acttext.OpInsert, Text: "szczegółowe"},
+			{Type: acttext.OpEqual, Text: "zasady ochrony przykładów."},
+		},
+	}, diffs[0])
+
+	assert.Equal(t, "1a", diffs[1].Number)
+	assert.Equal(t, acttext.StatusAdded, diffs[1].Status)
+	assert.Equal(t, []acttext.Op{{Type: acttext.OpInsert, Text: "Nowy przepis."}}, diffs[1].Ops)
+
+	assert.Equal(t, "3", diffs[2].Number)
+	assert.Equal(t, acttext.StatusRemoved, diffs[2].Status)
+	assert.Equal(t, []acttext.Op{{Type: acttext.OpDelete, Text: "Ustawa wchodzi w życie po 14 dniach."}}, diffs[2].Ops)
+}
+
+func TestDiffReplacedWords(t *testing.T) {
+	from := acttext.ParseLines([]string{"Art. 1. 1. Termin wynosi 7 dni.", "2. Przepis ust. 1 stosuje się odpowiednio."})
+	to := acttext.ParseLines([]string{"Art. 1. 1. Termin wynosi 14 dni.", "2. Przepis ust. 1 stosuje się odpowiednio."})
+
+	diffs := acttext.Diff(from, to)
+	require.Len(t, diffs, 1)
+	assert.Equal(t, []acttext.Op{
+		{Type: acttext.OpEqual, Text: "1. Termin wynosi"},
+		{Type: acttext.OpDelete, Text: "7"},
+		{Type: acttext.OpInsert, Text: "14"},
+		{Type: acttext.OpEqual, Text: "dni. 2. Przepis ust. 1 stosuje się odpowiednio."},
+	}, diffs[0].Ops)
+
+	assert.Empty(t, acttext.Diff(to, to))
+}
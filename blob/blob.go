@@ -0,0 +1,80 @@
+package blob
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// ErrInvalidHash is returned for hashes that are not hex SHA-256 digests
+var ErrInvalidHash = errors.New("invalid blob hash")
+
+// hashPattern matches hex SHA-256 digests
+var hashPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// Store keeps immutable blobs on disk, addressed by the SHA-256 of their content
+type Store struct {
+	dir string
+}
+
+// NewStore creates a blob store in a directory, creating it if needed
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create blob directory: %w", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Put stores data and returns its hash; storing the same content twice keeps a single copy
+func (s *Store) Put(data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	path := s.path(hash)
+	if _, err := os.Stat(path); err == nil {
+		return hash, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return "", fmt.Errorf("failed to create blob directory: %w", err)
+	}
+
+	// Write to a temporary file first so readers never see a partial blob
+	tmp, err := os.CreateTemp(filepath.Dir(path), hash+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("failed to create blob: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return "", fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("failed to store blob: %w", err)
+	}
+
+	return hash, nil
+}
+
+// Open opens a stored blob for reading
+func (s *Store) Open(hash string) (*os.File, error) {
+	if !hashPattern.MatchString(hash) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidHash, hash)
+	}
+	return os.Open(s.path(hash))
+}
+
+// path returns the location of a blob, sharded by the first two hash characters
+func (s *Store) path(hash string) string {
+	return filepath.Join(s.dir, hash[:2], hash[2:])
+}
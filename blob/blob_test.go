@@ -0,0 +1,52 @@
+package blob_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"testing"
+	"ustawka/blob"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPutAndOpen(t *testing.T) {
+	store, err := blob.NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	hash, err := store.Put([]byte("%PDF-1.7"))
+	require.NoError(t, err)
+	sum := sha256.Sum256([]byte("%PDF-1.7"))
+	assert.Equal(t, hex.EncodeToString(sum[:]), hash)
+
+	// The same content is addressed by the same hash
+	again, err := store.Put([]byte("%PDF-1.7"))
+	require.NoError(t, err)
+	assert.Equal(t, hash, again)
+
+	f, err := store.Open(hash)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, f.Close())
+	}()
+	data, err := io.ReadAll(f)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("%PDF-1.7"), data)
+
+	other, err := store.Put([]byte("<html></html>"))
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other)
+}
+
+func TestOpenRejectsInvalidHash(t *testing.T) {
+	store, err := blob.NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	_, err = store.Open("../../etc/passwd")
+	assert.ErrorIs(t, err, blob.ErrInvalidHash)
+
+	_, err = store.Open("0000000000000000000000000000000000000000000000000000000000000000")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
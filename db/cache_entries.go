@@ -0,0 +1,147 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Kinds of resources tracked in the cache metadata
+const (
+	CacheKindActs    = "acts"
+	CacheKindDetails = "details"
+	CacheKindTexts   = "texts"
+)
+
+// Outcomes of fetching a resource from the API
+const (
+	// OutcomeOK is a resource that was found and cached
+	OutcomeOK = "ok"
+	// OutcomeEmpty is a year listing without acts
+	OutcomeEmpty = "empty"
+	// OutcomeNotFound is a resource the API answered 404 for
+	OutcomeNotFound = "not_found"
+	// OutcomeFailed is a cached resource whose refresh failed, served from the older copy
+	OutcomeFailed = "failed"
+)
+
+// CacheEntry records when and with what outcome a resource was last fetched from the API
+type CacheEntry struct {
+	Kind       string
+	Key        string
+	FetchedAt  time.Time
+	TotalCount int
+	ETag       string
+	Outcome    string
+	// Invalidated is set when the resource must be fetched again regardless of its age
+	Invalidated bool
+}
+
+// Age returns the time elapsed since the resource was fetched
+func (e *CacheEntry) Age() time.Duration {
+	return time.Since(e.FetchedAt)
+}
+
+// YearKey identifies the listing of a publisher's year, e.g. DU/2024
+func YearKey(publisher string, year int) string {
+	return fmt.Sprintf("%s/%d", publisher, year)
+}
+
+// TextKey identifies a text of an act, e.g. DU/2024/1/text.pdf
+func TextKey(actID, name string) string {
+	return actID + "/" + name
+}
+
+// execer runs statements either directly or within a transaction
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// GetCacheEntry retrieves the cache metadata of a resource, or nil if it was never fetched
+func (db *DB) GetCacheEntry(ctx context.Context, kind, key string) (_ *CacheEntry, err error) {
+	ctx, end := startQuery(ctx, "get_cache_entry")
+	defer end(&err)
+
+	entry := CacheEntry{Kind: kind, Key: key}
+	var fetchedAt string
+	err = db.QueryRowContext(ctx, `
+		SELECT strftime('%Y-%m-%d %H:%M:%f', fetched_at), total_count, etag, outcome, invalidated
+		FROM cache_entries WHERE kind = ? AND key = ?
+	`, kind, key).Scan(&fetchedAt, &entry.TotalCount, &entry.ETag, &entry.Outcome, &entry.Invalidated)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.FetchedAt, err = time.Parse(timestampLayout, fetchedAt); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// StoreCacheEntry records that a resource was just fetched, such as one the API had no data for
+func (db *DB) StoreCacheEntry(ctx context.Context, entry CacheEntry) (err error) {
+	ctx, end := startQuery(ctx, "store_cache_entry")
+	defer end(&err)
+
+	return storeCacheEntry(ctx, db, entry)
+}
+
+// TouchCacheEntry marks a cached resource as just fetched without changing it,
+// reporting whether it had been fetched before
+func (db *DB) TouchCacheEntry(ctx context.Context, kind, key string) (_ bool, err error) {
+	ctx, end := startQuery(ctx, "touch_cache_entry")
+	defer end(&err)
+
+	result, err := db.ExecContext(ctx,
+		"UPDATE cache_entries SET fetched_at = strftime('%Y-%m-%d %H:%M:%f', 'now'), invalidated = 0 WHERE kind = ? AND key = ?",
+		kind, key,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// InvalidateCacheEntries marks resources whose key is the prefix or lies under it, such as
+// a year and the acts published in it, to be fetched again; an empty prefix marks all of them.
+// It returns the number of resources marked.
+func (db *DB) InvalidateCacheEntries(ctx context.Context, prefix string) (_ int64, err error) {
+	ctx, end := startQuery(ctx, "invalidate_cache_entries")
+	defer end(&err)
+
+	result, err := db.ExecContext(ctx, `
+		UPDATE cache_entries SET invalidated = 1
+		WHERE ? = '' OR key = ? OR substr(key, 1, length(?) + 1) = ? || '/'
+	`, prefix, prefix, prefix, prefix)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// storeCacheEntry replaces the cache metadata of a resource, stamping it with the current time
+func storeCacheEntry(ctx context.Context, exec execer, entry CacheEntry) error {
+	_, err := exec.ExecContext(ctx, `
+		INSERT INTO cache_entries (kind, key, fetched_at, total_count, etag, outcome)
+		VALUES (?, ?, strftime('%Y-%m-%d %H:%M:%f', 'now'), ?, ?, ?)
+		ON CONFLICT(kind, key) DO UPDATE SET
+			fetched_at = excluded.fetched_at, total_count = excluded.total_count,
+			etag = excluded.etag, outcome = excluded.outcome, invalidated = 0
+	`, entry.Kind, entry.Key, entry.TotalCount, entry.ETag, entry.Outcome)
+	return err
+}
+
+// invalidateCacheEntry marks a resource to be fetched again
+func invalidateCacheEntry(ctx context.Context, exec execer, kind, key string) error {
+	_, err := exec.ExecContext(ctx, "UPDATE cache_entries SET invalidated = 1 WHERE kind = ? AND key = ?", kind, key)
+	return err
+}
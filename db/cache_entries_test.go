@@ -0,0 +1,148 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ustawka/db"
+	"ustawka/sejm"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheEntriesOfStoredResources(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	// A year without acts is cached as empty
+	_, err := database.StoreActs(ctx, sejm.PublisherDU, 2026, nil)
+	require.NoError(t, err)
+	entry, err := database.GetCacheEntry(ctx, db.CacheKindActs, db.YearKey(sejm.PublisherDU, 2026))
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.Equal(t, db.OutcomeEmpty, entry.Outcome)
+	assert.Zero(t, entry.TotalCount)
+
+	details := &sejm.ActDetails{ID: "DU/2024/1", Title: "Ustawa", Publisher: sejm.PublisherDU, Year: 2024, Position: 1, ETag: `"d1"`}
+	require.NoError(t, database.StoreActDetails(ctx, details))
+	entry, err = database.GetCacheEntry(ctx, db.CacheKindDetails, "DU/2024/1")
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.Equal(t, db.OutcomeOK, entry.Outcome)
+	assert.Equal(t, `"d1"`, entry.ETag)
+
+	text := db.ActText{ActID: "DU/2024/1", Name: sejm.TextPDF, ContentType: "application/pdf", Hash: "abc", Size: 3, ETag: `"t1"`}
+	require.NoError(t, database.StoreActText(ctx, text))
+	entry, err = database.GetCacheEntry(ctx, db.CacheKindTexts, db.TextKey("DU/2024/1", sejm.TextPDF))
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.Equal(t, `"t1"`, entry.ETag)
+}
+
+func TestStoreAndTouchCacheEntry(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	// Missing resources are remembered without any cached data
+	missing := db.CacheEntry{Kind: db.CacheKindDetails, Key: "DU/2024/9999", Outcome: db.OutcomeNotFound}
+	require.NoError(t, database.StoreCacheEntry(ctx, missing))
+	entry, err := database.GetCacheEntry(ctx, db.CacheKindDetails, "DU/2024/9999")
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.Equal(t, db.OutcomeNotFound, entry.Outcome)
+
+	touched, err := database.TouchCacheEntry(ctx, db.CacheKindDetails, "DU/2024/9999")
+	require.NoError(t, err)
+	assert.True(t, touched)
+
+	touched, err = database.TouchCacheEntry(ctx, db.CacheKindActs, db.YearKey(sejm.PublisherDU, 2023))
+	require.NoError(t, err)
+	assert.False(t, touched)
+
+	entry, err = database.GetCacheEntry(ctx, db.CacheKindDetails, "DU/2024/9999")
+	require.NoError(t, err)
+	assert.Less(t, entry.Age(), time.Second)
+}
+
+func TestInvalidateCacheEntries(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	for _, entry := range []db.CacheEntry{
+		{Kind: db.CacheKindActs, Key: "DU/2024", Outcome: db.OutcomeOK},
+		{Kind: db.CacheKindDetails, Key: "DU/2024/1", Outcome: db.OutcomeOK},
+		{Kind: db.CacheKindDetails, Key: "DU/2024/10", Outcome: db.OutcomeOK},
+		{Kind: db.CacheKindTexts, Key: "DU/2024/1/text.pdf", Outcome: db.OutcomeOK},
+		{Kind: db.CacheKindDetails, Key: "DU/2023/1", Outcome: db.OutcomeOK},
+	} {
+		require.NoError(t, database.StoreCacheEntry(ctx, entry))
+	}
+
+	invalidated := func(kind, key string) bool {
+		entry, err := database.GetCacheEntry(ctx, kind, key)
+		require.NoError(t, err)
+		require.NotNil(t, entry)
+		return entry.Invalidated
+	}
+
+	// An act covers its texts but not acts sharing a key prefix
+	count, err := database.InvalidateCacheEntries(ctx, "DU/2024/1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	assert.True(t, invalidated(db.CacheKindDetails, "DU/2024/1"))
+	assert.True(t, invalidated(db.CacheKindTexts, "DU/2024/1/text.pdf"))
+	assert.False(t, invalidated(db.CacheKindDetails, "DU/2024/10"))
+
+	// A year covers its listing and acts
+	count, err = database.InvalidateCacheEntries(ctx, "DU/2024")
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), count)
+	assert.True(t, invalidated(db.CacheKindActs, "DU/2024"))
+	assert.False(t, invalidated(db.CacheKindDetails, "DU/2023/1"))
+
+	// Fetching a resource again makes it valid
+	_, err = database.TouchCacheEntry(ctx, db.CacheKindActs, "DU/2024")
+	require.NoError(t, err)
+	assert.False(t, invalidated(db.CacheKindActs, "DU/2024"))
+	require.NoError(t, database.StoreActDetails(ctx, &sejm.ActDetails{ID: "DU/2024/1", Publisher: sejm.PublisherDU, Year: 2024, Position: 1}))
+	assert.False(t, invalidated(db.CacheKindDetails, "DU/2024/1"))
+
+	count, err = database.InvalidateCacheEntries(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), count)
+	assert.True(t, invalidated(db.CacheKindDetails, "DU/2023/1"))
+}
+
+func TestStoreActsInvalidatesDetailsOfChangedStatus(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	act := sejm.Act{ID: "DU/2024/1", Publisher: sejm.PublisherDU, Title: "Ustawa", Status: "obowiązujący", Position: 1, Year: 2024}
+	other := sejm.Act{ID: "DU/2024/2", Publisher: sejm.PublisherDU, Title: "Ustawa", Status: "obowiązujący", Position: 2, Year: 2024}
+	_, err := database.StoreActs(ctx, sejm.PublisherDU, 2024, []sejm.Act{act, other})
+	require.NoError(t, err)
+	for _, a := range []sejm.Act{act, other} {
+		require.NoError(t, database.StoreActDetails(ctx, &sejm.ActDetails{ID: a.ID, Status: a.Status, Publisher: a.Publisher, Year: a.Year, Position: a.Position}))
+	}
+
+	// Only the act whose status changed in the listing has its details invalidated
+	act.Status = "uchylony"
+	other.Title = "Ustawa o zmianie"
+	_, err = database.StoreActs(ctx, sejm.PublisherDU, 2024, []sejm.Act{act, other})
+	require.NoError(t, err)
+
+	entry, err := database.GetCacheEntry(ctx, db.CacheKindDetails, act.ID)
+	require.NoError(t, err)
+	assert.True(t, entry.Invalidated)
+	entry, err = database.GetCacheEntry(ctx, db.CacheKindDetails, other.ID)
+	require.NoError(t, err)
+	assert.False(t, entry.Invalidated)
+}
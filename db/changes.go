@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"ustawka/sejm"
+)
+
+// ActChange records a field of an act that changed between two syncs
+type ActChange struct {
+	ActID      string    `json:"actId"`
+	Field      string    `json:"field"`
+	OldValue   string    `json:"oldValue"`
+	NewValue   string    `json:"newValue"`
+	DetectedAt time.Time `json:"detectedAt"`
+}
+
+// trackedFields lists the act fields whose changes are recorded in the history
+var trackedFields = []struct {
+	name  string
+	value func(sejm.Act) string
+}{
+	{"status", func(a sejm.Act) string { return a.Status }},
+	{"title", func(a sejm.Act) string { return a.Title }},
+	{"published", func(a sejm.Act) string { return a.Published }},
+	{"type", func(a sejm.Act) string { return a.Type }},
+	{"address", func(a sejm.Act) string { return a.Address }},
+}
+
+// DiffActs returns changes of tracked fields between the cached and fetched version of an act
+func DiffActs(previous, current sejm.Act) []ActChange {
+	var changes []ActChange
+	for _, field := range trackedFields {
+		oldValue, newValue := field.value(previous), field.value(current)
+		if oldValue != newValue {
+			changes = append(changes, ActChange{
+				ActID:    current.ID,
+				Field:    field.name,
+				OldValue: oldValue,
+				NewValue: newValue,
+			})
+		}
+	}
+	return changes
+}
+
+// insertChanges appends detected changes to the history
+func (*DB) insertChanges(ctx context.Context, tx *sql.Tx, changes []ActChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO act_changes (act_id, field, old_value, new_value, detected_at)
+		VALUES (?, ?, ?, ?, strftime('%Y-%m-%d %H:%M:%f', 'now'))
+	`)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := stmt.Close(); err != nil {
+			slog.Error("Error closing statement", "error", err)
+		}
+	}()
+
+	for _, change := range changes {
+		if _, err := stmt.ExecContext(ctx, change.ActID, change.Field, change.OldValue, change.NewValue); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetActChanges returns the recorded history of an act, most recent first
+func (db *DB) GetActChanges(ctx context.Context, id sejm.ELI) (_ []ActChange, err error) {
+	ctx, end := startQuery(ctx, "get_act_changes")
+	defer end(&err)
+
+	query := `SELECT act_id, field, old_value, new_value, detected_at
+			  FROM act_changes WHERE act_id = ? ORDER BY detected_at DESC, id DESC`
+
+	rows, err := db.QueryContext(ctx, query, id.String())
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("Error closing rows", "error", err)
+		}
+	}()
+
+	changes := make([]ActChange, 0)
+	for rows.Next() {
+		var change ActChange
+		var detectedAt string
+		if err := rows.Scan(&change.ActID, &change.Field, &change.OldValue, &change.NewValue, &detectedAt); err != nil {
+			return nil, err
+		}
+		if change.DetectedAt, err = time.Parse(timestampLayout, detectedAt); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
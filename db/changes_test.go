@@ -0,0 +1,66 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+
+	"ustawka/sejm"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreActsRecordsChanges(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	act := sejm.Act{
+		ID:        "DU/2024/1",
+		Publisher: sejm.PublisherDU,
+		Title:     "Test Act 1",
+		Status:    "obowiązujący",
+		Published: "2024-01-01",
+		Position:  1,
+		Year:      2024,
+		Type:      "Ustawa",
+		Address:   "WDU20240000001",
+	}
+	dropped := act
+	dropped.ID, dropped.Position, dropped.Address = "DU/2024/2", 2, "WDU20240000002"
+
+	// The first sync only fills the cache
+	_, err := database.StoreActs(ctx, sejm.PublisherDU, 2024, []sejm.Act{act, dropped})
+	require.NoError(t, err)
+	changes, err := database.GetActChanges(ctx, sejm.ELI{Publisher: act.Publisher, Year: act.Year, Position: act.Position})
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	// A repeal and a new title are recorded, unchanged fields are not
+	repealed := act
+	repealed.Status = "uchylony"
+	repealed.Title = "Test Act 1 (uchylony)"
+	_, err = database.StoreActs(ctx, sejm.PublisherDU, 2024, []sejm.Act{repealed})
+	require.NoError(t, err)
+
+	changes, err = database.GetActChanges(ctx, sejm.ELI{Publisher: act.Publisher, Year: act.Year, Position: act.Position})
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Equal(t, "status", changes[1].Field)
+	assert.Equal(t, "obowiązujący", changes[1].OldValue)
+	assert.Equal(t, "uchylony", changes[1].NewValue)
+	assert.Equal(t, "title", changes[0].Field)
+	assert.False(t, changes[0].DetectedAt.IsZero())
+
+	// Storing the same listing again adds nothing
+	_, err = database.StoreActs(ctx, sejm.PublisherDU, 2024, []sejm.Act{repealed})
+	require.NoError(t, err)
+	changes, err = database.GetActChanges(ctx, sejm.ELI{Publisher: act.Publisher, Year: act.Year, Position: act.Position})
+	require.NoError(t, err)
+	assert.Len(t, changes, 2)
+
+	// Acts missing from the listing are dropped from the cache
+	acts, err := database.GetActs(ctx, sejm.PublisherDU, 2024)
+	require.NoError(t, err)
+	assert.Equal(t, []sejm.Act{repealed}, acts)
+}
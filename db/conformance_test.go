@@ -0,0 +1,20 @@
+package db_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"ustawka/db"
+	"ustawka/db/dbtest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConformance(t *testing.T) {
+	dbtest.Run(t, func(t *testing.T) dbtest.Store {
+		database, err := db.New(filepath.Join(t.TempDir(), "acts.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { database.Close() })
+		return database
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
@@ -13,104 +14,59 @@ import (
 	_ "github.com/mattn/go-sqlite3" // SQLite driver
 )
 
+// timestampLayout matches timestamps formatted with strftime('%Y-%m-%d %H:%M:%f')
+const timestampLayout = "2006-01-02 15:04:05.999999999"
+
 // DB represents the database connection
 type DB struct {
 	*sql.DB
 }
 
-// New creates a new database connection
+// New opens the database, applying pending schema migrations
 func New(dbPath string) (*DB, error) {
-	db, err := sql.Open("sqlite3", dbPath)
+	db, err := Open(dbPath)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := db.Ping(); err != nil {
+	if _, err := db.Migrate(context.Background()); err != nil {
+		_ = db.Close()
 		return nil, err
 	}
 
-	if err := createTables(db); err != nil {
+	// The search index is kept out of the migrations and rebuilt whenever it is out of sync
+	if err := createSearchIndex(db.DB); err != nil {
+		_ = db.Close()
 		return nil, err
 	}
 
-	return &DB{db}, nil
+	return db, nil
 }
 
-// createTables creates the necessary tables if they don't exist
-func createTables(db *sql.DB) error {
-	queries := []string{
-		`CREATE TABLE IF NOT EXISTS acts (
-			id TEXT PRIMARY KEY,
-			title TEXT NOT NULL,
-			status TEXT NOT NULL,
-			published TEXT NOT NULL,
-			position INTEGER NOT NULL,
-			year INTEGER NOT NULL,
-			type TEXT NOT NULL,
-			address TEXT NOT NULL,
-			created_at TEXT NOT NULL DEFAULT (datetime('now')),
-			updated_at TEXT NOT NULL DEFAULT (datetime('now'))
-		)`,
-		`CREATE TABLE IF NOT EXISTS act_details (
-			id TEXT PRIMARY KEY,
-			title TEXT NOT NULL,
-			status TEXT NOT NULL,
-			published TEXT NOT NULL,
-			type TEXT NOT NULL,
-			address TEXT NOT NULL,
-			display_address TEXT NOT NULL,
-			position INTEGER NOT NULL,
-			year INTEGER NOT NULL,
-			announcement_date TEXT,
-			change_date TEXT,
-			publisher TEXT,
-			text_html BOOLEAN,
-			text_pdf BOOLEAN,
-			volume INTEGER,
-			entry_into_force TEXT,
-			in_force TEXT,
-			keywords TEXT,
-			keywords_names TEXT,
-			released_by TEXT,
-			texts TEXT,
-			act_references TEXT,
-			authorized_body TEXT,
-			directives TEXT,
-			obligated TEXT,
-			previous_title TEXT,
-			prints TEXT,
-			created_at TEXT NOT NULL DEFAULT (datetime('now')),
-			updated_at TEXT NOT NULL DEFAULT (datetime('now'))
-		)`,
-		`CREATE INDEX IF NOT EXISTS idx_acts_year ON acts(year)`,
-		`CREATE INDEX IF NOT EXISTS idx_acts_status ON acts(status)`,
-		`CREATE TRIGGER IF NOT EXISTS update_acts_timestamp 
-		AFTER UPDATE ON acts
-		BEGIN
-			UPDATE acts SET updated_at = datetime('now') WHERE id = NEW.id;
-		END`,
-		`CREATE TRIGGER IF NOT EXISTS update_act_details_timestamp 
-		AFTER UPDATE ON act_details
-		BEGIN
-			UPDATE act_details SET updated_at = datetime('now') WHERE id = NEW.id;
-		END`,
-	}
-
-	for _, query := range queries {
-		if _, err := db.Exec(query); err != nil {
-			return err
-		}
+// Open opens the database without changing its schema
+func Open(dbPath string) (*DB, error) {
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &DB{db}, nil
 }
 
-// GetActs retrieves acts for a specific year from the cache
-func (db *DB) GetActs(ctx context.Context, year int) ([]sejm.Act, error) {
-	query := `SELECT id, title, status, published, position, year, type, address 
-			  FROM acts WHERE year = ? ORDER BY position`
+// GetActs retrieves acts of a publisher for a specific year from the cache
+func (db *DB) GetActs(ctx context.Context, publisher string, year int) (_ []sejm.Act, err error) {
+	ctx, end := startQuery(ctx, "get_acts")
+	defer end(&err)
+
+	query := `SELECT id, publisher, title, status, published, position, year, type, address
+			  FROM acts WHERE publisher = ? AND year = ? ORDER BY position`
 
-	rows, err := db.QueryContext(ctx, query, year)
+	rows, err := db.QueryContext(ctx, query, publisher, year)
 	if err != nil {
 		return nil, err
 	}
@@ -125,6 +81,7 @@ func (db *DB) GetActs(ctx context.Context, year int) ([]sejm.Act, error) {
 		var act sejm.Act
 		if err := rows.Scan(
 			&act.ID,
+			&act.Publisher,
 			&act.Title,
 			&act.Status,
 			&act.Published,
@@ -141,36 +98,122 @@ func (db *DB) GetActs(ctx context.Context, year int) ([]sejm.Act, error) {
 	return acts, rows.Err()
 }
 
-// StoreActs stores acts for a specific year in the cache
-func (db *DB) StoreActs(ctx context.Context, year int, acts []sejm.Act) error {
+// StoreActs stores acts of a publisher for a specific year in the cache, recording
+// changes of acts that were already cached in the history and returning them
+func (db *DB) StoreActs(ctx context.Context, publisher string, year int, acts []sejm.Act) (_ []ActChange, err error) {
+	ctx, end := startQuery(ctx, "store_acts")
+	defer end(&err)
+
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer func() {
-		if err := tx.Rollback(); err != nil {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
 			slog.Error("Error rolling back transaction", "error", err)
 		}
 	}()
 
-	// Delete existing acts for the year
-	if _, err := tx.ExecContext(ctx, "DELETE FROM acts WHERE year = ?", year); err != nil {
-		return err
+	cached, err := db.cachedActs(ctx, tx, publisher, year)
+	if err != nil {
+		return nil, err
 	}
 
-	// Insert new acts
-	if err := db.insertActs(ctx, tx, acts); err != nil {
-		return err
+	var changes []ActChange
+	for _, act := range acts {
+		if previous, ok := cached[act.ID]; ok {
+			changes = append(changes, DiffActs(previous, act)...)
+			delete(cached, act.ID)
+		}
 	}
 
-	return tx.Commit()
+	// Acts no longer listed for the year are dropped from the cache
+	for id := range cached {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM acts WHERE id = ?", id); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := db.upsertActs(ctx, tx, publisher, acts); err != nil {
+		return nil, err
+	}
+
+	if err := db.insertChanges(ctx, tx, changes); err != nil {
+		return nil, err
+	}
+
+	// Cached details of acts whose status changed no longer match the listing
+	for _, change := range changes {
+		if change.Field != "status" {
+			continue
+		}
+		if err := invalidateCacheEntry(ctx, tx, CacheKindDetails, change.ActID); err != nil {
+			return nil, err
+		}
+	}
+
+	outcome := OutcomeOK
+	if len(acts) == 0 {
+		outcome = OutcomeEmpty
+	}
+	entry := CacheEntry{Kind: CacheKindActs, Key: YearKey(publisher, year), TotalCount: len(acts), Outcome: outcome}
+	if err := storeCacheEntry(ctx, tx, entry); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return changes, nil
 }
 
-// insertActs inserts acts using a prepared statement
-func (*DB) insertActs(ctx context.Context, tx *sql.Tx, acts []sejm.Act) error {
+// cachedActs loads acts of a publisher's year within a transaction, keyed by ID
+func (*DB) cachedActs(ctx context.Context, tx *sql.Tx, publisher string, year int) (map[string]sejm.Act, error) {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT id, publisher, title, status, published, position, year, type, address
+		 FROM acts WHERE publisher = ? AND year = ?`,
+		publisher, year,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("Error closing rows", "error", err)
+		}
+	}()
+
+	acts := make(map[string]sejm.Act)
+	for rows.Next() {
+		var act sejm.Act
+		if err := rows.Scan(
+			&act.ID,
+			&act.Publisher,
+			&act.Title,
+			&act.Status,
+			&act.Published,
+			&act.Position,
+			&act.Year,
+			&act.Type,
+			&act.Address,
+		); err != nil {
+			return nil, err
+		}
+		acts[act.ID] = act
+	}
+
+	return acts, rows.Err()
+}
+
+// upsertActs inserts or updates acts using a prepared statement
+func (*DB) upsertActs(ctx context.Context, tx *sql.Tx, publisher string, acts []sejm.Act) error {
 	stmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO acts (id, title, status, published, position, year, type, address, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, datetime('now'))
+		INSERT INTO acts (id, publisher, title, status, published, position, year, type, address, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, datetime('now'))
+		ON CONFLICT(id) DO UPDATE SET
+			publisher = excluded.publisher, title = excluded.title, status = excluded.status,
+			published = excluded.published, position = excluded.position, year = excluded.year,
+			type = excluded.type, address = excluded.address, updated_at = datetime('now')
 	`)
 	if err != nil {
 		return err
@@ -183,7 +226,7 @@ func (*DB) insertActs(ctx context.Context, tx *sql.Tx, acts []sejm.Act) error {
 
 	for _, act := range acts {
 		if _, err := stmt.ExecContext(ctx,
-			act.ID, act.Title, act.Status, act.Published,
+			act.ID, publisher, act.Title, act.Status, act.Published,
 			act.Position, act.Year, act.Type, act.Address,
 		); err != nil {
 			return err
@@ -194,8 +237,11 @@ func (*DB) insertActs(ctx context.Context, tx *sql.Tx, acts []sejm.Act) error {
 }
 
 // GetActDetails retrieves act details from the cache
-func (db *DB) GetActDetails(ctx context.Context, actID string) (*sejm.ActDetails, error) {
-	details, jsonStrings, err := db.scanActDetails(ctx, actID)
+func (db *DB) GetActDetails(ctx context.Context, id sejm.ELI) (_ *sejm.ActDetails, err error) {
+	ctx, end := startQuery(ctx, "get_act_details")
+	defer end(&err)
+
+	details, jsonStrings, err := db.scanActDetails(ctx, id.String())
 	if err != nil {
 		return nil, err
 	}
@@ -206,20 +252,30 @@ func (db *DB) GetActDetails(ctx context.Context, actID string) (*sejm.ActDetails
 	return db.parseJSONFields(details, jsonStrings)
 }
 
-// scanActDetails scans basic fields and JSON strings from database
-func (db *DB) scanActDetails(ctx context.Context, actID string) (*sejm.ActDetails, map[string]string, error) {
-	query := `SELECT id, title, status, published, type, address, display_address, position, year,
+// detailsQuery selects the columns of act_details scanned by scanActDetailsRow
+const detailsQuery = `SELECT id, title, status, published, type, address, display_address, position, year,
 			  announcement_date, change_date, publisher, text_html, text_pdf, volume,
 			  entry_into_force, in_force, keywords, keywords_names, released_by, texts,
 			  act_references, authorized_body, directives, obligated, previous_title, prints
-			  FROM act_details WHERE id = ?`
+			  FROM act_details`
+
+// scanActDetails scans basic fields and JSON strings from database
+func (db *DB) scanActDetails(ctx context.Context, actID string) (*sejm.ActDetails, map[string]string, error) {
+	details, jsonStrings, err := scanActDetailsRow(db.QueryRowContext(ctx, detailsQuery+" WHERE id = ?", actID))
+	if err == sql.ErrNoRows {
+		return nil, nil, nil
+	}
+	return details, jsonStrings, err
+}
 
+// scanActDetailsRow scans basic fields and JSON strings of a row selected by detailsQuery
+func scanActDetailsRow(row interface{ Scan(dest ...any) error }) (*sejm.ActDetails, map[string]string, error) {
 	var details sejm.ActDetails
 	jsonStrings := make(map[string]string)
 	var keywords, keywordsNames, releasedBy, texts, actReferences string
 	var authorizedBody, directives, obligated, previousTitle, prints string
 
-	err := db.QueryRowContext(ctx, query, actID).Scan(
+	err := row.Scan(
 		&details.ID, &details.Title, &details.Status, &details.Published,
 		&details.Type, &details.Address, &details.DisplayAddress, &details.Position,
 		&details.Year, &details.AnnouncementDate, &details.ChangeDate, &details.Publisher,
@@ -227,9 +283,6 @@ func (db *DB) scanActDetails(ctx context.Context, actID string) (*sejm.ActDetail
 		&details.InForce, &keywords, &keywordsNames, &releasedBy, &texts,
 		&actReferences, &authorizedBody, &directives, &obligated, &previousTitle, &prints,
 	)
-	if err == sql.ErrNoRows {
-		return nil, nil, nil
-	}
 	if err != nil {
 		return nil, nil, err
 	}
@@ -277,19 +330,76 @@ func (*DB) parseJSONFields(details *sejm.ActDetails, jsonStrings map[string]stri
 }
 
 // StoreActDetails stores act details in the cache
-func (db *DB) StoreActDetails(ctx context.Context, details *sejm.ActDetails) error {
+func (db *DB) StoreActDetails(ctx context.Context, details *sejm.ActDetails) (err error) {
+	ctx, end := startQuery(ctx, "store_act_details")
+	defer end(&err)
+
 	jsonStrings, err := db.marshalJSONFields(details)
 	if err != nil {
 		return err
 	}
 
-	return db.executeStoreQuery(ctx, details, jsonStrings)
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			slog.Error("Error rolling back transaction", "error", err)
+		}
+	}()
+
+	if err := db.executeStoreQuery(ctx, tx, details, jsonStrings); err != nil {
+		return err
+	}
+
+	if err := storeLinks(ctx, tx, details); err != nil {
+		return err
+	}
+
+	entry := CacheEntry{Kind: CacheKindDetails, Key: details.ID, TotalCount: 1, ETag: details.ETag, Outcome: OutcomeOK}
+	if err := storeCacheEntry(ctx, tx, entry); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteActDetails removes the cached details and references of an act the API no longer
+// has, recording it as not found
+func (db *DB) DeleteActDetails(ctx context.Context, id string) (err error) {
+	ctx, end := startQuery(ctx, "delete_act_details")
+	defer end(&err)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			slog.Error("Error rolling back transaction", "error", err)
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM act_details WHERE id = ?", id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM act_links WHERE source = ?", id); err != nil {
+		return err
+	}
+
+	entry := CacheEntry{Kind: CacheKindDetails, Key: id, Outcome: OutcomeNotFound}
+	if err := storeCacheEntry(ctx, tx, entry); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // marshalJSONFields converts struct fields to JSON strings
 func (*DB) marshalJSONFields(details *sejm.ActDetails) (map[string]string, error) {
 	jsonStrings := make(map[string]string)
-	
+
 	fields := map[string]any{
 		"keywords":       details.Keywords,
 		"keywordsNames":  details.KeywordsNames,
@@ -315,7 +425,7 @@ func (*DB) marshalJSONFields(details *sejm.ActDetails) (map[string]string, error
 }
 
 // executeStoreQuery executes the upsert query for act details
-func (db *DB) executeStoreQuery(ctx context.Context, details *sejm.ActDetails, jsonStrings map[string]string) error {
+func (*DB) executeStoreQuery(ctx context.Context, tx *sql.Tx, details *sejm.ActDetails, jsonStrings map[string]string) error {
 	query := `
 		INSERT INTO act_details (
 			id, title, status, published, type, address, display_address, position, year,
@@ -338,7 +448,7 @@ func (db *DB) executeStoreQuery(ctx context.Context, details *sejm.ActDetails, j
 			previous_title = excluded.previous_title, prints = excluded.prints, updated_at = datetime('now')
 	`
 
-	_, err := db.ExecContext(ctx, query,
+	_, err := tx.ExecContext(ctx, query,
 		details.ID, details.Title, details.Status, details.Published, details.Type,
 		details.Address, details.DisplayAddress, details.Position, details.Year,
 		details.AnnouncementDate, details.ChangeDate, details.Publisher,
@@ -350,21 +460,9 @@ func (db *DB) executeStoreQuery(ctx context.Context, details *sejm.ActDetails, j
 	return err
 }
 
-// GetCacheAge returns the age of the cache for a specific year
-func (db *DB) GetCacheAge(ctx context.Context, year int) (time.Duration, error) {
-	var updatedAt sql.NullString
-	err := db.QueryRowContext(ctx,
-		"SELECT strftime('%Y-%m-%d %H:%M:%f', MAX(updated_at)) FROM acts WHERE year = ?",
-		year,
-	).Scan(&updatedAt)
-	if err == sql.ErrNoRows || !updatedAt.Valid {
-		return 0, nil
-	}
-	if err != nil {
-		return 0, err
-	}
-
-	t, err := time.Parse("2006-01-02 15:04:05.999999999", updatedAt.String)
+// sinceTimestamp returns the time elapsed since a timestamp formatted by strftime
+func sinceTimestamp(timestamp string) (time.Duration, error) {
+	t, err := time.Parse(timestampLayout, timestamp)
 	if err != nil {
 		return 0, err
 	}
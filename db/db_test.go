@@ -2,6 +2,7 @@ package db_test
 
 import (
 	"context"
+	"database/sql"
 	"os"
 	"testing"
 	"time"
@@ -12,6 +13,14 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// mustParseELI parses an act ID used in a test
+func mustParseELI(t *testing.T, value string) sejm.ELI {
+	t.Helper()
+	id, err := sejm.ParseELI(value)
+	require.NoError(t, err)
+	return id
+}
+
 func setupTestDB(t *testing.T) (*db.DB, func()) {
 	t.Helper()
 	// Create a temporary database file
@@ -41,6 +50,7 @@ func TestStoreAndGetActs(t *testing.T) {
 	acts := []sejm.Act{
 		{
 			ID:        "DU/2024/1",
+			Publisher: sejm.PublisherDU,
 			Title:     "Test Act 1",
 			Status:    "obowiązujący",
 			Published: "2024-01-01",
@@ -51,6 +61,7 @@ func TestStoreAndGetActs(t *testing.T) {
 		},
 		{
 			ID:        "DU/2024/2",
+			Publisher: sejm.PublisherDU,
 			Title:     "Test Act 2",
 			Status:    "uchylony",
 			Published: "2024-01-02",
@@ -62,21 +73,89 @@ func TestStoreAndGetActs(t *testing.T) {
 	}
 
 	// Store acts
-	err := database.StoreActs(ctx, year, acts)
+	_, err := database.StoreActs(ctx, sejm.PublisherDU, year, acts)
 	require.NoError(t, err)
 
 	// Retrieve acts
-	retrieved, err := database.GetActs(ctx, year)
+	retrieved, err := database.GetActs(ctx, sejm.PublisherDU, year)
 	require.NoError(t, err)
 	assert.Len(t, retrieved, 2)
 	assert.Equal(t, acts, retrieved)
 
 	// Test cache age
 	time.Sleep(time.Millisecond) // Ensure some time has passed
-	age, err := database.GetCacheAge(ctx, year)
+	entry, err := database.GetCacheEntry(ctx, db.CacheKindActs, db.YearKey(sejm.PublisherDU, year))
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.True(t, entry.Age() > 0)
+	assert.True(t, entry.Age() < time.Second)
+	assert.Equal(t, db.OutcomeOK, entry.Outcome)
+	assert.Equal(t, 2, entry.TotalCount)
+}
+
+func TestActsArePartitionedByPublisher(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	du := []sejm.Act{{ID: "DU/2024/1", Publisher: sejm.PublisherDU, Title: "Ustawa", Position: 1, Year: 2024}}
+	mp := []sejm.Act{{ID: "MP/2024/1", Publisher: sejm.PublisherMP, Title: "Obwieszczenie", Position: 1, Year: 2024}}
+
+	_, err := database.StoreActs(ctx, sejm.PublisherDU, 2024, du)
+	require.NoError(t, err)
+	_, err = database.StoreActs(ctx, sejm.PublisherMP, 2024, mp)
+	require.NoError(t, err)
+
+	// Replacing one publisher's year must not touch the other
+	_, err = database.StoreActs(ctx, sejm.PublisherMP, 2024, mp)
+	require.NoError(t, err)
+
+	retrieved, err := database.GetActs(ctx, sejm.PublisherDU, 2024)
+	require.NoError(t, err)
+	assert.Equal(t, du, retrieved)
+
+	retrieved, err = database.GetActs(ctx, sejm.PublisherMP, 2024)
+	require.NoError(t, err)
+	assert.Equal(t, mp, retrieved)
+
+	entry, err := database.GetCacheEntry(ctx, db.CacheKindActs, db.YearKey(sejm.PublisherMP, 2023))
+	require.NoError(t, err)
+	assert.Nil(t, entry)
+}
+
+func TestNewAddsPublisherToLegacyActs(t *testing.T) {
+	tmpfile, err := os.CreateTemp("", "testdb-*.db")
+	require.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+
+	legacy, err := sql.Open("sqlite3", tmpfile.Name())
+	require.NoError(t, err)
+	_, err = legacy.Exec(`CREATE TABLE acts (
+		id TEXT PRIMARY KEY, title TEXT NOT NULL, status TEXT NOT NULL, published TEXT NOT NULL,
+		position INTEGER NOT NULL, year INTEGER NOT NULL, type TEXT NOT NULL, address TEXT NOT NULL,
+		created_at TEXT NOT NULL DEFAULT (datetime('now')), updated_at TEXT NOT NULL DEFAULT (datetime('now'))
+	)`)
+	require.NoError(t, err)
+	_, err = legacy.Exec(`INSERT INTO acts (id, title, status, published, position, year, type, address)
+		VALUES ('DU/2020/1', 'Legacy', 'obowiązujący', '2020-01-01', 1, 2020, 'Ustawa', 'WDU20200000001')`)
+	require.NoError(t, err)
+	require.NoError(t, legacy.Close())
+
+	database, err := db.New(tmpfile.Name())
+	require.NoError(t, err)
+	defer database.Close()
+
+	acts, err := database.GetActs(context.Background(), sejm.PublisherDU, 2020)
+	require.NoError(t, err)
+	require.Len(t, acts, 1)
+	assert.Equal(t, sejm.PublisherDU, acts[0].Publisher)
+
+	// Cached years get their cache metadata from the acts
+	entry, err := database.GetCacheEntry(context.Background(), db.CacheKindActs, "DU/2020")
 	require.NoError(t, err)
-	assert.True(t, age > 0)
-	assert.True(t, age < time.Second)
+	require.NotNil(t, entry)
+	assert.Equal(t, 1, entry.TotalCount)
+	assert.Less(t, entry.Age(), time.Minute)
 }
 
 func TestStoreAndGetActDetails(t *testing.T) {
@@ -98,12 +177,12 @@ func TestStoreAndGetActDetails(t *testing.T) {
 	require.NoError(t, err)
 
 	// Retrieve details
-	retrieved, err := database.GetActDetails(ctx, details.ID)
+	retrieved, err := database.GetActDetails(ctx, mustParseELI(t, details.ID))
 	require.NoError(t, err)
 	assert.Equal(t, details, retrieved)
 
 	// Test non-existent details
-	nonExistent, err := database.GetActDetails(ctx, "DU/2024/999")
+	nonExistent, err := database.GetActDetails(ctx, mustParseELI(t, "DU/2024/999"))
 	require.NoError(t, err)
 	assert.Nil(t, nonExistent)
 }
@@ -138,7 +217,7 @@ func TestUpdateActDetails(t *testing.T) {
 	require.NoError(t, err)
 
 	// Retrieve updated details
-	retrieved, err := database.GetActDetails(ctx, details.ID)
+	retrieved, err := database.GetActDetails(ctx, mustParseELI(t, details.ID))
 	require.NoError(t, err)
 	assert.Equal(t, updated, retrieved)
 }
@@ -0,0 +1,683 @@
+// Package dbtest is a conformance suite run against each storage backend, so that
+// the service behaves the same whichever database it is configured with.
+package dbtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"ustawka/acttext"
+	"ustawka/db"
+	"ustawka/notify"
+	"ustawka/sejm"
+	"ustawka/service"
+	"ustawka/snapshot"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Store is a storage backend of the server
+type Store interface {
+	service.Database
+	notify.Store
+	sejm.ValidatorStore
+	snapshot.Source
+	snapshot.Target
+	GetSnapshot(ctx context.Context) (*db.Snapshot, error)
+}
+
+// Run runs the suite, opening an empty store for each test
+func Run(t *testing.T, open func(t *testing.T) Store) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, store Store)
+	}{
+		{"Acts", testActs},
+		{"ActChanges", testActChanges},
+		{"ActDetails", testActDetails},
+		{"CacheEntries", testCacheEntries},
+		{"InvalidateCacheEntries", testInvalidateCacheEntries},
+		{"SearchActs", testSearchActs},
+		{"SearchResults", testSearchResults},
+		{"Links", testLinks},
+		{"Texts", testTexts},
+		{"Documents", testDocuments},
+		{"FeedEntries", testFeedEntries},
+		{"Watches", testWatches},
+		{"Validators", testValidators},
+		{"Export", testExport},
+		{"Snapshots", testSnapshots},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, open(t))
+		})
+	}
+}
+
+// act returns an act of the 2024 DU listing
+func act(position int, title, status, published string) sejm.Act {
+	id := sejm.ELI{Publisher: sejm.PublisherDU, Year: 2024, Position: position}
+	return sejm.Act{
+		ID: id.String(), Publisher: sejm.PublisherDU, Title: title, Status: status, Published: published,
+		Position: position, Year: 2024, Type: "Ustawa", Address: fmt.Sprintf("WDU2024%07d", position),
+	}
+}
+
+// details returns act details decoded from the JSON the API sends
+func details(t *testing.T, data string) *sejm.ActDetails {
+	t.Helper()
+	var details sejm.ActDetails
+	require.NoError(t, json.Unmarshal([]byte(data), &details))
+	return &details
+}
+
+// eli parses an act ID
+func eli(t *testing.T, value string) sejm.ELI {
+	t.Helper()
+	id, err := sejm.ParseELI(value)
+	require.NoError(t, err)
+	return id
+}
+
+func testActs(t *testing.T, store Store) {
+	ctx := context.Background()
+
+	acts, err := store.GetActs(ctx, sejm.PublisherDU, 2024)
+	require.NoError(t, err)
+	assert.Empty(t, acts)
+
+	listing := []sejm.Act{
+		act(2, "Ustawa B", "obowiązujący", "2024-01-02"),
+		act(1, "Ustawa A", "obowiązujący", "2024-01-01"),
+		act(3, "Ustawa C", "uchylony", "2024-01-03"),
+	}
+	_, err = store.StoreActs(ctx, sejm.PublisherDU, 2024, listing)
+	require.NoError(t, err)
+	_, err = store.StoreActs(ctx, sejm.PublisherMP, 2024, []sejm.Act{
+		{ID: "MP/2024/1", Publisher: sejm.PublisherMP, Title: "Obwieszczenie", Position: 1, Year: 2024},
+	})
+	require.NoError(t, err)
+
+	acts, err = store.GetActs(ctx, sejm.PublisherDU, 2024)
+	require.NoError(t, err)
+	assert.Equal(t, []sejm.Act{listing[1], listing[0], listing[2]}, acts, "acts are ordered by position")
+
+	entry, err := store.GetCacheEntry(ctx, db.CacheKindActs, db.YearKey(sejm.PublisherDU, 2024))
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.Equal(t, 3, entry.TotalCount)
+	assert.Equal(t, db.OutcomeOK, entry.Outcome)
+	assert.Less(t, entry.Age(), time.Minute)
+
+	// Storing a listing again updates acts and drops those no longer listed
+	updated := act(1, "Ustawa A (zmieniona)", "obowiązujący", "2024-01-01")
+	_, err = store.StoreActs(ctx, sejm.PublisherDU, 2024, []sejm.Act{updated})
+	require.NoError(t, err)
+	acts, err = store.GetActs(ctx, sejm.PublisherDU, 2024)
+	require.NoError(t, err)
+	assert.Equal(t, []sejm.Act{updated}, acts)
+
+	acts, err = store.GetActs(ctx, sejm.PublisherMP, 2024)
+	require.NoError(t, err)
+	assert.Len(t, acts, 1, "other publishers are kept")
+
+	_, err = store.StoreActs(ctx, sejm.PublisherDU, 2024, nil)
+	require.NoError(t, err)
+	entry, err = store.GetCacheEntry(ctx, db.CacheKindActs, db.YearKey(sejm.PublisherDU, 2024))
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.Equal(t, db.OutcomeEmpty, entry.Outcome)
+	assert.Zero(t, entry.TotalCount)
+}
+
+func testActChanges(t *testing.T, store Store) {
+	ctx := context.Background()
+	original := act(1, "Ustawa A", "akt posiada tekst jednolity", "2024-01-01")
+	_, err := store.StoreActs(ctx, sejm.PublisherDU, 2024, []sejm.Act{original})
+	require.NoError(t, err)
+	require.NoError(t, store.StoreActDetails(ctx, &sejm.ActDetails{ID: original.ID, Title: original.Title, Status: original.Status}))
+
+	changes, err := store.GetActChanges(ctx, eli(t, original.ID))
+	require.NoError(t, err)
+	assert.Empty(t, changes, "the first sync records no changes")
+
+	changed := original
+	changed.Title = "Ustawa A (zmieniona)"
+	changed.Status = "uchylony"
+	recorded, err := store.StoreActs(ctx, sejm.PublisherDU, 2024, []sejm.Act{changed})
+	require.NoError(t, err)
+	assert.Len(t, recorded, 2, "storing returns the changes it recorded")
+
+	// Storing the same listing again, as a concurrent refresh would, records nothing
+	recorded, err = store.StoreActs(ctx, sejm.PublisherDU, 2024, []sejm.Act{changed})
+	require.NoError(t, err)
+	assert.Empty(t, recorded)
+
+	changes, err = store.GetActChanges(ctx, eli(t, original.ID))
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	byField := make(map[string]db.ActChange)
+	for _, change := range changes {
+		assert.Equal(t, original.ID, change.ActID)
+		assert.WithinDuration(t, time.Now(), change.DetectedAt, time.Minute)
+		byField[change.Field] = change
+	}
+	assert.Equal(t, "akt posiada tekst jednolity", byField["status"].OldValue)
+	assert.Equal(t, "uchylony", byField["status"].NewValue)
+	assert.Equal(t, "Ustawa A (zmieniona)", byField["title"].NewValue)
+
+	// Details of an act whose status changed are fetched again
+	entry, err := store.GetCacheEntry(ctx, db.CacheKindDetails, original.ID)
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.True(t, entry.Invalidated)
+}
+
+// fullDetails is the API's JSON of act details using every stored field
+const fullDetails = `{
+	"ELI": "DU/2024/1", "title": "Ustawa o podatku", "status": "obowiązujący", "promulgation": "2024-01-01",
+	"type": "Ustawa", "address": "WDU20240000001", "displayAddress": "Dz.U. 2024 poz. 1", "pos": 1, "year": 2024,
+	"announcementDate": "2023-12-20", "changeDate": "2024-02-01T10:00:00", "publisher": "DU",
+	"textHTML": true, "textPDF": true, "volume": 0, "entryIntoForce": "2024-01-15", "inForce": "IN_FORCE",
+	"keywords": ["podatki"], "keywordsNames": ["podatek dochodowy"], "releasedBy": ["MINISTER FINANSÓW"],
+	"texts": [{"fileName": "D20240001.pdf", "type": "O"}],
+	"references": {"Akty zmienione": [{"id": "DU/2020/5", "date": "2024-01-15"}], "Podstawa prawna": [{"id": "DU/1997/483"}]},
+	"authorizedBody": ["Rada Ministrów"], "directives": [{"address": "32006L0112"}], "obligated": ["podatnicy"],
+	"previousTitle": ["Ustawa o podatkach"], "prints": [{"number": "123", "term": 10}]
+}`
+
+func testActDetails(t *testing.T, store Store) {
+	ctx := context.Background()
+	id := eli(t, "DU/2024/1")
+
+	cached, err := store.GetActDetails(ctx, id)
+	require.NoError(t, err)
+	assert.Nil(t, cached)
+
+	stored := details(t, fullDetails)
+	stored.ETag = `"v1"`
+	require.NoError(t, store.StoreActDetails(ctx, stored))
+
+	cached, err = store.GetActDetails(ctx, id)
+	require.NoError(t, err)
+	require.NotNil(t, cached)
+	expected := details(t, fullDetails)
+	assert.Equal(t, expected, cached)
+
+	entry, err := store.GetCacheEntry(ctx, db.CacheKindDetails, id.String())
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.Equal(t, `"v1"`, entry.ETag)
+	assert.Equal(t, db.OutcomeOK, entry.Outcome)
+
+	// Storing details again replaces them
+	stored.Status = "uchylony"
+	stored.Keywords = nil
+	require.NoError(t, store.StoreActDetails(ctx, stored))
+	cached, err = store.GetActDetails(ctx, id)
+	require.NoError(t, err)
+	require.NotNil(t, cached)
+	assert.Equal(t, "uchylony", cached.Status)
+	assert.Nil(t, cached.Keywords)
+
+	// Details of an act the API no longer has are removed with their references
+	links, err := store.GetActLinks(ctx, []string{id.String()})
+	require.NoError(t, err)
+	require.NotEmpty(t, links)
+	require.NoError(t, store.DeleteActDetails(ctx, id.String()))
+	cached, err = store.GetActDetails(ctx, id)
+	require.NoError(t, err)
+	assert.Nil(t, cached)
+	links, err = store.GetActLinks(ctx, []string{id.String()})
+	require.NoError(t, err)
+	assert.Empty(t, links)
+	entry, err = store.GetCacheEntry(ctx, db.CacheKindDetails, id.String())
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.Equal(t, db.OutcomeNotFound, entry.Outcome)
+}
+
+func testCacheEntries(t *testing.T, store Store) {
+	ctx := context.Background()
+
+	entry, err := store.GetCacheEntry(ctx, db.CacheKindDetails, "DU/2024/9999")
+	require.NoError(t, err)
+	assert.Nil(t, entry)
+
+	touched, err := store.TouchCacheEntry(ctx, db.CacheKindDetails, "DU/2024/9999")
+	require.NoError(t, err)
+	assert.False(t, touched, "entries that do not exist are not created")
+
+	require.NoError(t, store.StoreCacheEntry(ctx, db.CacheEntry{
+		Kind: db.CacheKindDetails, Key: "DU/2024/9999", Outcome: db.OutcomeNotFound,
+	}))
+	entry, err = store.GetCacheEntry(ctx, db.CacheKindDetails, "DU/2024/9999")
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.Equal(t, db.CacheEntry{
+		Kind: db.CacheKindDetails, Key: "DU/2024/9999", FetchedAt: entry.FetchedAt, Outcome: db.OutcomeNotFound,
+	}, *entry)
+	assert.Less(t, entry.Age(), time.Minute)
+	assert.GreaterOrEqual(t, entry.Age(), time.Duration(0))
+
+	_, err = store.InvalidateCacheEntries(ctx, "DU/2024/9999")
+	require.NoError(t, err)
+	touched, err = store.TouchCacheEntry(ctx, db.CacheKindDetails, "DU/2024/9999")
+	require.NoError(t, err)
+	assert.True(t, touched)
+	entry, err = store.GetCacheEntry(ctx, db.CacheKindDetails, "DU/2024/9999")
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.False(t, entry.Invalidated, "touching an entry validates it again")
+	assert.Equal(t, db.OutcomeNotFound, entry.Outcome)
+}
+
+func testInvalidateCacheEntries(t *testing.T, store Store) {
+	ctx := context.Background()
+	keys := map[string]string{
+		"DU/2024":            db.CacheKindActs,
+		"DU/2024/1":          db.CacheKindDetails,
+		"DU/2024/1/text.pdf": db.CacheKindTexts,
+		"DU/2024/12":         db.CacheKindDetails,
+		"DU/20245":           db.CacheKindActs,
+		"MP/2024":            db.CacheKindActs,
+	}
+	for key, kind := range keys {
+		require.NoError(t, store.StoreCacheEntry(ctx, db.CacheEntry{Kind: kind, Key: key, Outcome: db.OutcomeOK}))
+	}
+
+	invalidated := func() []string {
+		var marked []string
+		for key, kind := range keys {
+			entry, err := store.GetCacheEntry(ctx, kind, key)
+			require.NoError(t, err)
+			require.NotNil(t, entry)
+			if entry.Invalidated {
+				marked = append(marked, key)
+			}
+		}
+		return marked
+	}
+
+	count, err := store.InvalidateCacheEntries(ctx, "DU/2024/1")
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+	assert.ElementsMatch(t, []string{"DU/2024/1", "DU/2024/1/text.pdf"}, invalidated())
+
+	count, err = store.InvalidateCacheEntries(ctx, "DU/2024")
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, count)
+	assert.ElementsMatch(t, []string{"DU/2024", "DU/2024/1", "DU/2024/1/text.pdf", "DU/2024/12"}, invalidated())
+
+	// Storing an entry validates it again
+	require.NoError(t, store.StoreCacheEntry(ctx, db.CacheEntry{Kind: db.CacheKindActs, Key: "DU/2024", Outcome: db.OutcomeOK}))
+	assert.NotContains(t, invalidated(), "DU/2024")
+
+	count, err = store.InvalidateCacheEntries(ctx, "")
+	require.NoError(t, err)
+	assert.EqualValues(t, len(keys), count)
+	assert.Len(t, invalidated(), len(keys))
+}
+
+func testSearchActs(t *testing.T, store Store) {
+	ctx := context.Background()
+	_, err := store.StoreActs(ctx, sejm.PublisherDU, 2024, []sejm.Act{
+		act(1, "Ustawa o podatku dochodowym od osób fizycznych", "obowiązujący", "2024-01-01"),
+		act(2, "Rozporządzenie w sprawie cudzoziemców", "obowiązujący", "2024-01-02"),
+		act(3, "Ustawa o zmianie ustawy o podatku od towarów i usług", "obowiązujący", "2024-01-03"),
+	})
+	require.NoError(t, err)
+	require.NoError(t, store.StoreActDetails(ctx, &sejm.ActDetails{
+		ID: "DU/2024/2", Title: "Rozporządzenie w sprawie cudzoziemców", KeywordsNames: []string{"wizy"},
+	}))
+
+	search := func(query string) []string {
+		t.Helper()
+		acts, err := store.SearchActs(ctx, query, 10)
+		require.NoError(t, err)
+		require.NotNil(t, acts)
+		ids := make([]string, 0, len(acts))
+		for _, a := range acts {
+			ids = append(ids, a.ID)
+		}
+		return ids
+	}
+
+	assert.ElementsMatch(t, []string{"DU/2024/1", "DU/2024/3"}, search("podatku"))
+	assert.Equal(t, []string{"DU/2024/1"}, search("podatku osób"))
+	assert.Equal(t, []string{"DU/2024/2"}, search("rozporzadzenie"), "diacritics are folded")
+	assert.Equal(t, []string{"DU/2024/2"}, search("cudzoz"), "the last word may be a prefix")
+	assert.Equal(t, []string{"DU/2024/2"}, search("wizy"), "keywords are searched")
+	assert.Equal(t, []string{"DU/2024/3"}, search(`"zmianie ustawy"`))
+	assert.Empty(t, search(`"ustawy zmianie"`), "phrases keep their word order")
+	assert.Empty(t, search("konstytucja"))
+	assert.Empty(t, search(`  "" `))
+
+	acts, err := store.SearchActs(ctx, "ustawa", 1)
+	require.NoError(t, err)
+	assert.Len(t, acts, 1)
+}
+
+func testSearchResults(t *testing.T, store Store) {
+	ctx := context.Background()
+
+	result, _, err := store.GetSearchResult(ctx, "title=podatek")
+	require.NoError(t, err)
+	assert.Nil(t, result)
+
+	page := &sejm.SearchResult{Items: []sejm.Act{act(1, "Ustawa o podatku", "obowiązujący", "2024-01-01")}, TotalCount: 1}
+	require.NoError(t, store.StoreSearchResult(ctx, "title=podatek", page))
+	result, age, err := store.GetSearchResult(ctx, "title=podatek")
+	require.NoError(t, err)
+	assert.Equal(t, page, result)
+	assert.Less(t, age, time.Minute)
+
+	page.TotalCount = 2
+	require.NoError(t, store.StoreSearchResult(ctx, "title=podatek", page))
+	result, _, err = store.GetSearchResult(ctx, "title=podatek")
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.TotalCount)
+}
+
+func testLinks(t *testing.T, store Store) {
+	ctx := context.Background()
+	_, err := store.StoreActs(ctx, sejm.PublisherDU, 2024, []sejm.Act{act(1, "Tytuł z wykazu", "obowiązujący", "2024-01-01")})
+	require.NoError(t, err)
+	_, err = store.StoreActs(ctx, sejm.PublisherDU, 2020, []sejm.Act{{ID: "DU/2020/5", Title: "Ustawa zmieniana", Position: 5, Year: 2020}})
+	require.NoError(t, err)
+	require.NoError(t, store.StoreActDetails(ctx, details(t, fullDetails)))
+
+	links, err := store.GetActLinks(ctx, []string{"DU/2024/1"})
+	require.NoError(t, err)
+	assert.Equal(t, []db.ActLink{
+		{Source: "DU/2024/1", Target: "DU/2020/5", Kind: db.LinkAmendedAct, Date: "2024-01-15"},
+		{Source: "DU/2024/1", Target: "DU/1997/483", Kind: db.LinkLegalBasis},
+	}, links)
+
+	links, err = store.GetIncomingLinks(ctx, []string{"DU/2020/5", "DU/1997/483"})
+	require.NoError(t, err)
+	assert.Len(t, links, 2)
+
+	links, err = store.GetActLinks(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, links)
+
+	// Storing details again replaces their links
+	replaced := details(t, fullDetails)
+	replaced.References = sejm.References{}
+	require.NoError(t, store.StoreActDetails(ctx, replaced))
+	links, err = store.GetActLinks(ctx, []string{"DU/2024/1"})
+	require.NoError(t, err)
+	assert.Empty(t, links)
+
+	titles, err := store.GetActTitles(ctx, []string{"DU/2024/1", "DU/2020/5", "DU/1997/483"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"DU/2024/1": "Ustawa o podatku",
+		"DU/2020/5": "Ustawa zmieniana",
+	}, titles, "titles of details take precedence over listings")
+}
+
+func testTexts(t *testing.T, store Store) {
+	ctx := context.Background()
+	id := eli(t, "DU/2024/1")
+
+	text, err := store.GetActText(ctx, id, sejm.TextPDF)
+	require.NoError(t, err)
+	assert.Nil(t, text)
+
+	stored := db.ActText{ActID: id.String(), Name: sejm.TextPDF, ContentType: "application/pdf", Hash: "aaa", Size: 3, ChangeDate: "2024-01-01", ETag: `"pdf"`}
+	require.NoError(t, store.StoreActText(ctx, stored))
+	require.NoError(t, store.StoreActText(ctx, stored))
+
+	text, err = store.GetActText(ctx, id, sejm.TextPDF)
+	require.NoError(t, err)
+	require.NotNil(t, text)
+	assert.WithinDuration(t, time.Now(), text.FetchedAt, time.Minute)
+	expected := stored
+	expected.FetchedAt = text.FetchedAt
+	expected.ETag = ""
+	assert.Equal(t, expected, *text)
+
+	entry, err := store.GetCacheEntry(ctx, db.CacheKindTexts, db.TextKey(id.String(), sejm.TextPDF))
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.Equal(t, `"pdf"`, entry.ETag)
+
+	// A new download with different content is a new version
+	stored.Hash, stored.Size, stored.ChangeDate = "bbb", 4, "2024-06-01"
+	require.NoError(t, store.StoreActText(ctx, stored))
+
+	versions, err := store.GetActTextVersions(ctx, id, sejm.TextPDF)
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, "aaa", versions[0].Hash)
+	assert.Equal(t, "bbb", versions[1].Hash)
+	assert.Equal(t, "2024-06-01", versions[1].ChangeDate)
+	assert.Less(t, versions[0].ID, versions[1].ID)
+
+	text, err = store.GetActText(ctx, id, sejm.TextPDF)
+	require.NoError(t, err)
+	require.NotNil(t, text)
+	assert.Equal(t, "bbb", text.Hash)
+}
+
+func testDocuments(t *testing.T, store Store) {
+	ctx := context.Background()
+
+	doc, err := store.GetActDocument(ctx, "aaa")
+	require.NoError(t, err)
+	assert.Nil(t, doc)
+
+	stored := &acttext.Document{
+		Preamble: []string{"USTAWA"},
+		Units:    []*acttext.Unit{{Kind: acttext.KindArticle, Number: "1", Anchor: "art-1", Text: "Ustawa określa zasady."}},
+	}
+	require.NoError(t, store.StoreActDocument(ctx, "aaa", stored))
+	require.NoError(t, store.StoreActDocument(ctx, "aaa", stored))
+
+	doc, err = store.GetActDocument(ctx, "aaa")
+	require.NoError(t, err)
+	assert.Equal(t, stored, doc)
+}
+
+func testFeedEntries(t *testing.T, store Store) {
+	ctx := context.Background()
+	acts := []sejm.Act{
+		act(1, "Ustawa A", "obowiązujący", "2024-01-10"),
+		act(2, "Ustawa B", "uchylony", "2024-02-10"),
+		act(3, "Ustawa C", "obowiązujący", "2024-03-10"),
+	}
+	_, err := store.StoreActs(ctx, sejm.PublisherDU, 2024, acts)
+	require.NoError(t, err)
+	require.NoError(t, store.StoreActDetails(ctx, &sejm.ActDetails{
+		ID: "DU/2024/1", Publisher: "DU", Title: "Ustawa A", Status: "obowiązujący", Published: "2024-01-10",
+		Position: 1, Year: 2024, Type: "Ustawa", Address: acts[0].Address,
+		ChangeDate: "2024-06-01T10:00:00", Keywords: []string{"Podatki", "Cła"},
+	}))
+	require.NoError(t, store.StoreActDetails(ctx, &sejm.ActDetails{ID: "DU/2024/2", Title: "Ustawa B"}))
+
+	ids := func(entries []db.FeedEntry) []string {
+		ids := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			ids = append(ids, entry.ID)
+		}
+		return ids
+	}
+
+	entries, err := store.GetFeedEntries(ctx, db.FeedFilter{Publisher: "DU", Year: 2024}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DU/2024/1", "DU/2024/3", "DU/2024/2"}, ids(entries), "acts are ordered by their latest change")
+	require.Len(t, entries, 3)
+	assert.Equal(t, "2024-06-01T10:00:00", entries[0].Date)
+	assert.Equal(t, "2024-06-01T10:00:00", entries[0].ChangeDate)
+	assert.Equal(t, "2024-03-10", entries[1].Date)
+
+	entries, err = store.GetFeedEntries(ctx, db.FeedFilter{Status: "uchylony"}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DU/2024/2"}, ids(entries))
+
+	entries, err = store.GetFeedEntries(ctx, db.FeedFilter{Keyword: "PODATKI"}, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, acts[0], entries[0].Act)
+
+	entries, err = store.GetFeedEntries(ctx, db.FeedFilter{Keyword: "nieznane"}, 10)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	entries, err = store.GetFeedEntries(ctx, db.FeedFilter{Publisher: "DU", Year: 2024}, 1)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func testWatches(t *testing.T, store Store) {
+	ctx := context.Background()
+
+	watches, err := store.ListWatches(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, watches)
+
+	first, err := store.AddWatch(ctx, db.Watch{Kind: db.WatchAct, Target: "DU/2024/1", URL: "https://example.com/hook", Secret: "s3cret"})
+	require.NoError(t, err)
+	assert.NotZero(t, first.ID)
+	assert.WithinDuration(t, time.Now(), first.CreatedAt, time.Minute)
+	second, err := store.AddWatch(ctx, db.Watch{Kind: db.WatchYear, Target: "DU/2024", URL: "https://example.com/year"})
+	require.NoError(t, err)
+
+	watches, err = store.ListWatches(ctx)
+	require.NoError(t, err)
+	require.Len(t, watches, 2)
+	assert.Equal(t, first.ID, watches[0].ID)
+	assert.Equal(t, "s3cret", watches[0].Secret)
+	assert.True(t, first.CreatedAt.Equal(watches[0].CreatedAt))
+	assert.Equal(t, second.ID, watches[1].ID)
+
+	require.NoError(t, store.StoreDeadLetter(ctx, db.DeadLetter{
+		WatchID: first.ID, URL: first.URL, Payload: `{"kind":"act"}`, Error: "status 500", Attempts: 3,
+	}))
+
+	require.NoError(t, store.DeleteWatch(ctx, first.ID))
+	assert.ErrorIs(t, store.DeleteWatch(ctx, first.ID), db.ErrWatchNotFound)
+	watches, err = store.ListWatches(ctx)
+	require.NoError(t, err)
+	assert.Len(t, watches, 1)
+}
+
+func testValidators(t *testing.T, store Store) {
+	ctx := context.Background()
+	url := "https://api.sejm.gov.pl/eli/acts/DU/2024"
+
+	validators, err := store.GetValidators(ctx, url)
+	require.NoError(t, err)
+	assert.Nil(t, validators)
+
+	require.NoError(t, store.StoreValidators(ctx, url, sejm.Validators{ETag: `"a"`, LastModified: "Mon, 01 Jan 2024 00:00:00 GMT"}))
+	require.NoError(t, store.StoreValidators(ctx, url, sejm.Validators{ETag: `"b"`}))
+
+	validators, err = store.GetValidators(ctx, url)
+	require.NoError(t, err)
+	assert.Equal(t, &sejm.Validators{ETag: `"b"`}, validators)
+}
+
+func testExport(t *testing.T, store Store) {
+	ctx := context.Background()
+	mp := sejm.Act{
+		ID: "MP/2023/5", Publisher: sejm.PublisherMP, Title: "Obwieszczenie", Status: "obowiązujący",
+		Published: "2023-02-01", Position: 5, Year: 2023, Type: "Obwieszczenie", Address: "WMP20230000005",
+	}
+	_, err := store.StoreActs(ctx, sejm.PublisherDU, 2024, []sejm.Act{act(2, "B", "obowiązujący", "2024-01-02"), act(1, "A", "obowiązujący", "2024-01-01")})
+	require.NoError(t, err)
+	_, err = store.StoreActs(ctx, sejm.PublisherMP, 2023, []sejm.Act{mp})
+	require.NoError(t, err)
+
+	var acts []sejm.Act
+	require.NoError(t, store.EachAct(ctx, func(act sejm.Act) error {
+		acts = append(acts, act)
+		return nil
+	}))
+	assert.Equal(t, []sejm.Act{act(1, "A", "obowiązujący", "2024-01-01"), act(2, "B", "obowiązujący", "2024-01-02"), mp}, acts)
+
+	require.NoError(t, store.StoreActDetails(ctx, details(t, fullDetails)))
+	var exported []*sejm.ActDetails
+	require.NoError(t, store.EachActDetails(ctx, func(details *sejm.ActDetails) error {
+		exported = append(exported, details)
+		return nil
+	}))
+	assert.Equal(t, []*sejm.ActDetails{details(t, fullDetails)}, exported)
+
+	stored := db.ActText{ActID: "DU/2024/1", Name: sejm.TextPDF, ContentType: "application/pdf", Hash: "aaa", Size: 3, ChangeDate: "2024-01-01"}
+	require.NoError(t, store.StoreActText(ctx, stored))
+	var texts []db.ActText
+	require.NoError(t, store.EachActText(ctx, func(text db.ActText) error {
+		texts = append(texts, text)
+		return nil
+	}))
+	require.Len(t, texts, 1)
+	assert.WithinDuration(t, time.Now(), texts[0].FetchedAt, time.Minute)
+	stored.FetchedAt = texts[0].FetchedAt
+	assert.Equal(t, stored, texts[0])
+
+	keys := make(map[string]string)
+	require.NoError(t, store.EachCacheEntry(ctx, func(entry db.CacheEntry) error {
+		keys[entry.Key] = entry.Kind
+		return nil
+	}))
+	assert.Equal(t, map[string]string{
+		"DU/2024":            db.CacheKindActs,
+		"MP/2023":            db.CacheKindActs,
+		"DU/2024/1":          db.CacheKindDetails,
+		"DU/2024/1/text.pdf": db.CacheKindTexts,
+	}, keys)
+
+	// Errors of the callback stop the iteration
+	stop := errors.New("stop")
+	calls := 0
+	err = store.EachAct(ctx, func(sejm.Act) error {
+		calls++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls)
+}
+
+func testSnapshots(t *testing.T, store Store) {
+	ctx := context.Background()
+
+	snapshot, err := store.GetSnapshot(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, snapshot)
+
+	// Restored cache metadata keeps its fetch time and invalidation
+	fetchedAt := time.Date(2025, 5, 1, 10, 30, 0, 0, time.UTC)
+	restored := db.CacheEntry{
+		Kind: db.CacheKindActs, Key: "DU/2024", FetchedAt: fetchedAt, TotalCount: 2,
+		ETag: `"list"`, Outcome: db.OutcomeOK, Invalidated: true,
+	}
+	require.NoError(t, store.StoreCacheEntry(ctx, db.CacheEntry{Kind: db.CacheKindActs, Key: "DU/2024", Outcome: db.OutcomeEmpty}))
+	require.NoError(t, store.RestoreCacheEntry(ctx, restored))
+	entry, err := store.GetCacheEntry(ctx, db.CacheKindActs, "DU/2024")
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.True(t, fetchedAt.Equal(entry.FetchedAt), "fetched at %s", entry.FetchedAt)
+	entry.FetchedAt = fetchedAt
+	assert.Equal(t, restored, *entry)
+
+	first := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	second := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, store.StoreSnapshot(ctx, first))
+	require.NoError(t, store.StoreSnapshot(ctx, second))
+
+	snapshot, err = store.GetSnapshot(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, snapshot)
+	assert.True(t, second.Equal(snapshot.CreatedAt), "the latest snapshot is returned, got %s", snapshot.CreatedAt)
+	assert.WithinDuration(t, time.Now(), snapshot.ImportedAt, time.Minute)
+}
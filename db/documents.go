@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"ustawka/acttext"
+)
+
+// GetActDocument retrieves the structured text parsed from an HTML text blob, or nil if not parsed yet
+func (db *DB) GetActDocument(ctx context.Context, hash string) (_ *acttext.Document, err error) {
+	ctx, end := startQuery(ctx, "get_act_document")
+	defer end(&err)
+
+	var data string
+	err = db.QueryRowContext(ctx, "SELECT document FROM act_documents WHERE hash = ?", hash).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var doc acttext.Document
+	if err := json.Unmarshal([]byte(data), &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse document: %w", err)
+	}
+	return &doc, nil
+}
+
+// StoreActDocument stores the structured text parsed from an HTML text blob
+func (db *DB) StoreActDocument(ctx context.Context, hash string, doc *acttext.Document) (err error) {
+	ctx, end := startQuery(ctx, "store_act_document")
+	defer end(&err)
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal document: %w", err)
+	}
+
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO act_documents (hash, document) VALUES (?, ?)
+		ON CONFLICT(hash) DO UPDATE SET document = excluded.document
+	`, hash, string(data))
+	return err
+}
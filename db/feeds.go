@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"ustawka/sejm"
+)
+
+// FeedFilter selects cached acts for a feed; a keyword feed reads act details,
+// the others read act listings
+type FeedFilter struct {
+	Publisher string
+	Year      int
+	Status    string
+	Keyword   string
+}
+
+// FeedEntry is a cached act with the date of its latest change
+type FeedEntry struct {
+	sejm.Act
+	ChangeDate string
+	// Date is the later of the promulgation and change dates
+	Date string
+}
+
+// GetFeedEntries returns cached acts matching a feed filter, most recently changed first
+func (db *DB) GetFeedEntries(ctx context.Context, filter FeedFilter, limit int) (_ []FeedEntry, err error) {
+	ctx, end := startQuery(ctx, "get_feed_entries")
+	defer end(&err)
+
+	var query string
+	var conditions []string
+	var args []any
+
+	if filter.Keyword != "" {
+		query = `SELECT d.id, COALESCE(d.publisher, ''), d.title, d.status, d.published, d.position, d.year,
+				 d.type, d.address, COALESCE(d.change_date, ''),
+				 max(d.published, COALESCE(d.change_date, '')) AS entry_date
+				 FROM act_details d`
+		conditions = append(conditions,
+			"EXISTS (SELECT 1 FROM json_each(d.keywords) WHERE lower(json_each.value) = lower(?))")
+		args = append(args, filter.Keyword)
+	} else {
+		query = `SELECT a.id, a.publisher, a.title, a.status, a.published, a.position, a.year,
+				 a.type, a.address, COALESCE(d.change_date, ''),
+				 max(a.published, COALESCE(d.change_date, '')) AS entry_date
+				 FROM acts a LEFT JOIN act_details d ON d.id = a.id`
+		if filter.Publisher != "" {
+			conditions = append(conditions, "a.publisher = ?")
+			args = append(args, filter.Publisher)
+		}
+		if filter.Year != 0 {
+			conditions = append(conditions, "a.year = ?")
+			args = append(args, filter.Year)
+		}
+		if filter.Status != "" {
+			conditions = append(conditions, "a.status = ?")
+			args = append(args, filter.Status)
+		}
+	}
+
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY entry_date DESC, 1 DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("Error closing rows", "error", err)
+		}
+	}()
+
+	entries := make([]FeedEntry, 0)
+	for rows.Next() {
+		var entry FeedEntry
+		if err := rows.Scan(
+			&entry.ID, &entry.Publisher, &entry.Title, &entry.Status, &entry.Published,
+			&entry.Position, &entry.Year, &entry.Type, &entry.Address, &entry.ChangeDate, &entry.Date,
+		); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
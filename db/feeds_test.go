@@ -0,0 +1,58 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+
+	"ustawka/db"
+	"ustawka/sejm"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetFeedEntries(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	acts := []sejm.Act{
+		{ID: "DU/2024/1", Publisher: "DU", Title: "Ustawa A", Status: "obowiązujący", Published: "2024-01-10",
+			Position: 1, Year: 2024, Type: "Ustawa", Address: "WDU20240000001"},
+		{ID: "DU/2024/2", Publisher: "DU", Title: "Ustawa B", Status: "uchylony", Published: "2024-02-10",
+			Position: 2, Year: 2024, Type: "Ustawa", Address: "WDU20240000002"},
+		{ID: "DU/2024/3", Publisher: "DU", Title: "Ustawa C", Status: "obowiązujący", Published: "2024-03-10",
+			Position: 3, Year: 2024, Type: "Ustawa", Address: "WDU20240000003"},
+	}
+	_, err := database.StoreActs(ctx, sejm.PublisherDU, 2024, acts)
+	require.NoError(t, err)
+
+	// A recent change moves the oldest act to the top
+	require.NoError(t, database.StoreActDetails(ctx, &sejm.ActDetails{
+		ID: "DU/2024/1", Publisher: "DU", Title: "Ustawa A", Status: "obowiązujący", Published: "2024-01-10",
+		Position: 1, Year: 2024, Type: "Ustawa", Address: "WDU20240000001",
+		ChangeDate: "2024-06-01T10:00:00", Keywords: []string{"Podatki", "Cła"},
+	}))
+
+	entries, err := database.GetFeedEntries(ctx, db.FeedFilter{Publisher: "DU", Year: 2024}, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, []string{"DU/2024/1", "DU/2024/3", "DU/2024/2"},
+		[]string{entries[0].ID, entries[1].ID, entries[2].ID})
+	assert.Equal(t, "2024-06-01T10:00:00", entries[0].Date)
+	assert.Equal(t, "2024-03-10", entries[1].Date)
+
+	entries, err = database.GetFeedEntries(ctx, db.FeedFilter{Status: "uchylony"}, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "DU/2024/2", entries[0].ID)
+
+	entries, err = database.GetFeedEntries(ctx, db.FeedFilter{Keyword: "podatki"}, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, acts[0], entries[0].Act)
+
+	entries, err = database.GetFeedEntries(ctx, db.FeedFilter{Publisher: "DU", Year: 2024}, 1)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
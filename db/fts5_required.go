@@ -0,0 +1,7 @@
+//go:build !sqlite_fts5
+
+package db
+
+// The search index is an FTS5 table ranked with BM25, which SQLite only provides when
+// built with the sqlite_fts5 tag: use go build -tags sqlite_fts5 (or make build, make test)
+var _ = ustawka_must_be_built_with_tags_sqlite_fts5
@@ -0,0 +1,182 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+
+	"ustawka/sejm"
+)
+
+// Kinds of references between acts, named after the lists in ActDetails.References
+const (
+	LinkRepealedAct      = "repealed_act"
+	LinkAmendingAct      = "amending_act"
+	LinkAmendedAct       = "amended_act"
+	LinkLegalBasis       = "legal_basis"
+	LinkConsolidatedText = "consolidated_text"
+	LinkConsolidatedInfo = "consolidated_text_info"
+)
+
+// ActLink is a reference from an act to another act, as listed in the details of Source
+type ActLink struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Kind   string `json:"kind"`
+	Date   string `json:"date,omitempty"`
+}
+
+// ReferenceLinks lists the references of act details as links
+func ReferenceLinks(details *sejm.ActDetails) []ActLink {
+	var links []ActLink
+	add := func(kind, target, date string) {
+		if target != "" {
+			links = append(links, ActLink{Source: details.ID, Target: target, Kind: kind, Date: date})
+		}
+	}
+
+	refs := details.References
+	for _, ref := range refs.RepealedActs {
+		add(LinkRepealedAct, ref.ID, ref.Date)
+	}
+	for _, ref := range refs.AmendingActs {
+		add(LinkAmendingAct, ref.ID, ref.Date)
+	}
+	for _, ref := range refs.AmendedActs {
+		add(LinkAmendedAct, ref.ID, ref.Date)
+	}
+	for _, ref := range append(refs.LegalBasis, refs.LegalBasisWithArt...) {
+		add(LinkLegalBasis, ref.ID, ref.Date)
+	}
+	for _, ref := range refs.TekstJednolity {
+		add(LinkConsolidatedText, ref.ID, ref.Date)
+	}
+	for _, ref := range refs.InfOTekstJednolitym {
+		add(LinkConsolidatedInfo, ref.ID, ref.Date)
+	}
+	return links
+}
+
+// storeLinks replaces the outgoing links of an act with the references in its details
+func storeLinks(ctx context.Context, tx *sql.Tx, details *sejm.ActDetails) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM act_links WHERE source = ?", details.ID); err != nil {
+		return err
+	}
+
+	stmt, err := tx.PrepareContext(ctx,
+		"INSERT OR IGNORE INTO act_links (source, target, kind, date) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := stmt.Close(); err != nil {
+			slog.Error("Error closing statement", "error", err)
+		}
+	}()
+
+	for _, link := range ReferenceLinks(details) {
+		if _, err := stmt.ExecContext(ctx, link.Source, link.Target, link.Kind, link.Date); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetActLinks returns the outgoing links of acts
+func (db *DB) GetActLinks(ctx context.Context, sources []string) (_ []ActLink, err error) {
+	ctx, end := startQuery(ctx, "get_act_links")
+	defer end(&err)
+
+	return db.queryLinks(ctx, "source", sources)
+}
+
+// GetIncomingLinks returns the links pointing at acts, i.e. references listed in other acts' details
+func (db *DB) GetIncomingLinks(ctx context.Context, targets []string) (_ []ActLink, err error) {
+	ctx, end := startQuery(ctx, "get_incoming_links")
+	defer end(&err)
+
+	return db.queryLinks(ctx, "target", targets)
+}
+
+// queryLinks returns links whose source or target column is one of ids
+func (db *DB) queryLinks(ctx context.Context, column string, ids []string) ([]ActLink, error) {
+	links := make([]ActLink, 0)
+	if len(ids) == 0 {
+		return links, nil
+	}
+
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	query := "SELECT source, target, kind, date FROM act_links WHERE " + column +
+		" IN (?" + strings.Repeat(", ?", len(ids)-1) + ") ORDER BY source, kind, target"
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("Error closing rows", "error", err)
+		}
+	}()
+
+	for rows.Next() {
+		var link ActLink
+		if err := rows.Scan(&link.Source, &link.Target, &link.Kind, &link.Date); err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+
+	return links, rows.Err()
+}
+
+// GetActTitles returns the titles of cached acts by ID, from details or listings
+func (db *DB) GetActTitles(ctx context.Context, ids []string) (_ map[string]string, err error) {
+	ctx, end := startQuery(ctx, "get_act_titles")
+	defer end(&err)
+
+	titles := make(map[string]string, len(ids))
+	if len(ids) == 0 {
+		return titles, nil
+	}
+
+	args := make([]any, 0, 2*len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	args = append(args, args...)
+	placeholders := "?" + strings.Repeat(", ?", len(ids)-1)
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, title, 0 FROM act_details WHERE id IN (`+placeholders+`)
+		UNION ALL
+		SELECT id, title, 1 FROM acts WHERE id IN (`+placeholders+`)
+		ORDER BY 3
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("Error closing rows", "error", err)
+		}
+	}()
+
+	for rows.Next() {
+		var id, title string
+		var source int
+		if err := rows.Scan(&id, &title, &source); err != nil {
+			return nil, err
+		}
+		// Details come first and are fresher than listings
+		if _, ok := titles[id]; !ok {
+			titles[id] = title
+		}
+	}
+
+	return titles, rows.Err()
+}
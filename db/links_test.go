@@ -0,0 +1,62 @@
+package db_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"ustawka/db"
+	"ustawka/sejm"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreActDetailsLinks(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	var details sejm.ActDetails
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"ELI": "DU/2024/1", "title": "Ustawa o przykładach", "publisher": "DU", "year": 2024, "pos": 1,
+		"references": {
+			"Akty zmieniające": [{"id": "DU/2024/50", "date": "2024-03-01"}],
+			"Akty zmienione": [{"id": "DU/2020/5"}],
+			"Podstawa prawna": [{"id": "DU/1997/78"}],
+			"Podstawa prawna z art.": [{"id": "DU/1997/78", "art": "art. 92"}]
+		}
+	}`), &details))
+	require.NoError(t, database.StoreActDetails(ctx, &details))
+
+	links, err := database.GetActLinks(ctx, []string{"DU/2024/1"})
+	require.NoError(t, err)
+	assert.Equal(t, []db.ActLink{
+		{Source: "DU/2024/1", Target: "DU/2020/5", Kind: db.LinkAmendedAct},
+		{Source: "DU/2024/1", Target: "DU/2024/50", Kind: db.LinkAmendingAct, Date: "2024-03-01"},
+		{Source: "DU/2024/1", Target: "DU/1997/78", Kind: db.LinkLegalBasis},
+	}, links)
+
+	incoming, err := database.GetIncomingLinks(ctx, []string{"DU/2020/5"})
+	require.NoError(t, err)
+	assert.Equal(t, []db.ActLink{{Source: "DU/2024/1", Target: "DU/2020/5", Kind: db.LinkAmendedAct}}, incoming)
+
+	// Storing details again replaces the links
+	details.References = sejm.References{}
+	require.NoError(t, database.StoreActDetails(ctx, &details))
+	links, err = database.GetActLinks(ctx, []string{"DU/2024/1"})
+	require.NoError(t, err)
+	assert.Empty(t, links)
+
+	_, err = database.StoreActs(ctx, sejm.PublisherDU, 2024, []sejm.Act{
+		{ID: "DU/2024/50", Publisher: sejm.PublisherDU, Title: "Ustawa zmieniająca", Year: 2024, Position: 50},
+	})
+	require.NoError(t, err)
+	titles, err := database.GetActTitles(ctx, []string{"DU/2024/1", "DU/2024/50", "DU/1997/78"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"DU/2024/1":  "Ustawa o przykładach",
+		"DU/2024/50": "Ustawa zmieniająca",
+	}, titles)
+}
@@ -0,0 +1,215 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrationFiles holds the schema migrations, named like 0001_initial.sql and applied
+// in order of their version prefix
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migration is a schema migration and when it was applied, nil if it is pending
+type Migration struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+	// Query holds the statements of the migration
+	Query string
+}
+
+// legacyColumns lists the columns added to tables before migrations were tracked
+var legacyColumns = []struct {
+	table, column, definition string
+}{
+	// Databases created before publishers were supported only hold DU acts
+	{"acts", "publisher", "TEXT NOT NULL DEFAULT 'DU'"},
+	// Texts downloaded before versions were tracked are refetched once the act changes
+	{"act_texts", "change_date", "TEXT NOT NULL DEFAULT ''"},
+	// Cache metadata tracked before invalidation was supported is all valid
+	{"cache_entries", "invalidated", "INTEGER NOT NULL DEFAULT 0"},
+}
+
+// LoadMigrations parses the migrations in fsys matching pattern, ordered by version
+func LoadMigrations(fsys fs.ReadFileFS, pattern string) ([]Migration, error) {
+	names, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0, len(names))
+	for _, name := range names {
+		base := strings.TrimSuffix(path.Base(name), ".sql")
+		prefix, title, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("malformed migration name %q", name)
+		}
+
+		query, err := fsys.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{Version: version, Name: title, Query: string(query)})
+	}
+
+	slices.SortFunc(migrations, func(a, b Migration) int { return a.Version - b.Version })
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("duplicate migration version %d", migrations[i].Version)
+		}
+	}
+	return migrations, nil
+}
+
+// Migrations returns all schema migrations with the time each was applied
+func (db *DB) Migrations(ctx context.Context) ([]Migration, error) {
+	migrations, err := LoadMigrations(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := db.appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range migrations {
+		if at, ok := applied[migrations[i].Version]; ok {
+			migrations[i].AppliedAt = &at
+		}
+	}
+	return migrations, nil
+}
+
+// Migrate applies pending schema migrations in order, each in a transaction of its own,
+// and returns the number applied. Databases created before migrations were tracked
+// first get the columns added to them since.
+func (db *DB) Migrate(ctx context.Context) (int, error) {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+	)`); err != nil {
+		return 0, err
+	}
+
+	migrations, err := db.Migrations(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if !slices.ContainsFunc(migrations, func(m Migration) bool { return m.AppliedAt != nil }) {
+		if err := db.adoptLegacySchema(ctx); err != nil {
+			return 0, fmt.Errorf("failed to adopt legacy schema: %w", err)
+		}
+	}
+
+	count := 0
+	for _, migration := range migrations {
+		if migration.AppliedAt != nil {
+			continue
+		}
+		if err := db.applyMigration(ctx, migration); err != nil {
+			return count, fmt.Errorf("migration %04d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+		slog.Info("Applied migration", "version", migration.Version, "name", migration.Name)
+		count++
+	}
+	return count, nil
+}
+
+// applyMigration runs a migration and records it as applied
+func (db *DB) applyMigration(ctx context.Context, migration Migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			slog.Error("Error rolling back transaction", "error", err)
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, migration.Query); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO schema_migrations (version, name) VALUES (?, ?)", migration.Version, migration.Name,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// appliedMigrations returns when each applied migration ran, keyed by version
+func (db *DB) appliedMigrations(ctx context.Context) (map[int]time.Time, error) {
+	var tracked bool
+	err := db.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations')",
+	).Scan(&tracked)
+	if err != nil || !tracked {
+		return map[int]time.Time{}, err
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT version, strftime('%Y-%m-%d %H:%M:%f', applied_at) FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("Error closing rows", "error", err)
+		}
+	}()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt string
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		at, err := time.Parse(timestampLayout, appliedAt)
+		if err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
+
+// adoptLegacySchema adds the columns missing from tables of a database created before
+// migrations were tracked, so that the initial migration only creates what is missing
+func (db *DB) adoptLegacySchema(ctx context.Context) error {
+	for _, c := range legacyColumns {
+		var columns, found int
+		err := db.QueryRowContext(ctx,
+			"SELECT COUNT(*), COALESCE(SUM(name = ?), 0) FROM pragma_table_info(?)", c.column, c.table,
+		).Scan(&columns, &found)
+		if err != nil {
+			return err
+		}
+		// Missing tables are created by the initial migration
+		if columns == 0 || found > 0 {
+			continue
+		}
+
+		slog.Info("Adding column to legacy table", "table", c.table, "column", c.column)
+		if _, err := db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", c.table, c.column, c.definition)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
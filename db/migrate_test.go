@@ -0,0 +1,140 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"ustawka/db"
+	"ustawka/sejm"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// legacySchema is a database created before migrations were tracked, from before
+// publishers, text versions and cache metadata were supported
+const legacySchema = `
+	CREATE TABLE acts (
+		id TEXT PRIMARY KEY, title TEXT NOT NULL, status TEXT NOT NULL, published TEXT NOT NULL,
+		position INTEGER NOT NULL, year INTEGER NOT NULL, type TEXT NOT NULL, address TEXT NOT NULL,
+		created_at TEXT NOT NULL DEFAULT (datetime('now')), updated_at TEXT NOT NULL DEFAULT (datetime('now'))
+	);
+	CREATE TABLE act_details (
+		id TEXT PRIMARY KEY, title TEXT NOT NULL, status TEXT NOT NULL, published TEXT NOT NULL,
+		type TEXT NOT NULL, address TEXT NOT NULL, display_address TEXT NOT NULL,
+		position INTEGER NOT NULL, year INTEGER NOT NULL, announcement_date TEXT, change_date TEXT,
+		publisher TEXT, text_html BOOLEAN, text_pdf BOOLEAN, volume INTEGER, entry_into_force TEXT,
+		in_force TEXT, keywords TEXT, keywords_names TEXT, released_by TEXT, texts TEXT,
+		act_references TEXT, authorized_body TEXT, directives TEXT, obligated TEXT,
+		previous_title TEXT, prints TEXT,
+		created_at TEXT NOT NULL DEFAULT (datetime('now')), updated_at TEXT NOT NULL DEFAULT (datetime('now'))
+	);
+	CREATE TABLE act_texts (
+		act_id TEXT NOT NULL, name TEXT NOT NULL, content_type TEXT NOT NULL, hash TEXT NOT NULL,
+		size INTEGER NOT NULL, fetched_at TEXT NOT NULL, PRIMARY KEY (act_id, name)
+	);
+	INSERT INTO acts (id, title, status, published, position, year, type, address)
+	VALUES ('DU/2020/1', 'Legacy', 'obowiązujący', '2020-01-01', 1, 2020, 'Ustawa', 'WDU20200000001');
+	INSERT INTO act_details (id, title, status, published, type, address, display_address, position, year, act_references)
+	VALUES ('DU/2020/1', 'Legacy', 'obowiązujący', '2020-01-01', 'Ustawa', 'WDU20200000001', 'Dz.U. 2020 poz. 1', 1, 2020,
+		'{"Akty zmienione": [{"id": "DU/2019/5", "date": "2020-01-01"}]}');
+	INSERT INTO act_texts (act_id, name, content_type, hash, size, fetched_at)
+	VALUES ('DU/2020/1', 'text.pdf', 'application/pdf', 'abc', 3, datetime('now'));
+`
+
+func TestMigrateFreshDatabase(t *testing.T) {
+	tmpfile, err := os.CreateTemp("", "testdb-*.db")
+	require.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+
+	ctx := context.Background()
+	database, err := db.Open(tmpfile.Name())
+	require.NoError(t, err)
+	defer database.Close()
+
+	migrations, err := database.Migrations(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+	for i, migration := range migrations {
+		assert.Equal(t, i+1, migration.Version, "migrations are numbered without gaps")
+		assert.Nil(t, migration.AppliedAt)
+	}
+
+	applied, err := database.Migrate(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, len(migrations), applied)
+
+	migrations, err = database.Migrations(ctx)
+	require.NoError(t, err)
+	for _, migration := range migrations {
+		assert.NotNil(t, migration.AppliedAt, migration.Name)
+	}
+
+	// Applied migrations are not run again
+	applied, err = database.Migrate(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, applied)
+
+	_, err = database.StoreActs(ctx, sejm.PublisherDU, 2024, []sejm.Act{{ID: "DU/2024/1", Publisher: sejm.PublisherDU, Position: 1, Year: 2024}})
+	require.NoError(t, err)
+}
+
+func TestMigrateLegacyDatabase(t *testing.T) {
+	tmpfile, err := os.CreateTemp("", "testdb-*.db")
+	require.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+
+	legacy, err := sql.Open("sqlite3", tmpfile.Name())
+	require.NoError(t, err)
+	_, err = legacy.Exec(legacySchema)
+	require.NoError(t, err)
+	require.NoError(t, legacy.Close())
+
+	database, err := db.New(tmpfile.Name())
+	require.NoError(t, err)
+	defer database.Close()
+
+	ctx := context.Background()
+	migrations, err := database.Migrations(ctx)
+	require.NoError(t, err)
+	for _, migration := range migrations {
+		assert.NotNil(t, migration.AppliedAt, migration.Name)
+	}
+
+	// Columns added since are filled with their defaults
+	acts, err := database.GetActs(ctx, sejm.PublisherDU, 2020)
+	require.NoError(t, err)
+	require.Len(t, acts, 1)
+	assert.Equal(t, "Legacy", acts[0].Title)
+
+	id := sejm.ELI{Publisher: sejm.PublisherDU, Year: 2020, Position: 1}
+	text, err := database.GetActText(ctx, id, sejm.TextPDF)
+	require.NoError(t, err)
+	require.NotNil(t, text)
+	assert.Empty(t, text.ChangeDate)
+
+	// Data migrations fill what older versions didn't store
+	links, err := database.GetActLinks(ctx, []string{"DU/2020/1"})
+	require.NoError(t, err)
+	assert.Equal(t, []db.ActLink{{Source: "DU/2020/1", Target: "DU/2019/5", Kind: db.LinkAmendedAct, Date: "2020-01-01"}}, links)
+
+	for kind, key := range map[string]string{
+		db.CacheKindActs:    "DU/2020",
+		db.CacheKindDetails: "DU/2020/1",
+		db.CacheKindTexts:   "DU/2020/1/text.pdf",
+	} {
+		entry, err := database.GetCacheEntry(ctx, kind, key)
+		require.NoError(t, err)
+		require.NotNil(t, entry, kind)
+		assert.False(t, entry.Invalidated)
+	}
+
+	// New tables are usable
+	_, err = database.InvalidateCacheEntries(ctx, "DU/2020")
+	require.NoError(t, err)
+	results, err := database.SearchActs(ctx, "legacy", 10)
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+}
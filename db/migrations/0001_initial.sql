@@ -0,0 +1,157 @@
+-- Schema of databases created before migrations were tracked. Statements only create
+-- what is missing, so legacy databases are adopted once their added columns are in place.
+
+CREATE TABLE IF NOT EXISTS acts (
+    id TEXT PRIMARY KEY,
+    publisher TEXT NOT NULL DEFAULT 'DU',
+    title TEXT NOT NULL,
+    status TEXT NOT NULL,
+    published TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    year INTEGER NOT NULL,
+    type TEXT NOT NULL,
+    address TEXT NOT NULL,
+    created_at TEXT NOT NULL DEFAULT (datetime('now')),
+    updated_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE TABLE IF NOT EXISTS act_details (
+    id TEXT PRIMARY KEY,
+    title TEXT NOT NULL,
+    status TEXT NOT NULL,
+    published TEXT NOT NULL,
+    type TEXT NOT NULL,
+    address TEXT NOT NULL,
+    display_address TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    year INTEGER NOT NULL,
+    announcement_date TEXT,
+    change_date TEXT,
+    publisher TEXT,
+    text_html BOOLEAN,
+    text_pdf BOOLEAN,
+    volume INTEGER,
+    entry_into_force TEXT,
+    in_force TEXT,
+    keywords TEXT,
+    keywords_names TEXT,
+    released_by TEXT,
+    texts TEXT,
+    act_references TEXT,
+    authorized_body TEXT,
+    directives TEXT,
+    obligated TEXT,
+    previous_title TEXT,
+    prints TEXT,
+    created_at TEXT NOT NULL DEFAULT (datetime('now')),
+    updated_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE TABLE IF NOT EXISTS search_cache (
+    query_key TEXT PRIMARY KEY,
+    result TEXT NOT NULL,
+    updated_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE TABLE IF NOT EXISTS act_changes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    act_id TEXT NOT NULL,
+    field TEXT NOT NULL,
+    old_value TEXT NOT NULL,
+    new_value TEXT NOT NULL,
+    detected_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS watches (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL,
+    target TEXT NOT NULL,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE TABLE IF NOT EXISTS webhook_dead_letters (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    watch_id INTEGER NOT NULL,
+    url TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    error TEXT NOT NULL,
+    attempts INTEGER NOT NULL,
+    created_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE TABLE IF NOT EXISTS act_texts (
+    act_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    hash TEXT NOT NULL,
+    size INTEGER NOT NULL,
+    change_date TEXT NOT NULL DEFAULT '',
+    fetched_at TEXT NOT NULL,
+    PRIMARY KEY (act_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS act_text_versions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    act_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    hash TEXT NOT NULL,
+    change_date TEXT NOT NULL,
+    fetched_at TEXT NOT NULL,
+    UNIQUE (act_id, name, hash)
+);
+
+CREATE TABLE IF NOT EXISTS act_documents (
+    hash TEXT PRIMARY KEY,
+    document TEXT NOT NULL,
+    created_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE TABLE IF NOT EXISTS act_links (
+    source TEXT NOT NULL,
+    target TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    date TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (source, target, kind)
+);
+
+CREATE TABLE IF NOT EXISTS cache_entries (
+    kind TEXT NOT NULL,
+    key TEXT NOT NULL,
+    fetched_at TEXT NOT NULL,
+    total_count INTEGER NOT NULL DEFAULT 0,
+    etag TEXT NOT NULL DEFAULT '',
+    outcome TEXT NOT NULL,
+    invalidated INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (kind, key)
+);
+
+CREATE TABLE IF NOT EXISTS response_validators (
+    url TEXT PRIMARY KEY,
+    etag TEXT NOT NULL,
+    last_modified TEXT NOT NULL,
+    updated_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_act_links_target ON act_links(target);
+
+CREATE INDEX IF NOT EXISTS idx_acts_year ON acts(year);
+
+CREATE INDEX IF NOT EXISTS idx_acts_status ON acts(status);
+
+CREATE INDEX IF NOT EXISTS idx_act_changes_act ON act_changes(act_id, detected_at);
+
+CREATE INDEX IF NOT EXISTS idx_acts_publisher_year ON acts(publisher, year);
+
+CREATE TRIGGER IF NOT EXISTS update_acts_timestamp
+AFTER UPDATE ON acts
+BEGIN
+    UPDATE acts SET updated_at = datetime('now') WHERE id = NEW.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS update_act_details_timestamp
+AFTER UPDATE ON act_details
+BEGIN
+    UPDATE act_details SET updated_at = datetime('now') WHERE id = NEW.id;
+END;
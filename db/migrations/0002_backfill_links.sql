@@ -0,0 +1,19 @@
+-- Links between acts from details cached before links were stored, with the link kinds
+-- of db.LinkRepealedAct, LinkAmendingAct, LinkAmendedAct, LinkConsolidatedText,
+-- LinkConsolidatedInfo and LinkLegalBasis
+INSERT OR IGNORE INTO act_links (source, target, kind, date)
+SELECT d.id, json_extract(ref.value, '$.id'),
+    CASE list.key
+        WHEN 'Akty uznane za uchylone' THEN 'repealed_act'
+        WHEN 'Akty zmieniające' THEN 'amending_act'
+        WHEN 'Akty zmienione' THEN 'amended_act'
+        WHEN 'Tekst jednolity dla aktu' THEN 'consolidated_text'
+        WHEN 'Inf. o tekście jednolitym' THEN 'consolidated_text_info'
+        ELSE 'legal_basis'
+    END,
+    COALESCE(json_extract(ref.value, '$.date'), '')
+FROM act_details d, json_each(d.act_references) list, json_each(list.value) ref
+WHERE json_valid(d.act_references) AND json_type(list.value) = 'array'
+    AND list.key IN ('Akty uznane za uchylone', 'Akty zmieniające', 'Akty zmienione',
+        'Tekst jednolity dla aktu', 'Inf. o tekście jednolitym', 'Podstawa prawna', 'Podstawa prawna z art.')
+    AND json_extract(ref.value, '$.id') IS NOT NULL;
@@ -0,0 +1,11 @@
+-- Cache metadata of resources cached before it was tracked, with the kinds and outcome
+-- of db.CacheKindActs, CacheKindDetails, CacheKindTexts and OutcomeOK
+INSERT OR IGNORE INTO cache_entries (kind, key, fetched_at, total_count, etag, outcome)
+SELECT 'acts', publisher || '/' || year, MAX(updated_at), COUNT(*), '', 'ok'
+FROM acts GROUP BY publisher, year;
+
+INSERT OR IGNORE INTO cache_entries (kind, key, fetched_at, total_count, etag, outcome)
+SELECT 'details', id, updated_at, 1, '', 'ok' FROM act_details;
+
+INSERT OR IGNORE INTO cache_entries (kind, key, fetched_at, total_count, etag, outcome)
+SELECT 'texts', act_id || '/' || name, fetched_at, 1, '', 'ok' FROM act_texts;
@@ -0,0 +1,2 @@
+-- Parsed act texts are parsed again, giving chapters anchors unique across divisions
+DELETE FROM act_documents;
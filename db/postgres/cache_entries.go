@@ -0,0 +1,96 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"ustawka/db"
+)
+
+// execer runs statements either directly or within a transaction
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// GetCacheEntry retrieves the cache metadata of a resource, or nil if it was never fetched
+func (d *DB) GetCacheEntry(ctx context.Context, kind, key string) (_ *db.CacheEntry, err error) {
+	ctx, end := startQuery(ctx, "get_cache_entry")
+	defer end(&err)
+
+	entry := db.CacheEntry{Kind: kind, Key: key}
+	err = d.QueryRowContext(ctx, `
+		SELECT fetched_at, total_count, etag, outcome, invalidated
+		FROM cache_entries WHERE kind = $1 AND key = $2
+	`, kind, key).Scan(&entry.FetchedAt, &entry.TotalCount, &entry.ETag, &entry.Outcome, &entry.Invalidated)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// StoreCacheEntry records that a resource was just fetched, such as one the API had no data for
+func (d *DB) StoreCacheEntry(ctx context.Context, entry db.CacheEntry) (err error) {
+	ctx, end := startQuery(ctx, "store_cache_entry")
+	defer end(&err)
+
+	return storeCacheEntry(ctx, d, entry)
+}
+
+// TouchCacheEntry marks a cached resource as just fetched without changing it,
+// reporting whether it had been fetched before
+func (d *DB) TouchCacheEntry(ctx context.Context, kind, key string) (_ bool, err error) {
+	ctx, end := startQuery(ctx, "touch_cache_entry")
+	defer end(&err)
+
+	result, err := d.ExecContext(ctx,
+		"UPDATE cache_entries SET fetched_at = now(), invalidated = false WHERE kind = $1 AND key = $2",
+		kind, key,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// InvalidateCacheEntries marks resources whose key is the prefix or lies under it, such as
+// a year and the acts published in it, to be fetched again; an empty prefix marks all of them.
+// It returns the number of resources marked.
+func (d *DB) InvalidateCacheEntries(ctx context.Context, prefix string) (_ int64, err error) {
+	ctx, end := startQuery(ctx, "invalidate_cache_entries")
+	defer end(&err)
+
+	result, err := d.ExecContext(ctx, `
+		UPDATE cache_entries SET invalidated = true
+		WHERE CAST($1 AS TEXT) = '' OR key = $1 OR starts_with(key, $1 || '/')
+	`, prefix)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// storeCacheEntry replaces the cache metadata of a resource, stamping it with the current time
+func storeCacheEntry(ctx context.Context, exec execer, entry db.CacheEntry) error {
+	_, err := exec.ExecContext(ctx, `
+		INSERT INTO cache_entries (kind, key, fetched_at, total_count, etag, outcome)
+		VALUES ($1, $2, now(), $3, $4, $5)
+		ON CONFLICT (kind, key) DO UPDATE SET
+			fetched_at = excluded.fetched_at, total_count = excluded.total_count,
+			etag = excluded.etag, outcome = excluded.outcome, invalidated = false
+	`, entry.Kind, entry.Key, entry.TotalCount, entry.ETag, entry.Outcome)
+	return err
+}
+
+// invalidateCacheEntry marks a resource to be fetched again
+func invalidateCacheEntry(ctx context.Context, exec execer, kind, key string) error {
+	_, err := exec.ExecContext(ctx, "UPDATE cache_entries SET invalidated = true WHERE kind = $1 AND key = $2", kind, key)
+	return err
+}
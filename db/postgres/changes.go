@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+
+	"ustawka/db"
+	"ustawka/sejm"
+)
+
+// insertChanges appends detected changes to the history
+func insertChanges(ctx context.Context, tx *sql.Tx, changes []db.ActChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO act_changes (act_id, field, old_value, new_value, detected_at)
+		VALUES ($1, $2, $3, $4, now())
+	`)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := stmt.Close(); err != nil {
+			slog.Error("Error closing statement", "error", err)
+		}
+	}()
+
+	for _, change := range changes {
+		if _, err := stmt.ExecContext(ctx, change.ActID, change.Field, change.OldValue, change.NewValue); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetActChanges returns the recorded history of an act, most recent first
+func (d *DB) GetActChanges(ctx context.Context, id sejm.ELI) (_ []db.ActChange, err error) {
+	ctx, end := startQuery(ctx, "get_act_changes")
+	defer end(&err)
+
+	rows, err := d.QueryContext(ctx, `
+		SELECT act_id, field, old_value, new_value, detected_at
+		FROM act_changes WHERE act_id = $1 ORDER BY detected_at DESC, id DESC
+	`, id.String())
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows)
+
+	changes := make([]db.ActChange, 0)
+	for rows.Next() {
+		var change db.ActChange
+		if err := rows.Scan(&change.ActID, &change.Field, &change.OldValue, &change.NewValue, &change.DetectedAt); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"ustawka/db"
+)
+
+// GetFeedEntries returns cached acts matching a feed filter, most recently changed first
+func (d *DB) GetFeedEntries(ctx context.Context, filter db.FeedFilter, limit int) (_ []db.FeedEntry, err error) {
+	ctx, end := startQuery(ctx, "get_feed_entries")
+	defer end(&err)
+
+	var query string
+	var conditions []string
+	var args []any
+	// arg adds a query argument, returning its placeholder
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	// Dates are compared byte by byte like in SQLite, whatever the database collation
+	if filter.Keyword != "" {
+		query = `SELECT d.id, COALESCE(d.publisher, ''), d.title, d.status, d.published, d.position, d.year,
+				 d.type, d.address, COALESCE(d.change_date, ''),
+				 GREATEST(d.published COLLATE "C", COALESCE(d.change_date, '')) AS entry_date
+				 FROM act_details d`
+		conditions = append(conditions, `EXISTS (SELECT 1 FROM jsonb_array_elements_text(
+			CASE WHEN jsonb_typeof(d.keywords) = 'array' THEN d.keywords ELSE '[]' END
+		) keyword WHERE lower(keyword) = lower(`+arg(filter.Keyword)+`))`)
+	} else {
+		query = `SELECT a.id, a.publisher, a.title, a.status, a.published, a.position, a.year,
+				 a.type, a.address, COALESCE(d.change_date, ''),
+				 GREATEST(a.published COLLATE "C", COALESCE(d.change_date, '')) AS entry_date
+				 FROM acts a LEFT JOIN act_details d ON d.id = a.id`
+		if filter.Publisher != "" {
+			conditions = append(conditions, "a.publisher = "+arg(filter.Publisher))
+		}
+		if filter.Year != 0 {
+			conditions = append(conditions, "a.year = "+arg(filter.Year))
+		}
+		if filter.Status != "" {
+			conditions = append(conditions, "a.status = "+arg(filter.Status))
+		}
+	}
+
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY entry_date DESC, 1 DESC LIMIT ` + arg(limit)
+
+	rows, err := d.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows)
+
+	entries := make([]db.FeedEntry, 0)
+	for rows.Next() {
+		var entry db.FeedEntry
+		if err := rows.Scan(
+			&entry.ID, &entry.Publisher, &entry.Title, &entry.Status, &entry.Published,
+			&entry.Position, &entry.Year, &entry.Type, &entry.Address, &entry.ChangeDate, &entry.Date,
+		); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
@@ -0,0 +1,116 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+
+	"ustawka/db"
+	"ustawka/sejm"
+
+	"github.com/lib/pq"
+)
+
+// storeLinks replaces the outgoing links of an act with the references in its details
+func storeLinks(ctx context.Context, tx *sql.Tx, details *sejm.ActDetails) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM act_links WHERE source = $1", details.ID); err != nil {
+		return err
+	}
+
+	stmt, err := tx.PrepareContext(ctx,
+		"INSERT INTO act_links (source, target, kind, date) VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := stmt.Close(); err != nil {
+			slog.Error("Error closing statement", "error", err)
+		}
+	}()
+
+	for _, link := range db.ReferenceLinks(details) {
+		if _, err := stmt.ExecContext(ctx, link.Source, link.Target, link.Kind, link.Date); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetActLinks returns the outgoing links of acts
+func (d *DB) GetActLinks(ctx context.Context, sources []string) (_ []db.ActLink, err error) {
+	ctx, end := startQuery(ctx, "get_act_links")
+	defer end(&err)
+
+	return d.queryLinks(ctx, "source", sources)
+}
+
+// GetIncomingLinks returns the links pointing at acts, i.e. references listed in other acts' details
+func (d *DB) GetIncomingLinks(ctx context.Context, targets []string) (_ []db.ActLink, err error) {
+	ctx, end := startQuery(ctx, "get_incoming_links")
+	defer end(&err)
+
+	return d.queryLinks(ctx, "target", targets)
+}
+
+// queryLinks returns links whose source or target column is one of ids
+func (d *DB) queryLinks(ctx context.Context, column string, ids []string) ([]db.ActLink, error) {
+	links := make([]db.ActLink, 0)
+	if len(ids) == 0 {
+		return links, nil
+	}
+
+	rows, err := d.QueryContext(ctx,
+		"SELECT source, target, kind, date FROM act_links WHERE "+column+" = ANY($1) ORDER BY source, kind, target",
+		pq.Array(ids),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows)
+
+	for rows.Next() {
+		var link db.ActLink
+		if err := rows.Scan(&link.Source, &link.Target, &link.Kind, &link.Date); err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+
+	return links, rows.Err()
+}
+
+// GetActTitles returns the titles of cached acts by ID, from details or listings
+func (d *DB) GetActTitles(ctx context.Context, ids []string) (_ map[string]string, err error) {
+	ctx, end := startQuery(ctx, "get_act_titles")
+	defer end(&err)
+
+	titles := make(map[string]string, len(ids))
+	if len(ids) == 0 {
+		return titles, nil
+	}
+
+	rows, err := d.QueryContext(ctx, `
+		SELECT id, title, 0 FROM act_details WHERE id = ANY($1)
+		UNION ALL
+		SELECT id, title, 1 FROM acts WHERE id = ANY($1)
+		ORDER BY 3
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows)
+
+	for rows.Next() {
+		var id, title string
+		var source int
+		if err := rows.Scan(&id, &title, &source); err != nil {
+			return nil, err
+		}
+		// Details come first and are fresher than listings
+		if _, ok := titles[id]; !ok {
+			titles[id] = title
+		}
+	}
+
+	return titles, rows.Err()
+}
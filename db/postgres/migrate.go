@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"ustawka/db"
+)
+
+// migrationFiles holds the schema migrations, named like 0001_initial.sql and applied
+// in order of their version prefix
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLock serializes migrations of replicas starting at once
+const migrationLock = "ustawka_schema_migrations"
+
+// Migrations returns all schema migrations with the time each was applied
+func (d *DB) Migrations(ctx context.Context) ([]db.Migration, error) {
+	migrations, err := db.LoadMigrations(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := d.appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range migrations {
+		if at, ok := applied[migrations[i].Version]; ok {
+			migrations[i].AppliedAt = &at
+		}
+	}
+	return migrations, nil
+}
+
+// Migrate applies pending schema migrations in order, each in a transaction of its own,
+// and returns the number applied
+func (d *DB) Migrate(ctx context.Context) (int, error) {
+	conn, err := d.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			slog.Error("Error closing connection", "error", err)
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock(hashtext($1))", migrationLock); err != nil {
+		return 0, err
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock(hashtext($1))", migrationLock); err != nil {
+			slog.Error("Error releasing migration lock", "error", err)
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`); err != nil {
+		return 0, err
+	}
+
+	migrations, err := d.Migrations(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, migration := range migrations {
+		if migration.AppliedAt != nil {
+			continue
+		}
+		if err := applyMigration(ctx, conn, migration); err != nil {
+			return count, fmt.Errorf("migration %04d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+		slog.Info("Applied migration", "version", migration.Version, "name", migration.Name)
+		count++
+	}
+	return count, nil
+}
+
+// applyMigration runs a migration and records it as applied
+func applyMigration(ctx context.Context, conn *sql.Conn, migration db.Migration) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer rollback(tx)
+
+	if _, err := tx.ExecContext(ctx, migration.Query); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", migration.Version, migration.Name,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// appliedMigrations returns when each applied migration ran, keyed by version
+func (d *DB) appliedMigrations(ctx context.Context) (map[int]time.Time, error) {
+	var tracked bool
+	if err := d.QueryRowContext(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&tracked); err != nil || !tracked {
+		return map[int]time.Time{}, err
+	}
+
+	rows, err := d.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows)
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
@@ -0,0 +1,149 @@
+-- Schema matching the SQLite database after its migrations, with timestamps kept as
+-- TIMESTAMPTZ and the JSON lists of act details as JSONB
+
+CREATE TABLE acts (
+    id TEXT PRIMARY KEY,
+    publisher TEXT NOT NULL,
+    title TEXT NOT NULL,
+    status TEXT NOT NULL,
+    published TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    year INTEGER NOT NULL,
+    type TEXT NOT NULL,
+    address TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE act_details (
+    id TEXT PRIMARY KEY,
+    title TEXT NOT NULL,
+    status TEXT NOT NULL,
+    published TEXT NOT NULL,
+    type TEXT NOT NULL,
+    address TEXT NOT NULL,
+    display_address TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    year INTEGER NOT NULL,
+    announcement_date TEXT,
+    change_date TEXT,
+    publisher TEXT,
+    text_html BOOLEAN,
+    text_pdf BOOLEAN,
+    volume INTEGER,
+    entry_into_force TEXT,
+    in_force TEXT,
+    keywords JSONB,
+    keywords_names JSONB,
+    released_by JSONB,
+    texts JSONB,
+    act_references JSONB,
+    authorized_body JSONB,
+    directives JSONB,
+    obligated JSONB,
+    previous_title JSONB,
+    prints JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE search_cache (
+    query_key TEXT PRIMARY KEY,
+    result JSONB NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE act_changes (
+    id BIGSERIAL PRIMARY KEY,
+    act_id TEXT NOT NULL,
+    field TEXT NOT NULL,
+    old_value TEXT NOT NULL,
+    new_value TEXT NOT NULL,
+    detected_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE watches (
+    id BIGSERIAL PRIMARY KEY,
+    kind TEXT NOT NULL,
+    target TEXT NOT NULL,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE webhook_dead_letters (
+    id BIGSERIAL PRIMARY KEY,
+    watch_id BIGINT NOT NULL,
+    url TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    error TEXT NOT NULL,
+    attempts INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE act_texts (
+    act_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    hash TEXT NOT NULL,
+    size BIGINT NOT NULL,
+    change_date TEXT NOT NULL DEFAULT '',
+    fetched_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (act_id, name)
+);
+
+CREATE TABLE act_text_versions (
+    id BIGSERIAL PRIMARY KEY,
+    act_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    hash TEXT NOT NULL,
+    change_date TEXT NOT NULL,
+    fetched_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (act_id, name, hash)
+);
+
+CREATE TABLE act_documents (
+    hash TEXT PRIMARY KEY,
+    document JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE act_links (
+    source TEXT NOT NULL,
+    target TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    date TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (source, target, kind)
+);
+
+CREATE TABLE cache_entries (
+    kind TEXT NOT NULL,
+    key TEXT NOT NULL,
+    fetched_at TIMESTAMPTZ NOT NULL,
+    total_count INTEGER NOT NULL DEFAULT 0,
+    etag TEXT NOT NULL DEFAULT '',
+    outcome TEXT NOT NULL,
+    invalidated BOOLEAN NOT NULL DEFAULT false,
+    PRIMARY KEY (kind, key)
+);
+
+CREATE TABLE response_validators (
+    url TEXT PRIMARY KEY,
+    etag TEXT NOT NULL,
+    last_modified TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_act_links_target ON act_links (target);
+
+CREATE INDEX idx_acts_status ON acts (status);
+
+CREATE INDEX idx_acts_publisher_year ON acts (publisher, year);
+
+CREATE INDEX idx_act_changes_act ON act_changes (act_id, detected_at);
+
+-- Folds case and Polish diacritics so that searches match either spelling, like the
+-- remove_diacritics tokenizer of the SQLite index
+CREATE FUNCTION fold_search_text(value TEXT) RETURNS TEXT
+LANGUAGE SQL IMMUTABLE PARALLEL SAFE
+AS $$ SELECT translate(lower(value), 'ąćęłńóśźż', 'acelnoszz') $$;
@@ -0,0 +1,6 @@
+-- Snapshots imported with the import subcommand, recording when each was exported
+CREATE TABLE snapshots (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    imported_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,376 @@
+// Package postgres stores the cache in PostgreSQL, so that several replicas can
+// share it; it has the same semantics as the SQLite database of package db.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"ustawka/db"
+	"ustawka/sejm"
+
+	_ "github.com/lib/pq" // PostgreSQL driver
+)
+
+// DB represents the database connection
+type DB struct {
+	*sql.DB
+}
+
+// New connects to the database at a postgres:// URL, applying pending schema migrations
+func New(url string) (*DB, error) {
+	database, err := Open(url)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := database.Migrate(context.Background()); err != nil {
+		_ = database.Close()
+		return nil, err
+	}
+
+	return database, nil
+}
+
+// Open connects to the database without changing its schema
+func Open(url string) (*DB, error) {
+	database, err := sql.Open("postgres", url)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := database.Ping(); err != nil {
+		_ = database.Close()
+		return nil, err
+	}
+
+	return &DB{database}, nil
+}
+
+// actColumns are the columns of acts scanned by scanAct
+const actColumns = "id, publisher, title, status, published, position, year, type, address"
+
+// rowScanner is a single row or the current row of a result set
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanAct scans the actColumns of a row
+func scanAct(row rowScanner) (sejm.Act, error) {
+	var act sejm.Act
+	err := row.Scan(
+		&act.ID, &act.Publisher, &act.Title, &act.Status, &act.Published,
+		&act.Position, &act.Year, &act.Type, &act.Address,
+	)
+	return act, err
+}
+
+// closeRows closes a result set, logging failures
+func closeRows(rows *sql.Rows) {
+	if err := rows.Close(); err != nil {
+		slog.Error("Error closing rows", "error", err)
+	}
+}
+
+// rollback rolls back a transaction unless it was committed
+func rollback(tx *sql.Tx) {
+	if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		slog.Error("Error rolling back transaction", "error", err)
+	}
+}
+
+// GetActs retrieves acts of a publisher for a specific year from the cache
+func (d *DB) GetActs(ctx context.Context, publisher string, year int) (_ []sejm.Act, err error) {
+	ctx, end := startQuery(ctx, "get_acts")
+	defer end(&err)
+
+	rows, err := d.QueryContext(ctx,
+		"SELECT "+actColumns+" FROM acts WHERE publisher = $1 AND year = $2 ORDER BY position",
+		publisher, year,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows)
+
+	var acts []sejm.Act
+	for rows.Next() {
+		act, err := scanAct(rows)
+		if err != nil {
+			return nil, err
+		}
+		acts = append(acts, act)
+	}
+
+	return acts, rows.Err()
+}
+
+// StoreActs stores acts of a publisher for a specific year in the cache, recording
+// changes of acts that were already cached in the history and returning them.
+// Replicas storing the same year at once take turns, so each change is recorded once.
+func (d *DB) StoreActs(ctx context.Context, publisher string, year int, acts []sejm.Act) (_ []db.ActChange, err error) {
+	ctx, end := startQuery(ctx, "store_acts")
+	defer end(&err)
+
+	tx, err := d.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer rollback(tx)
+
+	key := db.YearKey(publisher, year)
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", key); err != nil {
+		return nil, err
+	}
+
+	cached, err := cachedActs(ctx, tx, publisher, year)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []db.ActChange
+	for _, act := range acts {
+		if previous, ok := cached[act.ID]; ok {
+			changes = append(changes, db.DiffActs(previous, act)...)
+			delete(cached, act.ID)
+		}
+	}
+
+	// Acts no longer listed for the year are dropped from the cache
+	for id := range cached {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM acts WHERE id = $1", id); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := upsertActs(ctx, tx, publisher, acts); err != nil {
+		return nil, err
+	}
+
+	if err := insertChanges(ctx, tx, changes); err != nil {
+		return nil, err
+	}
+
+	// Cached details of acts whose status changed no longer match the listing
+	for _, change := range changes {
+		if change.Field != "status" {
+			continue
+		}
+		if err := invalidateCacheEntry(ctx, tx, db.CacheKindDetails, change.ActID); err != nil {
+			return nil, err
+		}
+	}
+
+	outcome := db.OutcomeOK
+	if len(acts) == 0 {
+		outcome = db.OutcomeEmpty
+	}
+	entry := db.CacheEntry{Kind: db.CacheKindActs, Key: key, TotalCount: len(acts), Outcome: outcome}
+	if err := storeCacheEntry(ctx, tx, entry); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// cachedActs loads acts of a publisher's year within a transaction, keyed by ID
+func cachedActs(ctx context.Context, tx *sql.Tx, publisher string, year int) (map[string]sejm.Act, error) {
+	rows, err := tx.QueryContext(ctx,
+		"SELECT "+actColumns+" FROM acts WHERE publisher = $1 AND year = $2",
+		publisher, year,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows)
+
+	acts := make(map[string]sejm.Act)
+	for rows.Next() {
+		act, err := scanAct(rows)
+		if err != nil {
+			return nil, err
+		}
+		acts[act.ID] = act
+	}
+
+	return acts, rows.Err()
+}
+
+// upsertActs inserts or updates acts using a prepared statement
+func upsertActs(ctx context.Context, tx *sql.Tx, publisher string, acts []sejm.Act) error {
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO acts (id, publisher, title, status, published, position, year, type, address)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (id) DO UPDATE SET
+			publisher = excluded.publisher, title = excluded.title, status = excluded.status,
+			published = excluded.published, position = excluded.position, year = excluded.year,
+			type = excluded.type, address = excluded.address, updated_at = now()
+	`)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := stmt.Close(); err != nil {
+			slog.Error("Error closing statement", "error", err)
+		}
+	}()
+
+	for _, act := range acts {
+		if _, err := stmt.ExecContext(ctx,
+			act.ID, publisher, act.Title, act.Status, act.Published,
+			act.Position, act.Year, act.Type, act.Address,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// detailsColumns are the columns of act_details, in the order of detailsFields
+const detailsColumns = `id, title, status, published, type, address, display_address, position, year,
+	announcement_date, change_date, publisher, text_html, text_pdf, volume,
+	entry_into_force, in_force, keywords, keywords_names, released_by, texts,
+	act_references, authorized_body, directives, obligated, previous_title, prints`
+
+// detailsFields returns pointers to the fields of act details stored in plain columns
+// and to those stored as JSON, in the order of detailsColumns
+func detailsFields(details *sejm.ActDetails) (plain, encoded []any) {
+	plain = []any{
+		&details.ID, &details.Title, &details.Status, &details.Published,
+		&details.Type, &details.Address, &details.DisplayAddress, &details.Position,
+		&details.Year, &details.AnnouncementDate, &details.ChangeDate, &details.Publisher,
+		&details.TextHTML, &details.TextPDF, &details.Volume, &details.EntryIntoForce, &details.InForce,
+	}
+	encoded = []any{
+		&details.Keywords, &details.KeywordsNames, &details.ReleasedBy, &details.Texts,
+		&details.References, &details.AuthorizedBody, &details.Directives, &details.Obligated,
+		&details.PreviousTitle, &details.Prints,
+	}
+	return plain, encoded
+}
+
+// GetActDetails retrieves act details from the cache
+func (d *DB) GetActDetails(ctx context.Context, id sejm.ELI) (_ *sejm.ActDetails, err error) {
+	ctx, end := startQuery(ctx, "get_act_details")
+	defer end(&err)
+
+	details, err := scanActDetails(d.QueryRowContext(ctx, "SELECT "+detailsColumns+" FROM act_details WHERE id = $1", id.String()))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return details, err
+}
+
+// scanActDetails scans the detailsColumns of a row, decoding those stored as JSON
+func scanActDetails(row rowScanner) (*sejm.ActDetails, error) {
+	var details sejm.ActDetails
+	plain, encoded := detailsFields(&details)
+	data := make([][]byte, len(encoded))
+	dest := plain
+	for i := range data {
+		dest = append(dest, &data[i])
+	}
+
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	for i, field := range encoded {
+		if err := json.Unmarshal(data[i], field); err != nil {
+			return nil, fmt.Errorf("failed to parse column %d of act details: %w", len(plain)+i+1, err)
+		}
+	}
+
+	return &details, nil
+}
+
+// StoreActDetails stores act details in the cache
+func (d *DB) StoreActDetails(ctx context.Context, details *sejm.ActDetails) (err error) {
+	ctx, end := startQuery(ctx, "store_act_details")
+	defer end(&err)
+
+	plain, encoded := detailsFields(details)
+	args := make([]any, 0, len(plain)+len(encoded))
+	for _, field := range plain {
+		args = append(args, field)
+	}
+	for _, field := range encoded {
+		data, err := json.Marshal(field)
+		if err != nil {
+			return fmt.Errorf("failed to marshal act details: %w", err)
+		}
+		args = append(args, string(data))
+	}
+
+	tx, err := d.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer rollback(tx)
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO act_details (`+detailsColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17,
+			$18, $19, $20, $21, $22, $23, $24, $25, $26, $27)
+		ON CONFLICT (id) DO UPDATE SET
+			title = excluded.title, status = excluded.status, published = excluded.published,
+			type = excluded.type, address = excluded.address, display_address = excluded.display_address,
+			position = excluded.position, year = excluded.year, announcement_date = excluded.announcement_date,
+			change_date = excluded.change_date, publisher = excluded.publisher, text_html = excluded.text_html,
+			text_pdf = excluded.text_pdf, volume = excluded.volume, entry_into_force = excluded.entry_into_force,
+			in_force = excluded.in_force, keywords = excluded.keywords, keywords_names = excluded.keywords_names,
+			released_by = excluded.released_by, texts = excluded.texts, act_references = excluded.act_references,
+			authorized_body = excluded.authorized_body, directives = excluded.directives,
+			obligated = excluded.obligated,
+			previous_title = excluded.previous_title, prints = excluded.prints, updated_at = now()
+	`, args...)
+	if err != nil {
+		return err
+	}
+
+	if err := storeLinks(ctx, tx, details); err != nil {
+		return err
+	}
+
+	entry := db.CacheEntry{Kind: db.CacheKindDetails, Key: details.ID, TotalCount: 1, ETag: details.ETag, Outcome: db.OutcomeOK}
+	if err := storeCacheEntry(ctx, tx, entry); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteActDetails removes the cached details and references of an act the API no longer
+// has, recording it as not found
+func (d *DB) DeleteActDetails(ctx context.Context, id string) (err error) {
+	ctx, end := startQuery(ctx, "delete_act_details")
+	defer end(&err)
+
+	tx, err := d.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer rollback(tx)
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM act_details WHERE id = $1", id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM act_links WHERE source = $1", id); err != nil {
+		return err
+	}
+
+	entry := db.CacheEntry{Kind: db.CacheKindDetails, Key: id, Outcome: db.OutcomeNotFound}
+	if err := storeCacheEntry(ctx, tx, entry); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,91 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"ustawka/db/dbtest"
+	"ustawka/db/postgres"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDatabaseURL returns the database to test against from USTAWKA_TEST_DB_URL,
+// skipping the test when it is not set
+func testDatabaseURL(t *testing.T) string {
+	t.Helper()
+	dbURL := os.Getenv("USTAWKA_TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("USTAWKA_TEST_DB_URL not set, skipping PostgreSQL tests")
+	}
+	return dbURL
+}
+
+// openSchema connects to a schema of its own, dropped once the test ends, so that
+// tests start with an empty database and do not see each other's data
+func openSchema(t *testing.T, dbURL string, open func(string) (*postgres.DB, error)) *postgres.DB {
+	t.Helper()
+	admin, err := sql.Open("postgres", dbURL)
+	require.NoError(t, err)
+	t.Cleanup(func() { admin.Close() })
+
+	schema := fmt.Sprintf("ustawka_test_%d", time.Now().UnixNano())
+	_, err = admin.Exec("CREATE SCHEMA " + schema)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		assert.NoError(t, err)
+	})
+
+	u, err := url.Parse(dbURL)
+	require.NoError(t, err)
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+
+	database, err := open(u.String())
+	require.NoError(t, err)
+	t.Cleanup(func() { database.Close() })
+	return database
+}
+
+func TestConformance(t *testing.T) {
+	dbURL := testDatabaseURL(t)
+	dbtest.Run(t, func(t *testing.T) dbtest.Store {
+		return openSchema(t, dbURL, postgres.New)
+	})
+}
+
+func TestMigrate(t *testing.T) {
+	database := openSchema(t, testDatabaseURL(t), postgres.Open)
+	ctx := context.Background()
+
+	migrations, err := database.Migrations(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+	for i, migration := range migrations {
+		assert.Equal(t, i+1, migration.Version, "migrations are numbered without gaps")
+		assert.Nil(t, migration.AppliedAt)
+	}
+
+	applied, err := database.Migrate(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, len(migrations), applied)
+
+	migrations, err = database.Migrations(ctx)
+	require.NoError(t, err)
+	for _, migration := range migrations {
+		assert.NotNil(t, migration.AppliedAt, migration.Name)
+	}
+
+	// Applied migrations are not run again
+	applied, err = database.Migrate(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, applied)
+}
@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"ustawka/sejm"
+)
+
+// searchDocument weighs the searchable text of an act, titles first, then keywords,
+// previous titles and the publisher
+const searchDocument = `
+	setweight(to_tsvector('simple', fold_search_text(a.title)), 'A') ||
+	setweight(to_tsvector('simple', fold_search_text(COALESCE(d.keywords_names::text, ''))), 'B') ||
+	setweight(to_tsvector('simple', fold_search_text(COALESCE(d.previous_title::text, ''))), 'C') ||
+	setweight(to_tsvector('simple', fold_search_text(a.publisher)), 'D')`
+
+// SearchActs returns cached acts matching the query, best matches first
+func (d *DB) SearchActs(ctx context.Context, query string, limit int) (_ []sejm.Act, err error) {
+	ctx, end := startQuery(ctx, "search_acts")
+	defer end(&err)
+
+	tsquery := buildTSQuery(query)
+	if tsquery == "" {
+		return []sejm.Act{}, nil
+	}
+
+	rows, err := d.QueryContext(ctx, `
+		SELECT a.id, a.publisher, a.title, a.status, a.published, a.position, a.year, a.type, a.address
+		FROM acts a LEFT JOIN act_details d ON d.id = a.id,
+			to_tsquery('simple', fold_search_text($1)) q
+		WHERE (`+searchDocument+`) @@ q
+		ORDER BY ts_rank(`+searchDocument+`, q) DESC, a.year DESC, a.position DESC
+		LIMIT $2`, tsquery, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows)
+
+	acts := make([]sejm.Act, 0)
+	for rows.Next() {
+		act, err := scanAct(rows)
+		if err != nil {
+			return nil, err
+		}
+		acts = append(acts, act)
+	}
+
+	return acts, rows.Err()
+}
+
+// searchTerm matches a quoted phrase or a single word of a user query
+var searchTerm = regexp.MustCompile(`"([^"]*)"|[^\s"]+`)
+
+// buildTSQuery turns free text into a tsquery the way the SQLite index reads it: quoted
+// phrases are kept as phrases, all other words must appear and the last one may be a prefix
+func buildTSQuery(query string) string {
+	terms := make([]string, 0)
+	matches := searchTerm.FindAllStringSubmatch(query, -1)
+
+	for i, m := range matches {
+		if strings.HasPrefix(m[0], `"`) {
+			if words := searchWords(m[1]); len(words) > 0 {
+				terms = append(terms, "("+strings.Join(words, " <-> ")+")")
+			}
+			continue
+		}
+
+		words := searchWords(m[0])
+		if len(words) == 0 {
+			continue
+		}
+		if i == len(matches)-1 {
+			words[len(words)-1] += ":*"
+		}
+		terms = append(terms, words...)
+	}
+
+	return strings.Join(terms, " & ")
+}
+
+// searchWords splits text into words of letters and digits, dropping tsquery operators
+func searchWords(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// GetSearchResult retrieves a cached upstream search page and its age
+func (d *DB) GetSearchResult(ctx context.Context, key string) (_ *sejm.SearchResult, _ time.Duration, err error) {
+	ctx, end := startQuery(ctx, "get_search_result")
+	defer end(&err)
+
+	var result []byte
+	var updatedAt time.Time
+	err = d.QueryRowContext(ctx,
+		"SELECT result, updated_at FROM search_cache WHERE query_key = $1", key,
+	).Scan(&result, &updatedAt)
+	if err == sql.ErrNoRows {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var page sejm.SearchResult
+	if err := json.Unmarshal(result, &page); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse search result: %w", err)
+	}
+
+	return &page, time.Since(updatedAt), nil
+}
+
+// StoreSearchResult caches an upstream search page under its query key
+func (d *DB) StoreSearchResult(ctx context.Context, key string, result *sejm.SearchResult) (err error) {
+	ctx, end := startQuery(ctx, "store_search_result")
+	defer end(&err)
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal search result: %w", err)
+	}
+
+	_, err = d.ExecContext(ctx, `
+		INSERT INTO search_cache (query_key, result, updated_at)
+		VALUES ($1, $2, now())
+		ON CONFLICT (query_key) DO UPDATE SET result = excluded.result, updated_at = now()
+	`, key, string(data))
+	return err
+}
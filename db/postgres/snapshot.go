@@ -0,0 +1,127 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"ustawka/db"
+	"ustawka/sejm"
+)
+
+// eachRow runs a query and calls scan for each row of the result
+func (d *DB) eachRow(ctx context.Context, scan func(rows *sql.Rows) error, query string, args ...any) error {
+	rows, err := d.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer closeRows(rows)
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// EachAct calls fn for every cached act, ordered by publisher, year and position
+func (d *DB) EachAct(ctx context.Context, fn func(sejm.Act) error) (err error) {
+	ctx, end := startQuery(ctx, "each_act")
+	defer end(&err)
+
+	return d.eachRow(ctx, func(rows *sql.Rows) error {
+		act, err := scanAct(rows)
+		if err != nil {
+			return err
+		}
+		return fn(act)
+	}, "SELECT "+actColumns+" FROM acts ORDER BY publisher, year, position")
+}
+
+// EachActDetails calls fn for all cached act details, ordered by act ID
+func (d *DB) EachActDetails(ctx context.Context, fn func(*sejm.ActDetails) error) (err error) {
+	ctx, end := startQuery(ctx, "each_act_details")
+	defer end(&err)
+
+	return d.eachRow(ctx, func(rows *sql.Rows) error {
+		details, err := scanActDetails(rows)
+		if err != nil {
+			return err
+		}
+		return fn(details)
+	}, "SELECT "+detailsColumns+" FROM act_details ORDER BY id")
+}
+
+// EachActText calls fn for the index entry of every downloaded act text
+func (d *DB) EachActText(ctx context.Context, fn func(db.ActText) error) (err error) {
+	ctx, end := startQuery(ctx, "each_act_text")
+	defer end(&err)
+
+	return d.eachRow(ctx, func(rows *sql.Rows) error {
+		var text db.ActText
+		if err := rows.Scan(&text.ActID, &text.Name, &text.ContentType, &text.Hash, &text.Size, &text.ChangeDate, &text.FetchedAt); err != nil {
+			return err
+		}
+		return fn(text)
+	}, "SELECT act_id, name, content_type, hash, size, change_date, fetched_at FROM act_texts ORDER BY act_id, name")
+}
+
+// EachCacheEntry calls fn for the cache metadata of every fetched resource
+func (d *DB) EachCacheEntry(ctx context.Context, fn func(db.CacheEntry) error) (err error) {
+	ctx, end := startQuery(ctx, "each_cache_entry")
+	defer end(&err)
+
+	return d.eachRow(ctx, func(rows *sql.Rows) error {
+		var entry db.CacheEntry
+		if err := rows.Scan(
+			&entry.Kind, &entry.Key, &entry.FetchedAt, &entry.TotalCount, &entry.ETag, &entry.Outcome, &entry.Invalidated,
+		); err != nil {
+			return err
+		}
+		return fn(entry)
+	}, "SELECT kind, key, fetched_at, total_count, etag, outcome, invalidated FROM cache_entries ORDER BY kind, key")
+}
+
+// RestoreCacheEntry stores the cache metadata of a resource as recorded elsewhere,
+// keeping when it was fetched and whether it was invalidated
+func (d *DB) RestoreCacheEntry(ctx context.Context, entry db.CacheEntry) (err error) {
+	ctx, end := startQuery(ctx, "restore_cache_entry")
+	defer end(&err)
+
+	_, err = d.ExecContext(ctx, `
+		INSERT INTO cache_entries (kind, key, fetched_at, total_count, etag, outcome, invalidated)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (kind, key) DO UPDATE SET
+			fetched_at = excluded.fetched_at, total_count = excluded.total_count,
+			etag = excluded.etag, outcome = excluded.outcome, invalidated = excluded.invalidated
+	`, entry.Kind, entry.Key, entry.FetchedAt, entry.TotalCount, entry.ETag, entry.Outcome, entry.Invalidated)
+	return err
+}
+
+// StoreSnapshot records that a snapshot exported at createdAt was imported
+func (d *DB) StoreSnapshot(ctx context.Context, createdAt time.Time) (err error) {
+	ctx, end := startQuery(ctx, "store_snapshot")
+	defer end(&err)
+
+	_, err = d.ExecContext(ctx, "INSERT INTO snapshots (created_at) VALUES ($1)", createdAt)
+	return err
+}
+
+// GetSnapshot retrieves the most recently imported snapshot, or nil if none was
+func (d *DB) GetSnapshot(ctx context.Context) (_ *db.Snapshot, err error) {
+	ctx, end := startQuery(ctx, "get_snapshot")
+	defer end(&err)
+
+	var snapshot db.Snapshot
+	err = d.QueryRowContext(ctx,
+		"SELECT created_at, imported_at FROM snapshots ORDER BY id DESC LIMIT 1",
+	).Scan(&snapshot.CreatedAt, &snapshot.ImportedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
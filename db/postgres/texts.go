@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"ustawka/acttext"
+	"ustawka/db"
+	"ustawka/sejm"
+)
+
+// GetActText retrieves the index entry of an act text, or nil if it has not been downloaded
+func (d *DB) GetActText(ctx context.Context, id sejm.ELI, name string) (_ *db.ActText, err error) {
+	ctx, end := startQuery(ctx, "get_act_text")
+	defer end(&err)
+
+	text := db.ActText{ActID: id.String(), Name: name}
+	err = d.QueryRowContext(ctx,
+		"SELECT content_type, hash, size, change_date, fetched_at FROM act_texts WHERE act_id = $1 AND name = $2",
+		text.ActID, name,
+	).Scan(&text.ContentType, &text.Hash, &text.Size, &text.ChangeDate, &text.FetchedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &text, nil
+}
+
+// StoreActText indexes a downloaded act text, replacing a previous download;
+// a text with new content is also recorded as a new version
+func (d *DB) StoreActText(ctx context.Context, text db.ActText) (err error) {
+	ctx, end := startQuery(ctx, "store_act_text")
+	defer end(&err)
+
+	tx, err := d.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer rollback(tx)
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO act_texts (act_id, name, content_type, hash, size, change_date, fetched_at)
+		VALUES ($1, $2, $3, $4, $5, $6, now())
+		ON CONFLICT (act_id, name) DO UPDATE SET
+			content_type = excluded.content_type, hash = excluded.hash, size = excluded.size,
+			change_date = excluded.change_date, fetched_at = excluded.fetched_at
+	`, text.ActID, text.Name, text.ContentType, text.Hash, text.Size, text.ChangeDate)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO act_text_versions (act_id, name, hash, change_date, fetched_at)
+		VALUES ($1, $2, $3, $4, now())
+		ON CONFLICT DO NOTHING
+	`, text.ActID, text.Name, text.Hash, text.ChangeDate)
+	if err != nil {
+		return err
+	}
+
+	entry := db.CacheEntry{Kind: db.CacheKindTexts, Key: db.TextKey(text.ActID, text.Name), TotalCount: 1, ETag: text.ETag, Outcome: db.OutcomeOK}
+	if err := storeCacheEntry(ctx, tx, entry); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetActTextVersions returns the downloaded versions of an act text, oldest first
+func (d *DB) GetActTextVersions(ctx context.Context, id sejm.ELI, name string) (_ []db.ActTextVersion, err error) {
+	ctx, end := startQuery(ctx, "get_act_text_versions")
+	defer end(&err)
+
+	rows, err := d.QueryContext(ctx, `
+		SELECT id, hash, change_date, fetched_at FROM act_text_versions
+		WHERE act_id = $1 AND name = $2 ORDER BY id
+	`, id.String(), name)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows)
+
+	versions := make([]db.ActTextVersion, 0)
+	for rows.Next() {
+		var version db.ActTextVersion
+		if err := rows.Scan(&version.ID, &version.Hash, &version.ChangeDate, &version.FetchedAt); err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+
+	return versions, rows.Err()
+}
+
+// GetActDocument retrieves the structured text parsed from an HTML text blob, or nil if not parsed yet
+func (d *DB) GetActDocument(ctx context.Context, hash string) (_ *acttext.Document, err error) {
+	ctx, end := startQuery(ctx, "get_act_document")
+	defer end(&err)
+
+	var data []byte
+	err = d.QueryRowContext(ctx, "SELECT document FROM act_documents WHERE hash = $1", hash).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var doc acttext.Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse document: %w", err)
+	}
+	return &doc, nil
+}
+
+// StoreActDocument stores the structured text parsed from an HTML text blob
+func (d *DB) StoreActDocument(ctx context.Context, hash string, doc *acttext.Document) (err error) {
+	ctx, end := startQuery(ctx, "store_act_document")
+	defer end(&err)
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal document: %w", err)
+	}
+
+	_, err = d.ExecContext(ctx, `
+		INSERT INTO act_documents (hash, document) VALUES ($1, $2)
+		ON CONFLICT (hash) DO UPDATE SET document = excluded.document
+	`, hash, string(data))
+	return err
+}
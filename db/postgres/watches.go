@@ -0,0 +1,113 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"ustawka/db"
+	"ustawka/sejm"
+)
+
+// AddWatch stores a new watch and returns it with its ID
+func (d *DB) AddWatch(ctx context.Context, watch db.Watch) (_ *db.Watch, err error) {
+	ctx, end := startQuery(ctx, "add_watch")
+	defer end(&err)
+
+	err = d.QueryRowContext(ctx,
+		"INSERT INTO watches (kind, target, url, secret) VALUES ($1, $2, $3, $4) RETURNING id, created_at",
+		watch.Kind, watch.Target, watch.URL, watch.Secret,
+	).Scan(&watch.ID, &watch.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &watch, nil
+}
+
+// DeleteWatch removes a watch
+func (d *DB) DeleteWatch(ctx context.Context, id int64) (err error) {
+	ctx, end := startQuery(ctx, "delete_watch")
+	defer end(&err)
+
+	result, err := d.ExecContext(ctx, "DELETE FROM watches WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return db.ErrWatchNotFound
+	}
+	return nil
+}
+
+// ListWatches returns all watches, oldest first
+func (d *DB) ListWatches(ctx context.Context) (_ []db.Watch, err error) {
+	ctx, end := startQuery(ctx, "list_watches")
+	defer end(&err)
+
+	rows, err := d.QueryContext(ctx, "SELECT id, kind, target, url, secret, created_at FROM watches ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows)
+
+	watches := make([]db.Watch, 0)
+	for rows.Next() {
+		var watch db.Watch
+		if err := rows.Scan(&watch.ID, &watch.Kind, &watch.Target, &watch.URL, &watch.Secret, &watch.CreatedAt); err != nil {
+			return nil, err
+		}
+		watches = append(watches, watch)
+	}
+
+	return watches, rows.Err()
+}
+
+// StoreDeadLetter records a webhook delivery that could not be completed
+func (d *DB) StoreDeadLetter(ctx context.Context, letter db.DeadLetter) (err error) {
+	ctx, end := startQuery(ctx, "store_dead_letter")
+	defer end(&err)
+
+	_, err = d.ExecContext(ctx,
+		`INSERT INTO webhook_dead_letters (watch_id, url, payload, error, attempts)
+		 VALUES ($1, $2, $3, $4, $5)`,
+		letter.WatchID, letter.URL, letter.Payload, letter.Error, letter.Attempts,
+	)
+	return err
+}
+
+// GetValidators retrieves the ETag and Last-Modified validators stored for an API URL
+func (d *DB) GetValidators(ctx context.Context, url string) (_ *sejm.Validators, err error) {
+	ctx, end := startQuery(ctx, "get_validators")
+	defer end(&err)
+
+	var validators sejm.Validators
+	err = d.QueryRowContext(ctx,
+		"SELECT etag, last_modified FROM response_validators WHERE url = $1", url,
+	).Scan(&validators.ETag, &validators.LastModified)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &validators, nil
+}
+
+// StoreValidators stores the ETag and Last-Modified validators of an API response by URL
+func (d *DB) StoreValidators(ctx context.Context, url string, validators sejm.Validators) (err error) {
+	ctx, end := startQuery(ctx, "store_validators")
+	defer end(&err)
+
+	_, err = d.ExecContext(ctx, `
+		INSERT INTO response_validators (url, etag, last_modified, updated_at)
+		VALUES ($1, $2, $3, now())
+		ON CONFLICT (url) DO UPDATE SET etag = excluded.etag, last_modified = excluded.last_modified,
+			updated_at = now()
+	`, url, validators.ETag, validators.LastModified)
+	return err
+}
@@ -0,0 +1,182 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"ustawka/sejm"
+)
+
+// ftsTableQuery creates the FTS5 index; diacritics are folded so that
+// "zazalenie" also finds "zażalenie"
+const ftsTableQuery = `CREATE VIRTUAL TABLE IF NOT EXISTS acts_fts USING fts5(
+	id UNINDEXED, publisher, title, keywords, previous_titles,
+	tokenize = 'unicode61 remove_diacritics 2'
+)`
+
+// ftsRankOrder ranks matches with BM25, weighting titles above keywords and previous titles
+const ftsRankOrder = `bm25(acts_fts, 0.0, 1.0, 10.0, 5.0, 2.0), a.year DESC, a.position DESC`
+
+// searchTriggers keep the full-text index in sync with acts and their details
+var searchTriggers = []string{
+	`CREATE TRIGGER IF NOT EXISTS acts_fts_insert
+	AFTER INSERT ON acts
+	BEGIN
+		INSERT INTO acts_fts (rowid, id, publisher, title, keywords, previous_titles)
+		VALUES (NEW.rowid, NEW.id, NEW.publisher, NEW.title,
+			COALESCE((SELECT keywords_names FROM act_details WHERE id = NEW.id), ''),
+			COALESCE((SELECT previous_title FROM act_details WHERE id = NEW.id), ''));
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS acts_fts_delete
+	AFTER DELETE ON acts
+	BEGIN
+		DELETE FROM acts_fts WHERE rowid = OLD.rowid;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS acts_fts_update
+	AFTER UPDATE OF id, publisher, title ON acts
+	BEGIN
+		DELETE FROM acts_fts WHERE rowid = OLD.rowid;
+		INSERT INTO acts_fts (rowid, id, publisher, title, keywords, previous_titles)
+		VALUES (NEW.rowid, NEW.id, NEW.publisher, NEW.title,
+			COALESCE((SELECT keywords_names FROM act_details WHERE id = NEW.id), ''),
+			COALESCE((SELECT previous_title FROM act_details WHERE id = NEW.id), ''));
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS act_details_fts_insert
+	AFTER INSERT ON act_details
+	BEGIN
+		UPDATE acts_fts
+		SET keywords = COALESCE(NEW.keywords_names, ''), previous_titles = COALESCE(NEW.previous_title, '')
+		WHERE rowid = (SELECT rowid FROM acts WHERE id = NEW.id);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS act_details_fts_update
+	AFTER UPDATE OF keywords_names, previous_title ON act_details
+	BEGIN
+		UPDATE acts_fts
+		SET keywords = COALESCE(NEW.keywords_names, ''), previous_titles = COALESCE(NEW.previous_title, '')
+		WHERE rowid = (SELECT rowid FROM acts WHERE id = NEW.id);
+	END`,
+}
+
+// createSearchIndex creates the full-text index with its triggers and
+// rebuilds it when it is out of sync with the acts table
+func createSearchIndex(db *sql.DB) error {
+	// Builds without FTS5 used to create an FTS4 index, which has no BM25 ranking
+	var tableSQL string
+	err := db.QueryRow("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'acts_fts'").Scan(&tableSQL)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if strings.Contains(strings.ToLower(tableSQL), "using fts4") {
+		slog.Info("Replacing FTS4 search index with FTS5")
+		if _, err := db.Exec("DROP TABLE acts_fts"); err != nil {
+			return err
+		}
+	}
+
+	if _, err := db.Exec(ftsTableQuery); err != nil {
+		return err
+	}
+
+	for _, query := range searchTriggers {
+		if _, err := db.Exec(query); err != nil {
+			return err
+		}
+	}
+
+	var indexed, acts int
+	if err := db.QueryRow("SELECT COUNT(*) FROM acts_fts").Scan(&indexed); err != nil {
+		return err
+	}
+	if err := db.QueryRow("SELECT COUNT(*) FROM acts").Scan(&acts); err != nil {
+		return err
+	}
+	if indexed == acts {
+		return nil
+	}
+
+	slog.Info("Rebuilding search index", "indexed", indexed, "acts", acts)
+	if _, err := db.Exec("DELETE FROM acts_fts"); err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+		INSERT INTO acts_fts (rowid, id, publisher, title, keywords, previous_titles)
+		SELECT a.rowid, a.id, a.publisher, a.title, COALESCE(d.keywords_names, ''), COALESCE(d.previous_title, '')
+		FROM acts a LEFT JOIN act_details d ON d.id = a.id
+	`)
+	return err
+}
+
+// SearchActs returns cached acts matching the query, best matches first
+func (db *DB) SearchActs(ctx context.Context, query string, limit int) (_ []sejm.Act, err error) {
+	ctx, end := startQuery(ctx, "search_acts")
+	defer end(&err)
+
+	match := buildMatchQuery(query)
+	if match == "" {
+		return []sejm.Act{}, nil
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT a.id, a.publisher, a.title, a.status, a.published, a.position, a.year, a.type, a.address
+		FROM acts_fts JOIN acts a ON a.rowid = acts_fts.rowid
+		WHERE acts_fts MATCH ?
+		ORDER BY `+ftsRankOrder+`
+		LIMIT ?`, match, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("Error closing rows", "error", err)
+		}
+	}()
+
+	acts := make([]sejm.Act, 0)
+	for rows.Next() {
+		var act sejm.Act
+		if err := rows.Scan(
+			&act.ID, &act.Publisher, &act.Title, &act.Status, &act.Published,
+			&act.Position, &act.Year, &act.Type, &act.Address,
+		); err != nil {
+			return nil, err
+		}
+		acts = append(acts, act)
+	}
+
+	return acts, rows.Err()
+}
+
+// searchTerm matches a quoted phrase or a single word of a user query
+var searchTerm = regexp.MustCompile(`"([^"]*)"|[^\s"]+`)
+
+// buildMatchQuery turns free text into a MATCH expression: quoted phrases are
+// kept as phrases, all other words must appear and the last one may be a prefix
+func buildMatchQuery(query string) string {
+	terms := make([]string, 0)
+	matches := searchTerm.FindAllStringSubmatch(query, -1)
+
+	for i, m := range matches {
+		if strings.HasPrefix(m[0], `"`) {
+			if phrase := strings.TrimSpace(m[1]); phrase != "" {
+				terms = append(terms, `"`+phrase+`"`)
+			}
+			continue
+		}
+
+		word := strings.Trim(m[0], "*")
+		if word == "" {
+			continue
+		}
+		if i == len(matches)-1 {
+			terms = append(terms, `"`+word+`"*`)
+		} else {
+			terms = append(terms, `"`+word+`"`)
+		}
+	}
+
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"ustawka/sejm"
+)
+
+// GetSearchResult retrieves a cached upstream search page and its age
+func (db *DB) GetSearchResult(ctx context.Context, key string) (_ *sejm.SearchResult, _ time.Duration, err error) {
+	ctx, end := startQuery(ctx, "get_search_result")
+	defer end(&err)
+
+	var result, updatedAt string
+	err = db.QueryRowContext(ctx,
+		"SELECT result, strftime('%Y-%m-%d %H:%M:%f', updated_at) FROM search_cache WHERE query_key = ?",
+		key,
+	).Scan(&result, &updatedAt)
+	if err == sql.ErrNoRows {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var page sejm.SearchResult
+	if err := json.Unmarshal([]byte(result), &page); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse search result: %w", err)
+	}
+
+	age, err := sinceTimestamp(updatedAt)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return &page, age, nil
+}
+
+// StoreSearchResult caches an upstream search page under its query key
+func (db *DB) StoreSearchResult(ctx context.Context, key string, result *sejm.SearchResult) (err error) {
+	ctx, end := startQuery(ctx, "store_search_result")
+	defer end(&err)
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal search result: %w", err)
+	}
+
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO search_cache (query_key, result, updated_at)
+		VALUES (?, ?, datetime('now'))
+		ON CONFLICT(query_key) DO UPDATE SET result = excluded.result, updated_at = datetime('now')
+	`, key, string(data))
+	return err
+}
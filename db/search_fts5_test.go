@@ -0,0 +1,62 @@
+package db_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"ustawka/db"
+	"ustawka/sejm"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchActsRanking(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, err := database.StoreActs(ctx, sejm.PublisherDU, 2024, []sejm.Act{
+		{ID: "DU/2024/1", Publisher: sejm.PublisherDU, Title: "Ustawa o drogach publicznych", Position: 1, Year: 2024},
+		{ID: "DU/2024/2", Publisher: sejm.PublisherDU, Title: "Ustawa o zmianie ustawy o podatkach", Position: 2, Year: 2024},
+		{ID: "DU/2024/3", Publisher: sejm.PublisherDU, Title: "Ustawa o podatkach", Position: 3, Year: 2024},
+	})
+	require.NoError(t, err)
+	require.NoError(t, database.StoreActDetails(ctx, &sejm.ActDetails{
+		ID: "DU/2024/1", Title: "Ustawa o drogach publicznych", KeywordsNames: []string{"podatkach"},
+	}))
+
+	// Title matches outrank keyword matches, shorter titles outrank longer ones
+	assert.Equal(t, []string{"DU/2024/3", "DU/2024/2", "DU/2024/1"}, searchIDs(t, database, "podatkach"))
+}
+
+func TestSearchIndexReplacesFTS4(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	database, err := db.New(path)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = database.StoreActs(ctx, sejm.PublisherDU, 2024, []sejm.Act{
+		{ID: "DU/2024/1", Publisher: sejm.PublisherDU, Title: "Ustawa o zmianie ustawy o podatkach", Position: 1, Year: 2024},
+		{ID: "DU/2024/2", Publisher: sejm.PublisherDU, Title: "Ustawa o podatkach", Position: 2, Year: 2024},
+	})
+	require.NoError(t, err)
+
+	// An index left by an older build without FTS5
+	_, err = database.ExecContext(ctx, "DROP TABLE acts_fts")
+	require.NoError(t, err)
+	_, err = database.ExecContext(ctx, `CREATE VIRTUAL TABLE acts_fts USING fts4(
+		id, publisher, title, keywords, previous_titles, notindexed=id
+	)`)
+	require.NoError(t, err)
+	require.NoError(t, database.Close())
+
+	database, err = db.New(path)
+	require.NoError(t, err)
+	defer database.Close()
+
+	var tableSQL string
+	require.NoError(t, database.QueryRowContext(ctx, "SELECT sql FROM sqlite_master WHERE name = 'acts_fts'").Scan(&tableSQL))
+	assert.Contains(t, tableSQL, "fts5")
+	assert.Equal(t, []string{"DU/2024/2", "DU/2024/1"}, searchIDs(t, database, "podatkach"))
+}
@@ -0,0 +1,124 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+	"time"
+	"ustawka/db"
+	"ustawka/sejm"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// searchIDs returns the IDs of acts matching the query
+func searchIDs(t *testing.T, database *db.DB, query string) []string {
+	t.Helper()
+	acts, err := database.SearchActs(context.Background(), query, 50)
+	require.NoError(t, err)
+
+	ids := make([]string, 0, len(acts))
+	for _, act := range acts {
+		ids = append(ids, act.ID)
+	}
+	return ids
+}
+
+func TestSearchActs(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, err := database.StoreActs(ctx, sejm.PublisherDU, 2024, []sejm.Act{
+		{ID: "DU/2024/1", Publisher: sejm.PublisherDU, Title: "Ustawa o podatku od towarów i usług", Position: 1, Year: 2024},
+		{ID: "DU/2024/2", Publisher: sejm.PublisherDU, Title: "Rozporządzenie w sprawie zażaleń", Position: 2, Year: 2024},
+	})
+	require.NoError(t, err)
+	_, err = database.StoreActs(ctx, sejm.PublisherMP, 2023, []sejm.Act{
+		{ID: "MP/2023/5", Publisher: sejm.PublisherMP, Title: "Obwieszczenie o stanie środowiska", Position: 5, Year: 2023},
+	})
+	require.NoError(t, err)
+
+	// Details stored after the act are indexed by the triggers
+	require.NoError(t, database.StoreActDetails(ctx, &sejm.ActDetails{
+		ID:            "MP/2023/5",
+		Title:         "Obwieszczenie o stanie środowiska",
+		KeywordsNames: []string{"ochrona przyrody"},
+		PreviousTitle: []string{"Komunikat ekologiczny"},
+	}))
+
+	assert.Equal(t, []string{"DU/2024/1"}, searchIDs(t, database, "podatku"))
+	assert.Equal(t, []string{"DU/2024/1"}, searchIDs(t, database, `"towarów i usług"`))
+	assert.Equal(t, []string{"DU/2024/2"}, searchIDs(t, database, "zazalen"), "diacritics and prefixes")
+	assert.Equal(t, []string{"MP/2023/5"}, searchIDs(t, database, "przyrody"), "keywords")
+	assert.Equal(t, []string{"MP/2023/5"}, searchIDs(t, database, "ekologiczny"), "previous titles")
+	assert.Equal(t, []string{"MP/2023/5"}, searchIDs(t, database, "MP"), "publisher")
+	assert.Empty(t, searchIDs(t, database, `""`))
+
+	// Replacing a year keeps the index in sync
+	_, err = database.StoreActs(ctx, sejm.PublisherDU, 2024, []sejm.Act{
+		{ID: "DU/2024/2", Publisher: sejm.PublisherDU, Title: "Rozporządzenie w sprawie skarg", Position: 2, Year: 2024},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, searchIDs(t, database, "podatku"))
+	assert.Empty(t, searchIDs(t, database, "zazalen"))
+	assert.Equal(t, []string{"DU/2024/2"}, searchIDs(t, database, "skarg"))
+}
+
+func TestSearchIndexRebuiltOnOpen(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, err := database.StoreActs(ctx, sejm.PublisherDU, 2024, []sejm.Act{
+		{ID: "DU/2024/1", Publisher: sejm.PublisherDU, Title: "Ustawa o kosmonautyce", Position: 1, Year: 2024},
+	})
+	require.NoError(t, err)
+
+	// Simulate a database cached before the index existed
+	_, err = database.ExecContext(ctx, "DELETE FROM acts_fts")
+	require.NoError(t, err)
+
+	reopened, err := db.New(databasePath(t, database))
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	assert.Equal(t, []string{"DU/2024/1"}, searchIDs(t, reopened, "kosmonautyce"))
+}
+
+// databasePath returns the file backing the main schema of the database
+func databasePath(t *testing.T, database *db.DB) string {
+	t.Helper()
+	var seq int
+	var name, file string
+	require.NoError(t, database.QueryRow("PRAGMA database_list").Scan(&seq, &name, &file))
+	return file
+}
+
+func TestStoreAndGetSearchResult(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	missing, age, err := database.GetSearchResult(ctx, "title=nic")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+	assert.Zero(t, age)
+
+	result := &sejm.SearchResult{
+		Items:      []sejm.Act{{ID: "DU/2024/1", Publisher: sejm.PublisherDU, Title: "Prawo wodne"}},
+		Offset:     0,
+		TotalCount: 1,
+	}
+	require.NoError(t, database.StoreSearchResult(ctx, "title=prawo+wodne", result))
+
+	// Storing the same key again replaces the page
+	result.TotalCount = 2
+	require.NoError(t, database.StoreSearchResult(ctx, "title=prawo+wodne", result))
+
+	cached, age, err := database.GetSearchResult(ctx, "title=prawo+wodne")
+	require.NoError(t, err)
+	assert.Equal(t, result, cached)
+	assert.Less(t, age, time.Minute)
+}
@@ -0,0 +1,171 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"ustawka/sejm"
+)
+
+// Snapshot records a snapshot of the cache imported into the database
+type Snapshot struct {
+	// CreatedAt is when the snapshot was exported
+	CreatedAt  time.Time
+	ImportedAt time.Time
+}
+
+// formatTimestamp formats a time like strftime('%Y-%m-%d %H:%M:%f') in UTC
+func formatTimestamp(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05.000")
+}
+
+// eachRow runs a query and calls scan for each row of the result
+func (db *DB) eachRow(ctx context.Context, scan func(rows *sql.Rows) error, query string, args ...any) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("Error closing rows", "error", err)
+		}
+	}()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// EachAct calls fn for every cached act, ordered by publisher, year and position
+func (db *DB) EachAct(ctx context.Context, fn func(sejm.Act) error) (err error) {
+	ctx, end := startQuery(ctx, "each_act")
+	defer end(&err)
+
+	return db.eachRow(ctx, func(rows *sql.Rows) error {
+		var act sejm.Act
+		if err := rows.Scan(
+			&act.ID, &act.Publisher, &act.Title, &act.Status, &act.Published,
+			&act.Position, &act.Year, &act.Type, &act.Address,
+		); err != nil {
+			return err
+		}
+		return fn(act)
+	}, `SELECT id, publisher, title, status, published, position, year, type, address
+		FROM acts ORDER BY publisher, year, position`)
+}
+
+// EachActDetails calls fn for all cached act details, ordered by act ID
+func (db *DB) EachActDetails(ctx context.Context, fn func(*sejm.ActDetails) error) (err error) {
+	ctx, end := startQuery(ctx, "each_act_details")
+	defer end(&err)
+
+	return db.eachRow(ctx, func(rows *sql.Rows) error {
+		details, jsonStrings, err := scanActDetailsRow(rows)
+		if err != nil {
+			return err
+		}
+		if details, err = db.parseJSONFields(details, jsonStrings); err != nil {
+			return err
+		}
+		return fn(details)
+	}, detailsQuery+" ORDER BY id")
+}
+
+// EachActText calls fn for the index entry of every downloaded act text
+func (db *DB) EachActText(ctx context.Context, fn func(ActText) error) (err error) {
+	ctx, end := startQuery(ctx, "each_act_text")
+	defer end(&err)
+
+	return db.eachRow(ctx, func(rows *sql.Rows) error {
+		var text ActText
+		var fetchedAt string
+		if err := rows.Scan(&text.ActID, &text.Name, &text.ContentType, &text.Hash, &text.Size, &text.ChangeDate, &fetchedAt); err != nil {
+			return err
+		}
+		var err error
+		if text.FetchedAt, err = time.Parse(timestampLayout, fetchedAt); err != nil {
+			return err
+		}
+		return fn(text)
+	}, `SELECT act_id, name, content_type, hash, size, change_date, strftime('%Y-%m-%d %H:%M:%f', fetched_at)
+		FROM act_texts ORDER BY act_id, name`)
+}
+
+// EachCacheEntry calls fn for the cache metadata of every fetched resource
+func (db *DB) EachCacheEntry(ctx context.Context, fn func(CacheEntry) error) (err error) {
+	ctx, end := startQuery(ctx, "each_cache_entry")
+	defer end(&err)
+
+	return db.eachRow(ctx, func(rows *sql.Rows) error {
+		var entry CacheEntry
+		var fetchedAt string
+		if err := rows.Scan(
+			&entry.Kind, &entry.Key, &fetchedAt, &entry.TotalCount, &entry.ETag, &entry.Outcome, &entry.Invalidated,
+		); err != nil {
+			return err
+		}
+		var err error
+		if entry.FetchedAt, err = time.Parse(timestampLayout, fetchedAt); err != nil {
+			return err
+		}
+		return fn(entry)
+	}, `SELECT kind, key, strftime('%Y-%m-%d %H:%M:%f', fetched_at), total_count, etag, outcome, invalidated
+		FROM cache_entries ORDER BY kind, key`)
+}
+
+// RestoreCacheEntry stores the cache metadata of a resource as recorded elsewhere,
+// keeping when it was fetched and whether it was invalidated
+func (db *DB) RestoreCacheEntry(ctx context.Context, entry CacheEntry) (err error) {
+	ctx, end := startQuery(ctx, "restore_cache_entry")
+	defer end(&err)
+
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO cache_entries (kind, key, fetched_at, total_count, etag, outcome, invalidated)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(kind, key) DO UPDATE SET
+			fetched_at = excluded.fetched_at, total_count = excluded.total_count,
+			etag = excluded.etag, outcome = excluded.outcome, invalidated = excluded.invalidated
+	`, entry.Kind, entry.Key, formatTimestamp(entry.FetchedAt), entry.TotalCount, entry.ETag, entry.Outcome, entry.Invalidated)
+	return err
+}
+
+// StoreSnapshot records that a snapshot exported at createdAt was imported
+func (db *DB) StoreSnapshot(ctx context.Context, createdAt time.Time) (err error) {
+	ctx, end := startQuery(ctx, "store_snapshot")
+	defer end(&err)
+
+	_, err = db.ExecContext(ctx, "INSERT INTO snapshots (created_at) VALUES (?)", formatTimestamp(createdAt))
+	return err
+}
+
+// GetSnapshot retrieves the most recently imported snapshot, or nil if none was
+func (db *DB) GetSnapshot(ctx context.Context) (_ *Snapshot, err error) {
+	ctx, end := startQuery(ctx, "get_snapshot")
+	defer end(&err)
+
+	var createdAt, importedAt string
+	err = db.QueryRowContext(ctx, `
+		SELECT strftime('%Y-%m-%d %H:%M:%f', created_at), strftime('%Y-%m-%d %H:%M:%f', imported_at)
+		FROM snapshots ORDER BY id DESC LIMIT 1
+	`).Scan(&createdAt, &importedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot Snapshot
+	if snapshot.CreatedAt, err = time.Parse(timestampLayout, createdAt); err != nil {
+		return nil, err
+	}
+	if snapshot.ImportedAt, err = time.Parse(timestampLayout, importedAt); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
@@ -0,0 +1,134 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"ustawka/sejm"
+)
+
+// ActText indexes a downloaded act text kept in the blob store
+type ActText struct {
+	ActID       string
+	Name        string
+	ContentType string
+	Hash        string
+	Size        int64
+	// ChangeDate is the change date of the act when the text was downloaded
+	ChangeDate string
+	FetchedAt  time.Time
+	// ETag is the API's ETag of the text, kept in the cache metadata
+	ETag string
+}
+
+// ActTextVersion is a distinct downloaded version of an act text
+type ActTextVersion struct {
+	ID         int64     `json:"id"`
+	Hash       string    `json:"hash"`
+	ChangeDate string    `json:"changeDate"`
+	FetchedAt  time.Time `json:"fetchedAt"`
+}
+
+// GetActText retrieves the index entry of an act text, or nil if it has not been downloaded
+func (db *DB) GetActText(ctx context.Context, id sejm.ELI, name string) (_ *ActText, err error) {
+	ctx, end := startQuery(ctx, "get_act_text")
+	defer end(&err)
+
+	text := ActText{ActID: id.String(), Name: name}
+	var fetchedAt string
+	err = db.QueryRowContext(ctx,
+		"SELECT content_type, hash, size, change_date, fetched_at FROM act_texts WHERE act_id = ? AND name = ?",
+		text.ActID, name,
+	).Scan(&text.ContentType, &text.Hash, &text.Size, &text.ChangeDate, &fetchedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if text.FetchedAt, err = time.Parse(timestampLayout, fetchedAt); err != nil {
+		return nil, err
+	}
+	return &text, nil
+}
+
+// StoreActText indexes a downloaded act text, replacing a previous download;
+// a text with new content is also recorded as a new version
+func (db *DB) StoreActText(ctx context.Context, text ActText) (err error) {
+	ctx, end := startQuery(ctx, "store_act_text")
+	defer end(&err)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			slog.Error("Error rolling back transaction", "error", err)
+		}
+	}()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO act_texts (act_id, name, content_type, hash, size, change_date, fetched_at)
+		VALUES (?, ?, ?, ?, ?, ?, strftime('%Y-%m-%d %H:%M:%f', 'now'))
+		ON CONFLICT(act_id, name) DO UPDATE SET
+			content_type = excluded.content_type, hash = excluded.hash, size = excluded.size,
+			change_date = excluded.change_date, fetched_at = excluded.fetched_at
+	`, text.ActID, text.Name, text.ContentType, text.Hash, text.Size, text.ChangeDate)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO act_text_versions (act_id, name, hash, change_date, fetched_at)
+		VALUES (?, ?, ?, ?, strftime('%Y-%m-%d %H:%M:%f', 'now'))
+	`, text.ActID, text.Name, text.Hash, text.ChangeDate)
+	if err != nil {
+		return err
+	}
+
+	entry := CacheEntry{Kind: CacheKindTexts, Key: TextKey(text.ActID, text.Name), TotalCount: 1, ETag: text.ETag, Outcome: OutcomeOK}
+	if err := storeCacheEntry(ctx, tx, entry); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetActTextVersions returns the downloaded versions of an act text, oldest first
+func (db *DB) GetActTextVersions(ctx context.Context, id sejm.ELI, name string) (_ []ActTextVersion, err error) {
+	ctx, end := startQuery(ctx, "get_act_text_versions")
+	defer end(&err)
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, hash, change_date, fetched_at FROM act_text_versions
+		WHERE act_id = ? AND name = ? ORDER BY id
+	`, id.String(), name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("Error closing rows", "error", err)
+		}
+	}()
+
+	versions := make([]ActTextVersion, 0)
+	for rows.Next() {
+		var version ActTextVersion
+		var fetchedAt string
+		if err := rows.Scan(&version.ID, &version.Hash, &version.ChangeDate, &fetchedAt); err != nil {
+			return nil, err
+		}
+		if version.FetchedAt, err = time.Parse(timestampLayout, fetchedAt); err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+
+	return versions, rows.Err()
+}
@@ -0,0 +1,95 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+
+	"ustawka/acttext"
+	"ustawka/db"
+	"ustawka/sejm"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreAndGetActText(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	actID := sejm.ELI{Publisher: sejm.PublisherDU, Year: 2024, Position: 1}
+
+	missing, err := database.GetActText(ctx, actID, "text.pdf")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	text := db.ActText{ActID: "DU/2024/1", Name: "text.pdf", ContentType: "application/pdf", Hash: "abc", Size: 8}
+	require.NoError(t, database.StoreActText(ctx, text))
+
+	stored, err := database.GetActText(ctx, actID, "text.pdf")
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.False(t, stored.FetchedAt.IsZero())
+	text.FetchedAt = stored.FetchedAt
+	assert.Equal(t, &text, stored)
+
+	// A new download replaces the index entry
+	text.Hash, text.Size = "def", 16
+	require.NoError(t, database.StoreActText(ctx, text))
+	stored, err = database.GetActText(ctx, actID, "text.pdf")
+	require.NoError(t, err)
+	assert.Equal(t, "def", stored.Hash)
+	assert.Equal(t, int64(16), stored.Size)
+}
+
+func TestStoreAndGetActDocument(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	missing, err := database.GetActDocument(ctx, "abc")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	doc := acttext.ParseLines([]string{"USTAWA", "Art. 1. 1. Tekst.", "2. Dalszy tekst."})
+	require.NoError(t, database.StoreActDocument(ctx, "abc", doc))
+
+	stored, err := database.GetActDocument(ctx, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, doc, stored)
+}
+
+func TestGetActTextVersions(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	actID := sejm.ELI{Publisher: sejm.PublisherDU, Year: 2024, Position: 1}
+
+	versions, err := database.GetActTextVersions(ctx, actID, "text.html")
+	require.NoError(t, err)
+	assert.Empty(t, versions)
+
+	text := db.ActText{ActID: "DU/2024/1", Name: "text.html", ContentType: "text/html", Hash: "abc", Size: 8,
+		ChangeDate: "2024-01-02T10:00:00"}
+	require.NoError(t, database.StoreActText(ctx, text))
+	// Downloading the same content again does not add a version
+	require.NoError(t, database.StoreActText(ctx, text))
+
+	text.Hash, text.ChangeDate = "def", "2024-06-01T10:00:00"
+	require.NoError(t, database.StoreActText(ctx, text))
+
+	versions, err = database.GetActTextVersions(ctx, actID, "text.html")
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, "abc", versions[0].Hash)
+	assert.Equal(t, "2024-01-02T10:00:00", versions[0].ChangeDate)
+	assert.Equal(t, "def", versions[1].Hash)
+	assert.Less(t, versions[0].ID, versions[1].ID)
+	assert.False(t, versions[1].FetchedAt.IsZero())
+
+	stored, err := database.GetActText(ctx, actID, "text.html")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-06-01T10:00:00", stored.ChangeDate)
+}
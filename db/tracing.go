@@ -0,0 +1,29 @@
+package db
+
+import (
+	"context"
+	"time"
+	"ustawka/metrics"
+	"ustawka/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer starts the spans of database queries
+var tracer = otel.Tracer("ustawka/db")
+
+// startQuery starts a span of a database query, returning a function that ends
+// it, marking it as failed if the error pointed to is set, and records the query duration
+func startQuery(ctx context.Context, query string) (context.Context, func(*error)) {
+	start := time.Now()
+	ctx, span := tracer.Start(ctx, "db."+query, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("db.system.name", "sqlite"),
+		attribute.String("db.operation.name", query),
+	))
+	return ctx, func(err *error) {
+		tracing.End(span, err)
+		metrics.ObserveDBQuery(query, start)
+	}
+}
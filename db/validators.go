@@ -0,0 +1,41 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"ustawka/sejm"
+)
+
+// GetValidators retrieves the ETag and Last-Modified validators stored for an API URL
+func (db *DB) GetValidators(ctx context.Context, url string) (_ *sejm.Validators, err error) {
+	ctx, end := startQuery(ctx, "get_validators")
+	defer end(&err)
+
+	var validators sejm.Validators
+	err = db.QueryRowContext(ctx,
+		"SELECT etag, last_modified FROM response_validators WHERE url = ?", url,
+	).Scan(&validators.ETag, &validators.LastModified)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &validators, nil
+}
+
+// StoreValidators stores the ETag and Last-Modified validators of an API response by URL
+func (db *DB) StoreValidators(ctx context.Context, url string, validators sejm.Validators) (err error) {
+	ctx, end := startQuery(ctx, "store_validators")
+	defer end(&err)
+
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO response_validators (url, etag, last_modified, updated_at)
+		VALUES (?, ?, ?, datetime('now'))
+		ON CONFLICT(url) DO UPDATE SET etag = excluded.etag, last_modified = excluded.last_modified,
+			updated_at = datetime('now')
+	`, url, validators.ETag, validators.LastModified)
+	return err
+}
@@ -0,0 +1,31 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+
+	"ustawka/sejm"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreAndGetValidators(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	url := "https://api.sejm.gov.pl/eli/acts/DU/2024?offset=0&limit=500"
+
+	validators, err := database.GetValidators(ctx, url)
+	require.NoError(t, err)
+	assert.Nil(t, validators)
+
+	require.NoError(t, database.StoreValidators(ctx, url, sejm.Validators{ETag: `"a"`}))
+	want := sejm.Validators{ETag: `"b"`, LastModified: "Wed, 01 May 2024 10:00:00 GMT"}
+	require.NoError(t, database.StoreValidators(ctx, url, want))
+
+	validators, err = database.GetValidators(ctx, url)
+	require.NoError(t, err)
+	assert.Equal(t, &want, validators)
+}
@@ -0,0 +1,146 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// Kinds of watched targets
+const (
+	// WatchAct watches a single act by its ID, e.g. DU/2024/1
+	WatchAct = "act"
+	// WatchKeyword watches acts tagged with a keyword
+	WatchKeyword = "keyword"
+	// WatchYear watches all acts of a publisher's year, e.g. DU/2024
+	WatchYear = "year"
+)
+
+// ErrWatchNotFound is returned when deleting a watch that does not exist
+var ErrWatchNotFound = errors.New("watch not found")
+
+// Watch subscribes a webhook URL to changes of an act, a keyword or a year
+type Watch struct {
+	ID        int64     `json:"id"`
+	Kind      string    `json:"kind"`
+	Target    string    `json:"target"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// DeadLetter is a webhook delivery that failed after all retries
+type DeadLetter struct {
+	WatchID  int64
+	URL      string
+	Payload  string
+	Error    string
+	Attempts int
+}
+
+// AddWatch stores a new watch and returns it with its ID
+func (db *DB) AddWatch(ctx context.Context, watch Watch) (_ *Watch, err error) {
+	ctx, end := startQuery(ctx, "add_watch")
+	defer end(&err)
+
+	result, err := db.ExecContext(ctx,
+		"INSERT INTO watches (kind, target, url, secret) VALUES (?, ?, ?, ?)",
+		watch.Kind, watch.Target, watch.URL, watch.Secret,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return db.getWatch(ctx, id)
+}
+
+// getWatch retrieves a single watch by ID
+func (db *DB) getWatch(ctx context.Context, id int64) (*Watch, error) {
+	var watch Watch
+	var createdAt string
+	err := db.QueryRowContext(ctx,
+		"SELECT id, kind, target, url, secret, created_at FROM watches WHERE id = ?", id,
+	).Scan(&watch.ID, &watch.Kind, &watch.Target, &watch.URL, &watch.Secret, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrWatchNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if watch.CreatedAt, err = time.Parse(timestampLayout, createdAt); err != nil {
+		return nil, err
+	}
+	return &watch, nil
+}
+
+// DeleteWatch removes a watch
+func (db *DB) DeleteWatch(ctx context.Context, id int64) (err error) {
+	ctx, end := startQuery(ctx, "delete_watch")
+	defer end(&err)
+
+	result, err := db.ExecContext(ctx, "DELETE FROM watches WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrWatchNotFound
+	}
+	return nil
+}
+
+// ListWatches returns all watches, oldest first
+func (db *DB) ListWatches(ctx context.Context) (_ []Watch, err error) {
+	ctx, end := startQuery(ctx, "list_watches")
+	defer end(&err)
+
+	rows, err := db.QueryContext(ctx, "SELECT id, kind, target, url, secret, created_at FROM watches ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("Error closing rows", "error", err)
+		}
+	}()
+
+	watches := make([]Watch, 0)
+	for rows.Next() {
+		var watch Watch
+		var createdAt string
+		if err := rows.Scan(&watch.ID, &watch.Kind, &watch.Target, &watch.URL, &watch.Secret, &createdAt); err != nil {
+			return nil, err
+		}
+		if watch.CreatedAt, err = time.Parse(timestampLayout, createdAt); err != nil {
+			return nil, err
+		}
+		watches = append(watches, watch)
+	}
+
+	return watches, rows.Err()
+}
+
+// StoreDeadLetter records a webhook delivery that could not be completed
+func (db *DB) StoreDeadLetter(ctx context.Context, letter DeadLetter) (err error) {
+	ctx, end := startQuery(ctx, "store_dead_letter")
+	defer end(&err)
+
+	_, err = db.ExecContext(ctx,
+		`INSERT INTO webhook_dead_letters (watch_id, url, payload, error, attempts)
+		 VALUES (?, ?, ?, ?, ?)`,
+		letter.WatchID, letter.URL, letter.Payload, letter.Error, letter.Attempts,
+	)
+	return err
+}
@@ -0,0 +1,37 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+
+	"ustawka/db"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddListDeleteWatches(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	watch := db.Watch{Kind: db.WatchAct, Target: "DU/2024/1", URL: "https://example.com/hook", Secret: "s3cret"}
+
+	created, err := database.AddWatch(ctx, watch)
+	require.NoError(t, err)
+	assert.NotZero(t, created.ID)
+	assert.False(t, created.CreatedAt.IsZero())
+	watch.ID, watch.CreatedAt = created.ID, created.CreatedAt
+	assert.Equal(t, &watch, created)
+
+	watches, err := database.ListWatches(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []db.Watch{watch}, watches)
+
+	require.NoError(t, database.DeleteWatch(ctx, created.ID))
+	assert.ErrorIs(t, database.DeleteWatch(ctx, created.ID), db.ErrWatchNotFound)
+
+	watches, err = database.ListWatches(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, watches)
+}
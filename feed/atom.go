@@ -0,0 +1,136 @@
+package feed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"time"
+
+	"ustawka/db"
+)
+
+// ContentType is the media type of Atom feeds
+const ContentType = "application/atom+xml; charset=utf-8"
+
+// dateLayouts are the formats of promulgation and change dates in the ELI API
+var dateLayouts = []string{"2006-01-02T15:04:05", time.DateOnly}
+
+// Feed is an Atom feed document
+type Feed struct {
+	XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string   `xml:"id"`
+	Title   string   `xml:"title"`
+	Updated string   `xml:"updated"`
+	Links   []Link   `xml:"link"`
+	Author  Person   `xml:"author"`
+	Entries []Entry  `xml:"entry"`
+}
+
+// Link is an Atom link
+type Link struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+// Person is an Atom author
+type Person struct {
+	Name string `xml:"name"`
+}
+
+// Entry is an Atom entry describing a single act
+type Entry struct {
+	ID        string   `xml:"id"`
+	Title     string   `xml:"title"`
+	Updated   string   `xml:"updated"`
+	Published string   `xml:"published,omitempty"`
+	Link      Link     `xml:"link"`
+	Summary   string   `xml:"summary"`
+	Category  Category `xml:"category"`
+}
+
+// Category is an Atom category, used for the act status
+type Category struct {
+	Term string `xml:"term,attr"`
+}
+
+// New builds a feed from cached acts; baseURL is the absolute URL of the application
+// and selfPath the path of the feed itself
+func New(title, baseURL, selfPath string, acts []db.FeedEntry) *Feed {
+	f := &Feed{
+		ID:    baseURL + selfPath,
+		Title: title,
+		Links: []Link{
+			{Href: baseURL + selfPath, Rel: "self", Type: "application/atom+xml"},
+			{Href: baseURL + "/"},
+		},
+		Author:  Person{Name: "Ustawka"},
+		Entries: make([]Entry, 0, len(acts)),
+	}
+
+	// An act's change date falls back to its publication date
+	dates := make([]time.Time, len(acts))
+	// An empty feed has never been updated; the epoch keeps the document valid
+	updated := time.Unix(0, 0)
+	for i, act := range acts {
+		dates[i] = parseDate(act.Date)
+		if dates[i].IsZero() {
+			dates[i] = parseDate(act.Published)
+		}
+		if dates[i].After(updated) {
+			updated = dates[i]
+		}
+	}
+
+	for i, act := range acts {
+		// Acts without any known date take the feed's time, as Atom requires one
+		entryUpdated := dates[i]
+		if entryUpdated.IsZero() {
+			entryUpdated = updated
+		}
+
+		entry := Entry{
+			ID:      fmt.Sprintf("tag:ustawka,2021:%s", act.ID),
+			Title:   act.Title,
+			Updated: entryUpdated.UTC().Format(time.RFC3339),
+			Link: Link{
+				Href: fmt.Sprintf("%s/acts/%s/%d/%d", baseURL, act.Publisher, act.Year, act.Position),
+			},
+			Summary:  fmt.Sprintf("%s %s, %s", act.Type, act.ID, act.Status),
+			Category: Category{Term: act.Status},
+		}
+		if published := parseDate(act.Published); !published.IsZero() {
+			entry.Published = published.Format(time.RFC3339)
+		}
+		f.Entries = append(f.Entries, entry)
+	}
+
+	f.Updated = updated.UTC().Format(time.RFC3339)
+	return f
+}
+
+// parseDate parses an ELI date, returning the zero time for empty or unknown formats
+func parseDate(value string) time.Time {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Marshal encodes the feed as an XML document
+func (f *Feed) Marshal() ([]byte, error) {
+	data, err := xml.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// ETag returns a strong entity tag for an encoded feed
+func ETag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
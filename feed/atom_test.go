@@ -0,0 +1,80 @@
+package feed_test
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"ustawka/db"
+	"ustawka/feed"
+	"ustawka/sejm"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	entries := []db.FeedEntry{
+		{
+			Act: sejm.Act{ID: "DU/2024/1", Publisher: "DU", Title: "Ustawa A", Status: "obowiązujący",
+				Published: "2024-01-10", Position: 1, Year: 2024, Type: "Ustawa"},
+			ChangeDate: "2024-06-01T10:00:00",
+			Date:       "2024-06-01T10:00:00",
+		},
+		{
+			Act: sejm.Act{ID: "DU/2024/2", Publisher: "DU", Title: "Ustawa B", Status: "uchylony",
+				Published: "2024-02-10", Position: 2, Year: 2024, Type: "Ustawa"},
+			Date: "2024-02-10",
+		},
+	}
+
+	data, err := feed.New("Dziennik Ustaw 2024", "http://localhost:8080", "/feeds/DU/2024.atom", entries).Marshal()
+	require.NoError(t, err)
+
+	var parsed feed.Feed
+	require.NoError(t, xml.Unmarshal(data, &parsed))
+	assert.Equal(t, "Dziennik Ustaw 2024", parsed.Title)
+	assert.Equal(t, "2024-06-01T10:00:00Z", parsed.Updated)
+	require.Len(t, parsed.Entries, 2)
+	assert.Equal(t, "http://localhost:8080/acts/DU/2024/1", parsed.Entries[0].Link.Href)
+	assert.Equal(t, "2024-06-01T10:00:00Z", parsed.Entries[0].Updated)
+	assert.Equal(t, "2024-01-10T00:00:00Z", parsed.Entries[0].Published)
+	assert.Equal(t, "uchylony", parsed.Entries[1].Category.Term)
+
+	// Identical feeds share an ETag, any change produces a new one
+	again, err := feed.New("Dziennik Ustaw 2024", "http://localhost:8080", "/feeds/DU/2024.atom", entries).Marshal()
+	require.NoError(t, err)
+	assert.Equal(t, feed.ETag(data), feed.ETag(again))
+	changed, err := feed.New("Dziennik Ustaw 2024", "http://localhost:8080", "/feeds/DU/2024.atom", entries[:1]).Marshal()
+	require.NoError(t, err)
+	assert.NotEqual(t, feed.ETag(data), feed.ETag(changed))
+}
+
+func TestNewUnparseableDate(t *testing.T) {
+	entries := []db.FeedEntry{
+		{
+			Act:  sejm.Act{ID: "DU/2024/1", Publisher: "DU", Published: "2024-01-10", Position: 1, Year: 2024},
+			Date: "2024-06-01T10:00:00",
+		},
+		{
+			Act:  sejm.Act{ID: "DU/2024/2", Publisher: "DU", Published: "2024-02-10", Position: 2, Year: 2024},
+			Date: "1 czerwca 2024",
+		},
+		{
+			Act:  sejm.Act{ID: "DU/2024/3", Publisher: "DU", Position: 3, Year: 2024},
+			Date: "1 czerwca 2024",
+		},
+	}
+
+	f := feed.New("Dziennik Ustaw 2024", "http://localhost:8080", "/feeds/DU/2024.atom", entries)
+	require.Len(t, f.Entries, 3)
+	// An unparseable date falls back to the publication date, then to the feed's time
+	assert.Equal(t, "2024-02-10T00:00:00Z", f.Entries[1].Updated)
+	assert.Equal(t, "2024-06-01T10:00:00Z", f.Entries[2].Updated)
+	assert.Equal(t, "2024-06-01T10:00:00Z", f.Updated)
+}
+
+func TestNewEmpty(t *testing.T) {
+	f := feed.New("Status: uchylony", "http://localhost:8080", "/feeds/status/uchylony.atom", nil)
+	assert.Equal(t, "1970-01-01T00:00:00Z", f.Updated)
+	assert.Empty(t, f.Entries)
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"ustawka/service"
+)
+
+// maxAdminBodySize limits the size of an admin request body
+const maxAdminBodySize = 4 << 10
+
+// AdminOnly guards admin routes with a bearer token; without a token configured
+// the admin API is disabled
+func AdminOnly(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token == "" {
+				http.Error(w, "Admin API disabled", http.StatusForbidden)
+				return
+			}
+
+			given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// HandleInvalidateCache marks cached data of an act, a year or everything to be fetched
+// again from the Sejm API, e.g. {"act": "DU/2024/1"}, {"year": "DU/2024"} or {"all": true}
+func (h *Handler) HandleInvalidateCache(w http.ResponseWriter, r *http.Request) {
+	var scope service.CacheScope
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAdminBodySize)).Decode(&scope); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	count, err := h.actService.InvalidateCache(r.Context(), scope)
+	if errors.Is(err, service.ErrInvalidScope) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		slog.Error("Error invalidating cache", "error", err)
+		http.Error(w, "Failed to invalidate cache", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]int64{"invalidated": count}); err != nil {
+		slog.Error("Error encoding response", "error", err)
+	}
+}
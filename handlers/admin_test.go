@@ -0,0 +1,162 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"ustawka/db"
+	"ustawka/handlers"
+	"ustawka/sejm"
+	"ustawka/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const adminToken = "s3cret"
+
+// newAdminRouter serves the cache invalidation endpoint guarded by a token, with
+// two cached acts and their year in the database
+func newAdminRouter(t *testing.T, token string) http.Handler {
+	t.Helper()
+	database, err := db.New(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if err := database.Close(); err != nil {
+			t.Errorf("Error closing database: %v", err)
+		}
+	})
+
+	ctx := context.Background()
+	for _, entry := range []db.CacheEntry{
+		{Kind: db.CacheKindActs, Key: db.YearKey(sejm.PublisherDU, 2024), Outcome: db.OutcomeOK},
+		{Kind: db.CacheKindDetails, Key: "DU/2024/1", Outcome: db.OutcomeOK},
+		{Kind: db.CacheKindDetails, Key: "DU/2024/2", Outcome: db.OutcomeOK},
+	} {
+		require.NoError(t, database.StoreCacheEntry(ctx, entry))
+	}
+
+	actService := service.NewActServiceWithConfig(sejm.NewClientWithURL("http://127.0.0.1:0"), database, time.Second, time.Hour)
+	handler := handlers.NewHandler(nil, actService)
+
+	r := chi.NewRouter()
+	r.With(handlers.AdminOnly(token)).Post("/api/admin/cache/invalidate", handler.HandleInvalidateCache)
+	return r
+}
+
+func TestHandleInvalidateCache(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		body          string
+		wantStatus    int
+		wantBody      string
+	}{
+		{
+			name:          "no token configured",
+			authorization: "Bearer ",
+			body:          `{"all": true}`,
+			wantStatus:    http.StatusForbidden,
+		},
+		{
+			name:       "missing token",
+			token:      adminToken,
+			body:       `{"all": true}`,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:          "wrong token",
+			token:         adminToken,
+			authorization: "Bearer wrong",
+			body:          `{"all": true}`,
+			wantStatus:    http.StatusUnauthorized,
+		},
+		{
+			name:          "token without bearer scheme",
+			token:         adminToken,
+			authorization: adminToken,
+			body:          `{"all": true}`,
+			wantStatus:    http.StatusUnauthorized,
+		},
+		{
+			name:          "act",
+			token:         adminToken,
+			authorization: "Bearer " + adminToken,
+			body:          `{"act": "DU/2024/1"}`,
+			wantStatus:    http.StatusOK,
+			wantBody:      `{"invalidated":1}`,
+		},
+		{
+			name:          "year",
+			token:         adminToken,
+			authorization: "Bearer " + adminToken,
+			body:          `{"year": "du/2024"}`,
+			wantStatus:    http.StatusOK,
+			wantBody:      `{"invalidated":3}`,
+		},
+		{
+			name:          "all",
+			token:         adminToken,
+			authorization: "Bearer " + adminToken,
+			body:          `{"all": true}`,
+			wantStatus:    http.StatusOK,
+			wantBody:      `{"invalidated":3}`,
+		},
+		{
+			name:          "malformed body",
+			token:         adminToken,
+			authorization: "Bearer " + adminToken,
+			body:          `{"act": `,
+			wantStatus:    http.StatusBadRequest,
+		},
+		{
+			name:          "empty scope",
+			token:         adminToken,
+			authorization: "Bearer " + adminToken,
+			body:          `{}`,
+			wantStatus:    http.StatusBadRequest,
+		},
+		{
+			name:          "several scopes",
+			token:         adminToken,
+			authorization: "Bearer " + adminToken,
+			body:          `{"act": "DU/2024/1", "all": true}`,
+			wantStatus:    http.StatusBadRequest,
+		},
+		{
+			name:          "malformed year",
+			token:         adminToken,
+			authorization: "Bearer " + adminToken,
+			body:          `{"year": "DU/24"}`,
+			wantStatus:    http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newAdminRouter(t, tt.token)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/admin/cache/invalidate", strings.NewReader(tt.body))
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus == http.StatusUnauthorized {
+				assert.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+			}
+			if tt.wantBody != "" {
+				assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+				assert.JSONEq(t, tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
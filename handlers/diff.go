@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"ustawka/sejm"
+	"ustawka/service"
+)
+
+// actDiffView is the text comparison page data
+type actDiffView struct {
+	Details *sejm.ActDetails
+	Diff    *service.TextDiff
+}
+
+// HandleActDiff returns the per-article word diff between two text versions of an act as JSON
+func (h *Handler) HandleActDiff(w http.ResponseWriter, r *http.Request) {
+	diff, ok := h.actDiff(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(diff); err != nil {
+		slog.Error("Error encoding response", "error", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// ViewActDiff serves the page comparing two text versions of an act
+func (h *Handler) ViewActDiff(w http.ResponseWriter, r *http.Request) {
+	diff, ok := h.actDiff(w, r)
+	if !ok {
+		return
+	}
+
+	id, _ := actIDParam(r)
+	details, err := h.actService.GetActDetails(r.Context(), id)
+	if errors.Is(err, sejm.ErrActNotFound) {
+		http.Error(w, "Act not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, service.ErrOffline) {
+		http.Error(w, "Act is not available offline", http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		slog.Error("Error fetching act details", "error", err)
+		http.Error(w, "Failed to fetch act details", http.StatusInternalServerError)
+		return
+	}
+
+	err = h.render(r, w, "act_diff.html", actDiffView{Details: details, Diff: diff})
+	if err != nil {
+		slog.Error("Error executing template", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// actDiff compares the text versions selected by the from and to query parameters,
+// writing an error response on failure
+func (h *Handler) actDiff(w http.ResponseWriter, r *http.Request) (*service.TextDiff, bool) {
+	id, err := actIDParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	from, err := versionParam(r, "from")
+	if err != nil {
+		http.Error(w, "Invalid from parameter", http.StatusBadRequest)
+		return nil, false
+	}
+	to, err := versionParam(r, "to")
+	if err != nil {
+		http.Error(w, "Invalid to parameter", http.StatusBadRequest)
+		return nil, false
+	}
+
+	diff, err := h.actService.GetActTextDiff(r.Context(), id, from, to)
+	if err != nil {
+		writeHTMLTextError(w, err)
+		return nil, false
+	}
+
+	return diff, true
+}
+
+// versionParam parses an optional text version ID, returning zero when it is absent
+func versionParam(r *http.Request, name string) (int64, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err == nil && id < 0 {
+		return 0, strconv.ErrRange
+	}
+	return id, err
+}
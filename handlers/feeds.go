@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"ustawka/db"
+	"ustawka/feed"
+	"ustawka/sejm"
+	"ustawka/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// HandleYearFeed serves an Atom feed of a publisher's acts for a year
+func (h *Handler) HandleYearFeed(w http.ResponseWriter, r *http.Request) {
+	publisher, ok := publisherParam(r)
+	if !ok {
+		http.Error(w, "Invalid publisher parameter", http.StatusBadRequest)
+		return
+	}
+
+	year, err := strconv.Atoi(chi.URLParam(r, "year"))
+	if err != nil {
+		http.Error(w, "Invalid year parameter", http.StatusBadRequest)
+		return
+	}
+
+	title := fmt.Sprintf("%s %d", publisherNames[publisher], year)
+	h.serveFeed(w, r, title, db.FeedFilter{Publisher: publisher, Year: year})
+}
+
+// HandleKeywordFeed serves an Atom feed of acts tagged with a keyword
+func (h *Handler) HandleKeywordFeed(w http.ResponseWriter, r *http.Request) {
+	keyword := chi.URLParam(r, "keyword")
+	h.serveFeed(w, r, "Słowo kluczowe: "+keyword, db.FeedFilter{Keyword: keyword})
+}
+
+// HandleStatusFeed serves an Atom feed of acts with a status
+func (h *Handler) HandleStatusFeed(w http.ResponseWriter, r *http.Request) {
+	status := chi.URLParam(r, "status")
+	h.serveFeed(w, r, "Status: "+status, db.FeedFilter{Status: status})
+}
+
+// publisherNames are feed titles of publishers
+var publisherNames = map[string]string{
+	sejm.PublisherDU: "Dziennik Ustaw",
+	sejm.PublisherMP: "Monitor Polski",
+}
+
+// serveFeed renders an Atom feed, answering 304 when the client already has it
+func (h *Handler) serveFeed(w http.ResponseWriter, r *http.Request, title string, filter db.FeedFilter) {
+	entries, err := h.actService.GetFeedEntries(r.Context(), filter)
+	if errors.Is(err, service.ErrUnknownPublisher) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		slog.Error("Error fetching feed entries", "error", err)
+		http.Error(w, "Failed to fetch feed", http.StatusInternalServerError)
+		return
+	}
+
+	data, err := feed.New(title, baseURL(r), r.URL.Path, entries).Marshal()
+	if err != nil {
+		slog.Error("Error encoding feed", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	etag := feed.ETag(data)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", feed.ContentType)
+	if _, err := w.Write(data); err != nil {
+		slog.Error("Error writing response", "error", err)
+	}
+}
+
+// etagMatches reports whether an If-None-Match header lists the entity tag
+func etagMatches(header, etag string) bool {
+	for candidate := range strings.SplitSeq(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// baseURL returns the absolute URL of the application as seen by the client
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
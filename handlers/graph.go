@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"ustawka/sejm"
+	"ustawka/service"
+)
+
+// HandleActGraph returns the reference graph around an act as nodes and edges
+func (h *Handler) HandleActGraph(w http.ResponseWriter, r *http.Request) {
+	id, err := actIDParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	depth := service.DefaultGraphDepth
+	if value := r.URL.Query().Get("depth"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > service.MaxGraphDepth {
+			http.Error(w, "Invalid depth parameter", http.StatusBadRequest)
+			return
+		}
+		depth = parsed
+	}
+
+	graph, err := h.actService.GetActGraph(r.Context(), id, depth)
+	if errors.Is(err, sejm.ErrActNotFound) {
+		http.Error(w, "Act not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, service.ErrOffline) {
+		http.Error(w, "Act is not available offline", http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		slog.Error("Error building act graph", "error", err)
+		http.Error(w, "Failed to build act graph", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(graph); err != nil {
+		slog.Error("Error encoding response", "error", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// ViewActGraph serves the interactive reference graph page of an act
+func (h *Handler) ViewActGraph(w http.ResponseWriter, r *http.Request) {
+	id, err := actIDParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	details, err := h.actService.GetActDetails(r.Context(), id)
+	if errors.Is(err, sejm.ErrActNotFound) {
+		http.Error(w, "Act not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, service.ErrOffline) {
+		http.Error(w, "Act is not available offline", http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		slog.Error("Error fetching act details", "error", err)
+		http.Error(w, "Failed to fetch act details", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.render(r, w, "act_graph.html", details); err != nil {
+		slog.Error("Error executing template", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// HandleActIncoming returns cached acts citing or amending an act
+func (h *Handler) HandleActIncoming(w http.ResponseWriter, r *http.Request) {
+	id, err := actIDParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	incoming, err := h.actService.GetIncomingReferences(r.Context(), id)
+	if err != nil {
+		slog.Error("Error fetching incoming references", "error", err)
+		http.Error(w, "Failed to fetch incoming references", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(incoming); err != nil {
+		slog.Error("Error encoding response", "error", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,47 @@
+package handlers_test
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+	"ustawka/db"
+	"ustawka/handlers"
+	"ustawka/sejm"
+	"ustawka/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMissingActNotFound(t *testing.T) {
+	upstream := httptest.NewServer(http.NotFoundHandler())
+	defer upstream.Close()
+
+	database, err := db.New(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer database.Close()
+
+	actService := service.NewActServiceWithConfig(sejm.NewClientWithURL(upstream.URL), database, time.Second, time.Hour)
+	templates := template.Must(template.ParseFiles("../templates/base.html", "../templates/act_graph.html"))
+	handler := handlers.NewHandler(templates, actService)
+
+	r := chi.NewRouter()
+	r.Get("/api/acts/{publisher}/{year}/{position}/graph", handler.HandleActGraph)
+	r.Get("/acts/{publisher}/{year}/{position}/graph", handler.ViewActGraph)
+
+	for _, path := range []string{
+		"/api/acts/DU/2024/1/graph",
+		"/acts/DU/2024/1/graph",
+	} {
+		t.Run(path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+			assert.Contains(t, rec.Body.String(), "Act not found")
+		})
+	}
+}
@@ -2,15 +2,26 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"html/template"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
+	"ustawka/db"
+	"ustawka/sejm"
 	"ustawka/service"
+	"ustawka/tracing"
 
 	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel"
 )
 
+// tracer starts the spans of template rendering
+var tracer = otel.Tracer("ustawka/handlers")
+
 // Handler handles HTTP requests for the application
 type Handler struct {
 	templates  *template.Template
@@ -25,9 +36,18 @@ func NewHandler(templates *template.Template, actService *service.ActService) *H
 	}
 }
 
+// render executes a template in a span of its own, telling rendering time apart
+// from loading the data
+func (h *Handler) render(r *http.Request, w http.ResponseWriter, name string, data any) (err error) {
+	_, span := tracer.Start(r.Context(), "template "+name)
+	defer tracing.End(span, &err)
+
+	return h.templates.ExecuteTemplate(w, name, data)
+}
+
 // Home serves the main application page
-func (h *Handler) Home(w http.ResponseWriter, _ *http.Request) {
-	err := h.templates.ExecuteTemplate(w, "base.html", nil)
+func (h *Handler) Home(w http.ResponseWriter, r *http.Request) {
+	err := h.render(r, w, "base.html", nil)
 	if err != nil {
 		slog.Error("Error executing template", "error", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
@@ -35,9 +55,51 @@ func (h *Handler) Home(w http.ResponseWriter, _ *http.Request) {
 	}
 }
 
+// publisherParam returns the publisher from the route or the query string,
+// defaulting to Dziennik Ustaw
+func publisherParam(r *http.Request) (string, bool) {
+	publisher := chi.URLParam(r, "publisher")
+	if publisher == "" {
+		publisher = r.URL.Query().Get("publisher")
+	}
+	if publisher == "" {
+		return sejm.PublisherDU, true
+	}
+
+	publisher = strings.ToUpper(publisher)
+	return publisher, sejm.IsValidPublisher(publisher)
+}
+
+// actIDParam returns the act identified by the publisher, year and position route parameters
+func actIDParam(r *http.Request) (sejm.ELI, error) {
+	return sejm.NewELI(chi.URLParam(r, "publisher"), chi.URLParam(r, "year"), chi.URLParam(r, "position"))
+}
+
+// RedirectActAddress redirects an act given by address, e.g. /acts/WDU20200001234,
+// to its canonical route
+func (h *Handler) RedirectActAddress(w http.ResponseWriter, r *http.Request) {
+	id, err := sejm.ParseELI(chi.URLParam(r, "address"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	target := "/acts/" + id.String()
+	if strings.HasPrefix(r.URL.Path, "/api/") {
+		target = "/api" + target
+	}
+	http.Redirect(w, r, target, http.StatusMovedPermanently)
+}
+
 // HandleYears returns available years with legislative acts
 func (h *Handler) HandleYears(w http.ResponseWriter, r *http.Request) {
-	years, err := h.actService.GetAvailableYears(r.Context())
+	publisher, ok := publisherParam(r)
+	if !ok {
+		http.Error(w, "Invalid publisher parameter", http.StatusBadRequest)
+		return
+	}
+
+	years, err := h.actService.GetAvailableYears(r.Context(), publisher)
 	if err != nil {
 		slog.Error("Error getting available years", "error", err)
 		http.Error(w, "Failed to get available years", http.StatusInternalServerError)
@@ -54,6 +116,12 @@ func (h *Handler) HandleYears(w http.ResponseWriter, r *http.Request) {
 
 // HandleActs returns acts for a specific year, organized by status
 func (h *Handler) HandleActs(w http.ResponseWriter, r *http.Request) {
+	publisher, ok := publisherParam(r)
+	if !ok {
+		http.Error(w, "Invalid publisher parameter", http.StatusBadRequest)
+		return
+	}
+
 	yearStr := chi.URLParam(r, "year")
 	if yearStr == "" {
 		http.Error(w, "Year parameter is required", http.StatusBadRequest)
@@ -66,16 +134,30 @@ func (h *Handler) HandleActs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	data, err := h.actService.GetActsByYear(r.Context(), yearInt)
+	data, err := h.actService.GetActsByYear(r.Context(), publisher, yearInt)
 	if err != nil {
 		slog.Error("Error fetching acts", "error", err)
-		http.Error(w, err.Error(), http.StatusNotFound)
+		status := http.StatusNotFound
+		if errors.Is(err, service.ErrOffline) {
+			status = http.StatusServiceUnavailable
+		}
+		http.Error(w, err.Error(), status)
 		return
 	}
 
+	// Expired acts are served while they are refreshed in the background
+	if data.Stale {
+		w.Header().Set("X-Ustawka-Cache", "stale")
+		w.Header().Set("Age", strconv.Itoa(int(time.Since(data.UpdatedAt).Seconds())))
+	}
+	// Offline acts come from the cache without being checked against the API
+	if data.Offline {
+		w.Header().Set("X-Ustawka-Cache", "offline")
+	}
+
 	// If the request is from HTMX, render the board template
 	if r.Header.Get("HX-Request") == "true" {
-		err := h.templates.ExecuteTemplate(w, "board", data)
+		err := h.render(r, w, "board", data)
 		if err != nil {
 			slog.Error("Error executing template", "error", err)
 			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
@@ -93,16 +175,47 @@ func (h *Handler) HandleActs(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// actDetailsView is the act details page data, with the act's change history and
+// the cached acts referring to it
+type actDetailsView struct {
+	*sejm.ActDetails
+	History  []db.ActChange
+	Incoming *service.IncomingReferences
+}
+
+// newActDetailsView wraps act details for rendering, skipping the history and incoming
+// references if they cannot be read
+func (h *Handler) newActDetailsView(r *http.Request, id sejm.ELI, details *sejm.ActDetails) *actDetailsView {
+	history, err := h.actService.GetActHistory(r.Context(), id)
+	if err != nil {
+		slog.Error("Error fetching act history", "error", err)
+	}
+
+	incoming, err := h.actService.GetIncomingReferences(r.Context(), id)
+	if err != nil {
+		slog.Error("Error fetching incoming references", "error", err)
+	}
+
+	return &actDetailsView{ActDetails: details, History: history, Incoming: incoming}
+}
+
 // HandleActDetails returns detailed information about a specific act
 func (h *Handler) HandleActDetails(w http.ResponseWriter, r *http.Request) {
-	year := chi.URLParam(r, "year")
-	position := chi.URLParam(r, "position")
-	if year == "" || position == "" {
-		http.Error(w, "Year and position parameters are required", http.StatusBadRequest)
+	id, err := actIDParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	details, err := h.actService.GetActDetails(r.Context(), year, position)
+	details, err := h.actService.GetActDetails(r.Context(), id)
+	if errors.Is(err, sejm.ErrActNotFound) {
+		http.Error(w, "Act not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, service.ErrOffline) {
+		http.Error(w, "Act is not available offline", http.StatusServiceUnavailable)
+		return
+	}
 	if err != nil {
 		slog.Error("Error fetching act details", "error", err)
 		http.Error(w, "Failed to fetch act details", http.StatusInternalServerError)
@@ -111,7 +224,8 @@ func (h *Handler) HandleActDetails(w http.ResponseWriter, r *http.Request) {
 
 	// If the request is from HTMX, render the act details template
 	if r.Header.Get("HX-Request") == "true" {
-		err := h.templates.ExecuteTemplate(w, "act_details", details)
+		view := h.newActDetailsView(r, id, details)
+		err := h.render(r, w, "act_details", view)
 		if err != nil {
 			slog.Error("Error executing template", "error", err)
 			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
@@ -131,24 +245,191 @@ func (h *Handler) HandleActDetails(w http.ResponseWriter, r *http.Request) {
 
 // ViewActDetails serves the act details page
 func (h *Handler) ViewActDetails(w http.ResponseWriter, r *http.Request) {
-	year := chi.URLParam(r, "year")
-	position := chi.URLParam(r, "position")
-	if year == "" || position == "" {
-		http.Error(w, "Year and position parameters are required", http.StatusBadRequest)
+	id, err := actIDParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	details, err := h.actService.GetActDetails(r.Context(), year, position)
+	details, err := h.actService.GetActDetails(r.Context(), id)
+	if errors.Is(err, sejm.ErrActNotFound) {
+		http.Error(w, "Act not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, service.ErrOffline) {
+		http.Error(w, "Act is not available offline", http.StatusServiceUnavailable)
+		return
+	}
 	if err != nil {
 		slog.Error("Error fetching act details", "error", err)
 		http.Error(w, "Failed to fetch act details", http.StatusInternalServerError)
 		return
 	}
 
-	err = h.templates.ExecuteTemplate(w, "base.html", details)
+	view := h.newActDetailsView(r, id, details)
+	err = h.render(r, w, "base.html", view)
 	if err != nil {
 		slog.Error("Error executing template", "error", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
 }
+
+// HandleActHistory returns changes recorded for a specific act between syncs
+func (h *Handler) HandleActHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := actIDParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	history, err := h.actService.GetActHistory(r.Context(), id)
+	if err != nil {
+		slog.Error("Error fetching act history", "error", err)
+		http.Error(w, "Failed to fetch act history", http.StatusInternalServerError)
+		return
+	}
+
+	// If the request is from HTMX, render the history template
+	if r.Header.Get("HX-Request") == "true" {
+		err := h.render(r, w, "act_history", history)
+		if err != nil {
+			slog.Error("Error executing template", "error", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+
+	// Otherwise return JSON
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(history); err != nil {
+		slog.Error("Error encoding response", "error", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// HandleSearch runs a full-text search over cached acts
+func (h *Handler) HandleSearch(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		if limit, err = strconv.Atoi(limitStr); err != nil {
+			http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	results, err := h.actService.SearchCachedActs(r.Context(), r.URL.Query().Get("q"), limit)
+	if errors.Is(err, service.ErrEmptyQuery) {
+		// A cleared search box simply clears the HTMX result list
+		if r.Header.Get("HX-Request") == "true" {
+			return
+		}
+		http.Error(w, "Query parameter q is required", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		slog.Error("Error searching acts", "error", err)
+		http.Error(w, "Failed to search acts", http.StatusInternalServerError)
+		return
+	}
+
+	// If the request is from HTMX, render the search results template
+	if r.Header.Get("HX-Request") == "true" {
+		err := h.render(r, w, "search_results", results)
+		if err != nil {
+			slog.Error("Error executing template", "error", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+
+	// Otherwise return JSON
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(results); err != nil {
+		slog.Error("Error encoding response", "error", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// searchQueryParam builds an ELI search query from the request's query string
+func searchQueryParam(r *http.Request) (sejm.SearchQuery, error) {
+	params := r.URL.Query()
+	query := sejm.SearchQuery{
+		Publisher: params.Get("publisher"),
+		Title:     params.Get("title"),
+		Keyword:   params.Get("keyword"),
+		Type:      params.Get("type"),
+		Status:    params.Get("status"),
+		DateFrom:  params.Get("dateFrom"),
+		DateTo:    params.Get("dateTo"),
+	}
+
+	ints := map[string]*int{"year": &query.Year, "offset": &query.Offset, "limit": &query.Limit}
+	for name, target := range ints {
+		if value := params.Get(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return query, fmt.Errorf("invalid %s parameter", name)
+			}
+			*target = n
+		}
+	}
+
+	if value := params.Get("inForce"); value != "" {
+		inForce, err := strconv.ParseBool(value)
+		if err != nil {
+			return query, errors.New("invalid inForce parameter")
+		}
+		query.InForce = &inForce
+	}
+
+	return query, nil
+}
+
+// HandleActSearch searches acts through the ELI API with structured filters
+func (h *Handler) HandleActSearch(w http.ResponseWriter, r *http.Request) {
+	query, err := searchQueryParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.actService.SearchActs(r.Context(), query)
+	if errors.Is(err, sejm.ErrInvalidQuery) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, service.ErrOffline) {
+		http.Error(w, "Search is not available offline", http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		slog.Error("Error searching acts", "error", err)
+		http.Error(w, "Failed to search acts", http.StatusBadGateway)
+		return
+	}
+
+	// If the request is from HTMX, render the matches on the board
+	if r.Header.Get("HX-Request") == "true" {
+		err := h.render(r, w, "board", service.OrganizeActsByStatus(result.Items))
+		if err != nil {
+			slog.Error("Error executing template", "error", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+
+	// Otherwise return JSON
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		slog.Error("Error encoding response", "error", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
@@ -6,11 +6,18 @@ import (
 	"ustawka/metrics"
 )
 
-// MetricsHandler handles metrics endpoint
-func MetricsHandler(w http.ResponseWriter, _ *http.Request) {
+// MetricsHandler serves metrics in the Prometheus text format, or the plain
+// counters as JSON with ?format=json
+func MetricsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	if r.URL.Query().Get("format") != "json" {
+		metrics.Handler().ServeHTTP(w, r)
+		return
+	}
+
 	// Set response headers
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Cache-Control", "no-store")
 
 	// Get metrics
 	m := metrics.GetMetrics()
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"path"
+	"ustawka/acttext"
+	"ustawka/sejm"
+	"ustawka/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// HandleActText serves a cached text of an act: text.pdf, text.html or a file listed in its details
+func (h *Handler) HandleActText(w http.ResponseWriter, r *http.Request) {
+	id, err := actIDParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	name := path.Base(r.URL.Path)
+	if fileName := chi.URLParam(r, "fileName"); fileName != "" {
+		name = sejm.TextFileName(sejm.Text{Type: chi.URLParam(r, "type"), FileName: fileName})
+	}
+
+	text, err := h.actService.GetActText(r.Context(), id, name)
+	switch {
+	case errors.Is(err, sejm.ErrInvalidTextName):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, sejm.ErrTextNotFound):
+		http.Error(w, "Act text not found", http.StatusNotFound)
+		return
+	case errors.Is(err, service.ErrTextsUnavailable), errors.Is(err, service.ErrOffline):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	case err != nil:
+		slog.Error("Error fetching act text", "error", err)
+		http.Error(w, "Failed to fetch act text", http.StatusBadGateway)
+		return
+	}
+	defer func() {
+		if err := text.Content.Close(); err != nil {
+			slog.Error("Error closing act text", "error", err)
+		}
+	}()
+
+	// Blobs are content addressed, so the hash is a strong validator
+	w.Header().Set("ETag", `"`+text.Hash+`"`)
+	if text.ContentType != "" {
+		w.Header().Set("Content-Type", text.ContentType)
+	}
+	http.ServeContent(w, r, path.Base(name), text.FetchedAt, text.Content)
+}
+
+// actTextView is the act reader page data
+type actTextView struct {
+	Details  *sejm.ActDetails
+	Document *acttext.Document
+}
+
+// HandleActDocument returns the structured text of an act as JSON
+func (h *Handler) HandleActDocument(w http.ResponseWriter, r *http.Request) {
+	doc, ok := h.actDocument(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(doc); err != nil {
+		slog.Error("Error encoding response", "error", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// ViewActText serves the act reader page with a table of contents and provision anchors
+func (h *Handler) ViewActText(w http.ResponseWriter, r *http.Request) {
+	doc, ok := h.actDocument(w, r)
+	if !ok {
+		return
+	}
+
+	id, _ := actIDParam(r)
+	details, err := h.actService.GetActDetails(r.Context(), id)
+	if err != nil {
+		slog.Error("Error fetching act details", "error", err)
+		http.Error(w, "Failed to fetch act details", http.StatusInternalServerError)
+		return
+	}
+
+	err = h.render(r, w, "act_text.html", actTextView{Details: details, Document: doc})
+	if err != nil {
+		slog.Error("Error executing template", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// actDocument loads the structured text of the act in the route, writing an error response on failure
+func (h *Handler) actDocument(w http.ResponseWriter, r *http.Request) (*acttext.Document, bool) {
+	id, err := actIDParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	doc, err := h.actService.GetActDocument(r.Context(), id)
+	if err != nil {
+		writeHTMLTextError(w, err)
+		return nil, false
+	}
+
+	return doc, true
+}
+
+// writeHTMLTextError writes the response for a failure to load the HTML text of an act
+func writeHTMLTextError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, sejm.ErrTextNotFound):
+		http.Error(w, "Act has no HTML text", http.StatusNotFound)
+	case errors.Is(err, service.ErrTextVersionNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrTextsUnavailable), errors.Is(err, service.ErrOffline):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		slog.Error("Error fetching act text", "error", err)
+		http.Error(w, "Failed to fetch act text", http.StatusBadGateway)
+	}
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"ustawka/db"
+	"ustawka/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxWatchBodySize limits the size of a watch request body
+const maxWatchBodySize = 64 << 10
+
+// HandleCreateWatch subscribes a webhook URL to changes of an act, a keyword or a year.
+// The response includes the secret used to sign webhook requests.
+func (h *Handler) HandleCreateWatch(w http.ResponseWriter, r *http.Request) {
+	var watch db.Watch
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxWatchBodySize)).Decode(&watch); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	created, err := h.actService.AddWatch(r.Context(), watch)
+	if errors.Is(err, service.ErrInvalidWatch) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		slog.Error("Error adding watch", "error", err)
+		http.Error(w, "Failed to add watch", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(created); err != nil {
+		slog.Error("Error encoding response", "error", err)
+	}
+}
+
+// HandleListWatches returns all watches without their secrets
+func (h *Handler) HandleListWatches(w http.ResponseWriter, r *http.Request) {
+	watches, err := h.actService.ListWatches(r.Context())
+	if err != nil {
+		slog.Error("Error listing watches", "error", err)
+		http.Error(w, "Failed to list watches", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(watches); err != nil {
+		slog.Error("Error encoding response", "error", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// HandleDeleteWatch removes a watch
+func (h *Handler) HandleDeleteWatch(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid watch id", http.StatusBadRequest)
+		return
+	}
+
+	err = h.actService.DeleteWatch(r.Context(), id)
+	if errors.Is(err, db.ErrWatchNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("Error deleting watch", "error", err)
+		http.Error(w, "Failed to delete watch", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
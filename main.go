@@ -1,12 +1,36 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
 	"ustawka/server"
+	"ustawka/tracing"
 )
 
+// subcommands are run instead of the server when named as the first argument
+var subcommands = map[string]func(ctx context.Context, args []string, out io.Writer) error{
+	"migrate": runMigrate,
+	"export":  runExport,
+	"import":  runImport,
+}
+
 func main() {
+	// Subcommands log to stderr with the default logger, leaving stdout to their output
+	if len(os.Args) > 1 {
+		if run, ok := subcommands[os.Args[1]]; ok {
+			if err := run(context.Background(), os.Args[2:], os.Stdout); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
 	// Configure slog
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
 		Level: slog.LevelInfo,
@@ -22,6 +46,22 @@ func main() {
 		slog.Info("Using custom port", "port", port)
 	}
 
+	// Stop gracefully on interrupt or termination
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Export traces when an OTLP endpoint is configured
+	shutdownTracing, err := tracing.Setup(ctx)
+	if err != nil {
+		slog.Error("Failed to set up tracing", "error", err)
+		panic(err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			slog.Error("Error flushing traces", "error", err)
+		}
+	}()
+
 	// Create and start server
 	srv, err := server.NewServer()
 	if err != nil {
@@ -29,7 +69,7 @@ func main() {
 		panic(err)
 	}
 
-	if err := srv.Start(port); err != nil {
+	if err := srv.Start(ctx, port); err != nil {
 		slog.Error("Server failed to start", "error", err)
 		panic(err)
 	}
@@ -1,7 +1,17 @@
 package metrics
 
 import (
+	"strconv"
 	"sync/atomic"
+	"time"
+)
+
+// Cache kinds labelling cache hits and misses
+const (
+	CacheActs    = "acts"
+	CacheDetails = "details"
+	CacheSearch  = "search"
+	CacheTexts   = "texts"
 )
 
 var (
@@ -16,6 +26,26 @@ var (
 
 	// Cache misses counter
 	cacheMisses uint64
+
+	// Expired cache entries served while being refreshed counter
+	cacheStale uint64
+
+	// Callers that shared an upstream fetch started by another caller counter
+	coalesced uint64
+
+	// Background sync runs counter
+	syncRuns uint64
+
+	// Background sync failed tasks counter
+	syncFailures uint64
+
+	// Last background sync run: start time, duration and failed tasks
+	syncLastRun        uint64
+	syncLastDurationMs uint64
+	syncLastFailures   uint64
+
+	// Sejm API circuit breaker state: 0 closed, 1 open, 2 half-open
+	sejmCircuitState uint64
 )
 
 // IncrementAPI calls counter
@@ -28,14 +58,67 @@ func IncrementSejmAPI() {
 	atomic.AddUint64(&sejmAPICalls, 1)
 }
 
-// IncrementCacheHit increments cache hits counter
-func IncrementCacheHit() {
+// IncrementCacheHit increments cache hits counter of a cache kind
+func IncrementCacheHit(kind string) {
 	atomic.AddUint64(&cacheHits, 1)
+	cacheRequests.WithLabelValues(kind, "hit").Inc()
 }
 
-// IncrementCacheMiss increments cache misses counter
-func IncrementCacheMiss() {
+// IncrementCacheMiss increments cache misses counter of a cache kind
+func IncrementCacheMiss(kind string) {
 	atomic.AddUint64(&cacheMisses, 1)
+	cacheRequests.WithLabelValues(kind, "miss").Inc()
+}
+
+// IncrementCacheStale increments the counter of expired entries of a cache kind
+// served while they are refreshed
+func IncrementCacheStale(kind string) {
+	atomic.AddUint64(&cacheStale, 1)
+	cacheRequests.WithLabelValues(kind, "stale").Inc()
+}
+
+// IncrementCoalesced counts a caller of a cache kind that shared an upstream fetch
+// already in flight instead of starting its own
+func IncrementCoalesced(kind string) {
+	atomic.AddUint64(&coalesced, 1)
+	coalescedRequests.WithLabelValues(kind).Inc()
+}
+
+// ObserveSejmRequest records a request to the Sejm API endpoint, with status 0
+// standing for a request that got no response
+func ObserveSejmRequest(endpoint string, status int, duration time.Duration) {
+	code := "error"
+	if status != 0 {
+		code = strconv.Itoa(status)
+	}
+	sejmRequests.WithLabelValues(endpoint, code).Inc()
+	sejmDuration.WithLabelValues(endpoint).Observe(duration.Seconds())
+}
+
+// IncrementSejmRetry counts a request to the Sejm API endpoint sent again after a failure
+func IncrementSejmRetry(endpoint string) {
+	sejmRetries.WithLabelValues(endpoint).Inc()
+}
+
+// SetSejmCircuitState records the state of the Sejm API circuit breaker:
+// 0 closed, 1 open, 2 half-open
+func SetSejmCircuitState(state int) {
+	atomic.StoreUint64(&sejmCircuitState, uint64(state))
+}
+
+// ObserveDBQuery records the duration of a database query started at start,
+// meant to be deferred at the top of a query method
+func ObserveDBQuery(query string, start time.Time) {
+	dbDuration.WithLabelValues(query).Observe(time.Since(start).Seconds())
+}
+
+// RecordSyncRun records the outcome of a background sync run
+func RecordSyncRun(start time.Time, duration time.Duration, failures int) {
+	atomic.AddUint64(&syncRuns, 1)
+	atomic.AddUint64(&syncFailures, uint64(failures))
+	atomic.StoreUint64(&syncLastRun, uint64(start.Unix()))
+	atomic.StoreUint64(&syncLastDurationMs, uint64(duration.Milliseconds()))
+	atomic.StoreUint64(&syncLastFailures, uint64(failures))
 }
 
 // GetMetrics returns current metrics values
@@ -45,5 +128,15 @@ func GetMetrics() map[string]uint64 {
 		"sejm_api_calls": atomic.LoadUint64(&sejmAPICalls),
 		"cache_hits":     atomic.LoadUint64(&cacheHits),
 		"cache_misses":   atomic.LoadUint64(&cacheMisses),
+		"cache_stale":    atomic.LoadUint64(&cacheStale),
+		"coalesced":      atomic.LoadUint64(&coalesced),
+
+		"sejm_circuit_state": atomic.LoadUint64(&sejmCircuitState),
+
+		"sync_runs":             atomic.LoadUint64(&syncRuns),
+		"sync_failures":         atomic.LoadUint64(&syncFailures),
+		"sync_last_run_unix":    atomic.LoadUint64(&syncLastRun),
+		"sync_last_duration_ms": atomic.LoadUint64(&syncLastDurationMs),
+		"sync_last_failures":    atomic.LoadUint64(&syncLastFailures),
 	}
 }
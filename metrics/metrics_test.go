@@ -0,0 +1,65 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"ustawka/metrics"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scrape(t *testing.T) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	return rec.Body.String()
+}
+
+func TestMiddleware(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(metrics.Middleware)
+	r.Get("/api/acts/{publisher}/{year}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	r.Get("/", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	for _, path := range []string{"/api/acts/DU/2024", "/api/acts/MP/2023", "/", "/missing/path"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	body := scrape(t)
+	assert.Contains(t, body, `ustawka_http_requests_total{method="GET",route="/api/acts/{publisher}/{year}",status="404"} 2`)
+	assert.Contains(t, body, `ustawka_http_requests_total{method="GET",route="/",status="200"} 1`)
+	assert.Contains(t, body, `ustawka_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, body, `ustawka_http_request_duration_seconds_count{method="GET",route="/api/acts/{publisher}/{year}"} 2`)
+	assert.NotContains(t, body, "/missing/path")
+}
+
+func TestLabelledMetrics(t *testing.T) {
+	metrics.IncrementCacheHit(metrics.CacheDetails)
+	metrics.IncrementCacheMiss(metrics.CacheActs)
+	metrics.ObserveSejmRequest("details", http.StatusOK, 120*time.Millisecond)
+	metrics.ObserveSejmRequest("details", 0, time.Second)
+	metrics.ObserveDBQuery("get_acts", time.Now())
+
+	body := scrape(t)
+	assert.Contains(t, body, `ustawka_cache_requests_total{kind="details",result="hit"} 1`)
+	assert.Contains(t, body, `ustawka_cache_requests_total{kind="acts",result="miss"} 1`)
+	assert.Contains(t, body, `ustawka_sejm_requests_total{endpoint="details",status="200"} 1`)
+	assert.Contains(t, body, `ustawka_sejm_requests_total{endpoint="details",status="error"} 1`)
+	assert.Contains(t, body, `ustawka_sejm_request_duration_seconds_count{endpoint="details"} 2`)
+	assert.Contains(t, body, `ustawka_db_query_duration_seconds_count{query="get_acts"} 1`)
+	assert.Contains(t, body, "ustawka_api_calls_total")
+	assert.Contains(t, body, "ustawka_sync_runs_total")
+
+	m := metrics.GetMetrics()
+	assert.Equal(t, uint64(1), m["cache_hits"])
+	assert.Equal(t, uint64(1), m["cache_misses"])
+}
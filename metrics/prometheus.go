@@ -0,0 +1,141 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes the names of all exposed metrics
+const namespace = "ustawka"
+
+// unmatchedRoute labels requests that matched no route, keeping arbitrary paths out of labels
+const unmatchedRoute = "unmatched"
+
+// dbBuckets are histogram buckets for database queries, which mostly take well below a millisecond
+var dbBuckets = prometheus.ExponentialBuckets(0.0001, 4, 8)
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests served, by method, route pattern and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP requests served, by method and route pattern.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	sejmRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sejm_requests_total",
+		Help:      "Requests sent to the Sejm API, by endpoint and status code.",
+	}, []string{"endpoint", "status"})
+
+	sejmDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "sejm_request_duration_seconds",
+		Help:      "Latency of requests sent to the Sejm API, by endpoint.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"endpoint"})
+
+	sejmRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sejm_retries_total",
+		Help:      "Requests sent to the Sejm API again after a failure, by endpoint.",
+	}, []string{"endpoint"})
+
+	cacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Cache lookups, by cache kind and result.",
+	}, []string{"kind", "result"})
+
+	coalescedRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "coalesced_requests_total",
+		Help:      "Cache misses that waited for an upstream fetch already in flight, by cache kind.",
+	}, []string{"kind"})
+
+	dbDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Latency of database queries, by query.",
+		Buckets:   dbBuckets,
+	}, []string{"query"})
+)
+
+// registry holds the exposed collectors, leaving out those registered globally by libraries
+var registry = newRegistry()
+
+func newRegistry() *prometheus.Registry {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration,
+		sejmRequests, sejmDuration, sejmRetries,
+		cacheRequests, coalescedRequests,
+		dbDuration,
+		counterFunc("api_calls_total", "Service API calls.", &apiCalls),
+		counterFunc("sejm_api_calls_total", "Successful Sejm API calls made by the service.", &sejmAPICalls),
+		counterFunc("sync_runs_total", "Background sync runs.", &syncRuns),
+		counterFunc("sync_failures_total", "Failed background sync tasks.", &syncFailures),
+		gaugeFunc("sejm_circuit_state", "Sejm API circuit breaker state: 0 closed, 1 open, 2 half-open.", &sejmCircuitState, 1),
+		gaugeFunc("sync_last_run_timestamp_seconds", "Start time of the last background sync run.", &syncLastRun, 1),
+		gaugeFunc("sync_last_duration_seconds", "Duration of the last background sync run.", &syncLastDurationMs, 1e-3),
+		gaugeFunc("sync_last_failures", "Failed tasks of the last background sync run.", &syncLastFailures, 1),
+	)
+	return reg
+}
+
+// counterFunc exposes one of the plain counters
+func counterFunc(name, help string, value *uint64) prometheus.Collector {
+	return prometheus.NewCounterFunc(prometheus.CounterOpts{Namespace: namespace, Name: name, Help: help},
+		func() float64 { return float64(atomic.LoadUint64(value)) })
+}
+
+// gaugeFunc exposes one of the plain values, scaled to base units
+func gaugeFunc(name, help string, value *uint64, scale float64) prometheus.Collector {
+	return prometheus.NewGaugeFunc(prometheus.GaugeOpts{Namespace: namespace, Name: name, Help: help},
+		func() float64 { return float64(atomic.LoadUint64(value)) * scale })
+}
+
+// Handler serves all metrics in the Prometheus text exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// Middleware counts requests and records their latency labelled with the chi route pattern
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		// The pattern is only known once the router has matched the request
+		route := unmatchedRoute
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			if pattern := rctx.RoutePattern(); pattern != "" {
+				route = pattern
+			}
+		}
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		httpRequests.WithLabelValues(r.Method, route, strconv.Itoa(status)).Inc()
+		httpDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+	"ustawka/db"
+	"ustawka/server"
+)
+
+// errUsage is returned for unknown subcommands and arguments
+var errUsage = errors.New("usage: ustawka migrate status|up")
+
+// runMigrate shows or applies the schema migrations of the database
+func runMigrate(ctx context.Context, args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	database, err := server.OpenStore()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = database.Close()
+	}()
+
+	switch args[0] {
+	case "status":
+		migrations, err := database.Migrations(ctx)
+		if err != nil {
+			return err
+		}
+		return printMigrations(out, migrations)
+	case "up":
+		applied, err := database.Migrate(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(out, "Applied %d migration(s)\n", applied)
+		return err
+	default:
+		return errUsage
+	}
+}
+
+// printMigrations writes a table of migrations and when they were applied
+func printMigrations(out io.Writer, migrations []db.Migration) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+	for _, m := range migrations {
+		applied := "pending"
+		if m.AppliedAt != nil {
+			applied = m.AppliedAt.Local().Format(time.DateTime)
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\n", m.Version, m.Name, applied)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,144 @@
+package notify
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"ustawka/db"
+	"ustawka/sejm"
+)
+
+// Types of changes reported to watchers
+const (
+	// EventStatusChanged is sent when an act changes its status, e.g. becomes repealed
+	EventStatusChanged = "status_changed"
+	// EventAmended is sent when a new amending act references the act
+	EventAmended = "amended"
+	// EventConsolidatedText is sent when a consolidated text of the act is published
+	EventConsolidatedText = "consolidated_text"
+)
+
+// Event describes a relevant change of an act detected during a refresh
+type Event struct {
+	Type       string    `json:"type"`
+	ActID      string    `json:"actId"`
+	Title      string    `json:"title"`
+	Keywords   []string  `json:"keywords,omitempty"`
+	OldValue   string    `json:"oldValue,omitempty"`
+	NewValue   string    `json:"newValue"`
+	DetectedAt time.Time `json:"detectedAt"`
+}
+
+// DetectChanges compares act details before and after a refresh and returns amendments and
+// consolidated texts; status changes are reported from year listings by StatusChanges
+// only, so a change seen in both is sent once
+func DetectChanges(before, after *sejm.ActDetails) []Event {
+	if before == nil || after == nil {
+		return nil
+	}
+
+	now := time.Now()
+	newEvent := func(eventType, oldValue, newValue string) Event {
+		return Event{
+			Type:       eventType,
+			ActID:      after.ID,
+			Title:      after.Title,
+			Keywords:   after.Keywords,
+			OldValue:   oldValue,
+			NewValue:   newValue,
+			DetectedAt: now,
+		}
+	}
+
+	var events []Event
+	for _, id := range addedReferences(amendingActs(before), amendingActs(after)) {
+		events = append(events, newEvent(EventAmended, "", id))
+	}
+
+	for _, id := range addedReferences(consolidatedTexts(before), consolidatedTexts(after)) {
+		events = append(events, newEvent(EventConsolidatedText, "", id))
+	}
+
+	return events
+}
+
+// StatusChanges returns events for the status changes recorded when storing a listing of
+// acts; listings carry no keywords, so these are left for the caller to fill in
+func StatusChanges(changes []db.ActChange, acts []sejm.Act) []Event {
+	titles := make(map[string]string, len(acts))
+	for _, act := range acts {
+		titles[act.ID] = act.Title
+	}
+
+	now := time.Now()
+	var events []Event
+	for _, change := range changes {
+		if change.Field != "status" {
+			continue
+		}
+		events = append(events, Event{
+			Type:       EventStatusChanged,
+			ActID:      change.ActID,
+			Title:      titles[change.ActID],
+			OldValue:   change.OldValue,
+			NewValue:   change.NewValue,
+			DetectedAt: now,
+		})
+	}
+
+	return events
+}
+
+// addedReferences returns IDs referenced after but not before
+func addedReferences(before, after []string) []string {
+	var added []string
+	for _, id := range after {
+		if !slices.Contains(before, id) {
+			added = append(added, id)
+		}
+	}
+	return added
+}
+
+// amendingActs returns IDs of acts amending an act
+func amendingActs(details *sejm.ActDetails) []string {
+	ids := make([]string, 0, len(details.References.AmendingActs))
+	for _, ref := range details.References.AmendingActs {
+		ids = append(ids, ref.ID)
+	}
+	return ids
+}
+
+// consolidatedTexts identifies consolidated texts of an act by references and text files
+func consolidatedTexts(details *sejm.ActDetails) []string {
+	var ids []string
+	for _, ref := range details.References.TekstJednolity {
+		ids = append(ids, ref.ID)
+	}
+	for _, ref := range details.References.InfOTekstJednolitym {
+		ids = append(ids, ref.ID)
+	}
+	for _, text := range details.Texts {
+		if text.Type == "I" {
+			ids = append(ids, text.FileName)
+		}
+	}
+	return ids
+}
+
+// Matches reports whether a watch is interested in an event
+func Matches(watch db.Watch, event Event) bool {
+	switch watch.Kind {
+	case db.WatchAct:
+		return watch.Target == event.ActID
+	case db.WatchYear:
+		return strings.HasPrefix(event.ActID, watch.Target+"/")
+	case db.WatchKeyword:
+		return slices.ContainsFunc(event.Keywords, func(keyword string) bool {
+			return strings.EqualFold(keyword, watch.Target)
+		})
+	default:
+		return false
+	}
+}
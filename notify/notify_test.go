@@ -0,0 +1,66 @@
+package notify_test
+
+import (
+	"encoding/json"
+	"testing"
+	"ustawka/db"
+	"ustawka/notify"
+	"ustawka/sejm"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseDetails builds act details from API JSON, as references are not constructible directly
+func parseDetails(t *testing.T, data string) *sejm.ActDetails {
+	t.Helper()
+	var details sejm.ActDetails
+	require.NoError(t, json.Unmarshal([]byte(data), &details))
+	return &details
+}
+
+func TestDetectChanges(t *testing.T) {
+	before := parseDetails(t, `{
+		"ELI": "DU/2024/1", "title": "Ustawa", "status": "obowiązujący",
+		"references": {"Akty zmieniające": [{"id": "DU/2024/50"}]}
+	}`)
+	after := parseDetails(t, `{
+		"ELI": "DU/2024/1", "title": "Ustawa", "status": "uchylony", "keywords": ["podatki"],
+		"references": {
+			"Akty zmieniające": [{"id": "DU/2024/50"}, {"id": "DU/2025/7"}],
+			"Inf. o tekście jednolitym": [{"id": "DU/2025/100"}]
+		}
+	}`)
+
+	events := notify.DetectChanges(before, after)
+	// Status changes come from year listings only
+	require.Len(t, events, 2)
+
+	assert.Equal(t, notify.EventAmended, events[0].Type)
+	assert.Equal(t, "DU/2025/7", events[0].NewValue)
+	assert.Equal(t, []string{"podatki"}, events[0].Keywords)
+	assert.Equal(t, notify.EventConsolidatedText, events[1].Type)
+	assert.Equal(t, "DU/2025/100", events[1].NewValue)
+
+	assert.Empty(t, notify.DetectChanges(after, after))
+	assert.Empty(t, notify.DetectChanges(nil, after))
+}
+
+func TestStatusChanges(t *testing.T) {
+	changes := []db.ActChange{
+		{ActID: "DU/2024/1", Field: "status", OldValue: "obowiązujący", NewValue: "uchylony"},
+		{ActID: "DU/2024/2", Field: "title", OldValue: "Ustawa", NewValue: "Ustawa o podatku"},
+	}
+	acts := []sejm.Act{
+		{ID: "DU/2024/1", Title: "Ustawa A", Status: "uchylony"},
+		{ID: "DU/2024/2", Title: "Ustawa o podatku", Status: "obowiązujący"},
+	}
+
+	events := notify.StatusChanges(changes, acts)
+	require.Len(t, events, 1)
+	assert.Equal(t, notify.EventStatusChanged, events[0].Type)
+	assert.Equal(t, "DU/2024/1", events[0].ActID)
+	assert.Equal(t, "Ustawa A", events[0].Title)
+	assert.Equal(t, "obowiązujący", events[0].OldValue)
+	assert.Equal(t, "uchylony", events[0].NewValue)
+}
@@ -0,0 +1,325 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"sync"
+	"syscall"
+	"time"
+
+	"ustawka/db"
+)
+
+// Webhook request headers
+const (
+	// SignatureHeader carries the hex HMAC-SHA256 of the body keyed with the watch secret
+	SignatureHeader = "X-Ustawka-Signature"
+	// EventHeader carries the event type
+	EventHeader = "X-Ustawka-Event"
+)
+
+// Default delivery settings
+const (
+	defaultAttempts  = 4
+	defaultBackoff   = time.Second
+	defaultTimeout   = 10 * time.Second
+	defaultWorkers   = 4
+	defaultQueueSize = 1000
+)
+
+// ErrForbiddenAddress is returned when a webhook would be sent to a loopback, link-local or
+// private address
+var ErrForbiddenAddress = errors.New("webhook address not allowed")
+
+// errQueueFull is recorded for deliveries dropped because too many are waiting
+var errQueueFull = errors.New("delivery queue full")
+
+// Store provides watches and keeps failed deliveries
+type Store interface {
+	ListWatches(ctx context.Context) ([]db.Watch, error)
+	StoreDeadLetter(ctx context.Context, letter db.DeadLetter) error
+}
+
+// Payload is the JSON body of a webhook request
+type Payload struct {
+	WatchID int64  `json:"watchId"`
+	Kind    string `json:"kind"`
+	Target  string `json:"target"`
+	Event   Event  `json:"event"`
+}
+
+// Webhooks delivers events to the URLs of matching watches in the background
+type Webhooks struct {
+	store      Store
+	httpClient *http.Client
+	attempts   int
+	backoff    time.Duration
+	workers    int
+	queueSize  int
+	queue      chan delivery
+}
+
+// delivery is an event queued for a watch
+type delivery struct {
+	watch db.Watch
+	event Event
+}
+
+// Option configures Webhooks
+type Option func(*Webhooks)
+
+// WithRetries sets how many times a delivery is attempted and the initial delay between attempts,
+// which doubles after every failure
+func WithRetries(attempts int, backoff time.Duration) Option {
+	return func(w *Webhooks) {
+		if attempts > 0 {
+			w.attempts = attempts
+		}
+		if backoff >= 0 {
+			w.backoff = backoff
+		}
+	}
+}
+
+// WithWorkers sets how many deliveries run at the same time and how many may wait in the queue
+func WithWorkers(workers, queueSize int) Option {
+	return func(w *Webhooks) {
+		if workers > 0 {
+			w.workers = workers
+		}
+		if queueSize > 0 {
+			w.queueSize = queueSize
+		}
+	}
+}
+
+// WithHTTPClient sets the client used for deliveries
+func WithHTTPClient(client *http.Client) Option {
+	return func(w *Webhooks) {
+		w.httpClient = client
+	}
+}
+
+// NewWebhooks creates a webhook notifier
+func NewWebhooks(store Store, opts ...Option) *Webhooks {
+	w := &Webhooks{
+		store:      store,
+		httpClient: newHTTPClient(),
+		attempts:   defaultAttempts,
+		backoff:    defaultBackoff,
+		workers:    defaultWorkers,
+		queueSize:  defaultQueueSize,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	w.queue = make(chan delivery, w.queueSize)
+	return w
+}
+
+// Notify queues each event for all watches interested in it without waiting for the
+// deliveries, which are made by Run; events that don't fit in the queue are recorded
+// as dead letters
+func (w *Webhooks) Notify(ctx context.Context, events []Event) {
+	if len(events) == 0 {
+		return
+	}
+
+	watches, err := w.store.ListWatches(ctx)
+	if err != nil {
+		slog.Error("Error listing watches", "error", err)
+		return
+	}
+
+	for _, event := range events {
+		for _, watch := range watches {
+			if !Matches(watch, event) {
+				continue
+			}
+			select {
+			case w.queue <- delivery{watch: watch, event: event}:
+			default:
+				slog.Warn("Webhook queue full, dropping delivery", "watch_id", watch.ID, "event", event.Type)
+				w.deadLetter(ctx, watch, event, errQueueFull, 0)
+			}
+		}
+	}
+}
+
+// Run delivers queued events until the context is cancelled; deliveries still queued
+// then are recorded as dead letters
+func (w *Webhooks) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for range w.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case d := <-w.queue:
+					w.deliver(ctx, d.watch, d.event)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for {
+		select {
+		case d := <-w.queue:
+			w.deadLetter(ctx, d.watch, d.event, ctx.Err(), 0)
+		default:
+			return
+		}
+	}
+}
+
+// deliver posts an event to a watch's URL, retrying with exponential backoff
+func (w *Webhooks) deliver(ctx context.Context, watch db.Watch, event Event) {
+	body, err := payload(watch, event)
+	if err != nil {
+		slog.Error("Error encoding webhook payload", "watch_id", watch.ID, "error", err)
+		return
+	}
+
+	backoff := w.backoff
+	attempt := 0
+	for {
+		attempt++
+		err = w.post(ctx, watch, event.Type, body)
+		if err == nil {
+			slog.Info("Webhook delivered", "watch_id", watch.ID, "event", event.Type, "act_id", event.ActID)
+			return
+		}
+		slog.Warn("Webhook delivery failed", "watch_id", watch.ID, "attempt", attempt, "error", err)
+
+		if attempt >= w.attempts || !sleep(ctx, backoff) {
+			break
+		}
+		backoff *= 2
+	}
+
+	w.deadLetter(ctx, watch, event, err, attempt)
+}
+
+// deadLetter records a delivery that failed or was never attempted
+func (w *Webhooks) deadLetter(ctx context.Context, watch db.Watch, event Event, cause error, attempts int) {
+	body, err := payload(watch, event)
+	if err != nil {
+		slog.Error("Error encoding webhook payload", "watch_id", watch.ID, "error", err)
+		return
+	}
+
+	letter := db.DeadLetter{
+		WatchID:  watch.ID,
+		URL:      watch.URL,
+		Payload:  string(body),
+		Error:    cause.Error(),
+		Attempts: attempts,
+	}
+	// The dead letter is kept even if deliveries are being shut down
+	if err := w.store.StoreDeadLetter(context.WithoutCancel(ctx), letter); err != nil {
+		slog.Error("Error storing dead letter", "watch_id", watch.ID, "error", err)
+	}
+}
+
+// payload encodes the webhook body of an event sent to a watch
+func payload(watch db.Watch, event Event) ([]byte, error) {
+	return json.Marshal(Payload{WatchID: watch.ID, Kind: watch.Kind, Target: watch.Target, Event: event})
+}
+
+// post sends a single signed webhook request
+func (w *Webhooks) post(ctx context.Context, watch db.Watch, eventType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, watch.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, eventType)
+	req.Header.Set(SignatureHeader, "sha256="+Sign(watch.Secret, body))
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending webhook: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			slog.Error("Error closing response body", "error", err)
+		}
+	}()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook failed with status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// newHTTPClient creates a client that only connects to public addresses, so webhooks
+// can't reach internal services even through DNS names or redirects
+func newHTTPClient() *http.Client {
+	dialer := &net.Dialer{Timeout: defaultTimeout, Control: publicOnly}
+	return &http.Client{
+		Timeout:   defaultTimeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+	}
+}
+
+// publicOnly refuses connections to addresses that are not public
+func publicOnly(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !PublicAddr(addr) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+	}
+	return nil
+}
+
+// PublicAddr reports whether webhooks may be sent to an address, i.e. it is not
+// loopback, link-local, private, multicast or unspecified
+func PublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() &&
+		!addr.IsLoopback() &&
+		!addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() &&
+		!addr.IsPrivate() &&
+		!addr.IsMulticast() &&
+		!addr.IsUnspecified()
+}
+
+// Sign returns the hex HMAC-SHA256 of a body keyed with a secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// sleep waits for the duration, returning false if the context is cancelled first
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
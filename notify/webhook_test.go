@@ -0,0 +1,256 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+	"ustawka/db"
+	"ustawka/notify"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestDB(t *testing.T) *db.DB {
+	t.Helper()
+	database, err := db.New(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if err := database.Close(); err != nil {
+			t.Errorf("Error closing database: %v", err)
+		}
+	})
+	return database
+}
+
+// runWebhooks delivers queued events until the test ends
+func runWebhooks(t *testing.T, webhooks *notify.Webhooks) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		webhooks.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+// countDeadLetters returns the number of recorded dead letters
+func countDeadLetters(t *testing.T, database *db.DB) int {
+	t.Helper()
+	var count int
+	require.NoError(t, database.QueryRowContext(context.Background(), "SELECT COUNT(*) FROM webhook_dead_letters").Scan(&count))
+	return count
+}
+
+func statusEvent(actID string) notify.Event {
+	return notify.Event{
+		Type:       notify.EventStatusChanged,
+		ActID:      actID,
+		Title:      "Ustawa testowa",
+		Keywords:   []string{"Podatki"},
+		OldValue:   "obowiązujący",
+		NewValue:   "uchylony",
+		DetectedAt: time.Now(),
+	}
+}
+
+func TestNotifyDeliversSignedWebhooks(t *testing.T) {
+	database := setupTestDB(t)
+	ctx := context.Background()
+
+	received := make(chan notify.Payload, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, "sha256="+notify.Sign("s3cret", body), r.Header.Get(notify.SignatureHeader))
+		assert.Equal(t, notify.EventStatusChanged, r.Header.Get(notify.EventHeader))
+
+		var payload notify.Payload
+		assert.NoError(t, json.Unmarshal(body, &payload))
+		received <- payload
+	}))
+	defer receiver.Close()
+
+	watches := []db.Watch{
+		{Kind: db.WatchAct, Target: "DU/2024/1", URL: receiver.URL, Secret: "s3cret"},
+		{Kind: db.WatchYear, Target: "DU/2024", URL: receiver.URL, Secret: "s3cret"},
+		{Kind: db.WatchKeyword, Target: "podatki", URL: receiver.URL, Secret: "s3cret"},
+		{Kind: db.WatchAct, Target: "DU/2024/2", URL: receiver.URL, Secret: "s3cret"},
+		{Kind: db.WatchYear, Target: "DU/202", URL: receiver.URL, Secret: "s3cret"},
+	}
+	for _, watch := range watches {
+		_, err := database.AddWatch(ctx, watch)
+		require.NoError(t, err)
+	}
+
+	webhooks := notify.NewWebhooks(database, notify.WithHTTPClient(receiver.Client()))
+	runWebhooks(t, webhooks)
+	webhooks.Notify(ctx, []notify.Event{statusEvent("DU/2024/1")})
+
+	var kinds []string
+	for range 3 {
+		select {
+		case payload := <-received:
+			assert.Equal(t, "DU/2024/1", payload.Event.ActID)
+			kinds = append(kinds, payload.Kind)
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for webhooks")
+		}
+	}
+	assert.ElementsMatch(t, []string{db.WatchAct, db.WatchYear, db.WatchKeyword}, kinds)
+}
+
+func TestNotifyRetriesAndRecordsDeadLetters(t *testing.T) {
+	database := setupTestDB(t)
+	ctx := context.Background()
+
+	var flakyCalls, brokenCalls atomic.Int32
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if flakyCalls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer flaky.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		brokenCalls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer broken.Close()
+
+	_, err := database.AddWatch(ctx, db.Watch{Kind: db.WatchAct, Target: "DU/2024/1", URL: flaky.URL})
+	require.NoError(t, err)
+	brokenWatch, err := database.AddWatch(ctx, db.Watch{Kind: db.WatchAct, Target: "DU/2024/1", URL: broken.URL})
+	require.NoError(t, err)
+
+	webhooks := notify.NewWebhooks(database, notify.WithRetries(3, time.Millisecond), notify.WithHTTPClient(http.DefaultClient))
+	runWebhooks(t, webhooks)
+	webhooks.Notify(ctx, []notify.Event{statusEvent("DU/2024/1")})
+
+	require.Eventually(t, func() bool {
+		return countDeadLetters(t, database) == 1 && flakyCalls.Load() == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(3), brokenCalls.Load())
+
+	var watchID int64
+	var url, payload, lastError string
+	var attempts int
+	require.NoError(t, database.QueryRowContext(ctx,
+		"SELECT watch_id, url, payload, error, attempts FROM webhook_dead_letters",
+	).Scan(&watchID, &url, &payload, &lastError, &attempts))
+	assert.Equal(t, brokenWatch.ID, watchID)
+	assert.Equal(t, broken.URL, url)
+	assert.Contains(t, payload, `"actId":"DU/2024/1"`)
+	assert.Contains(t, lastError, "500")
+	assert.Equal(t, 3, attempts)
+}
+
+func TestNotifyRefusesLocalAddresses(t *testing.T) {
+	database := setupTestDB(t)
+	ctx := context.Background()
+
+	var calls atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+	}))
+	defer receiver.Close()
+
+	_, err := database.AddWatch(ctx, db.Watch{Kind: db.WatchAct, Target: "DU/2024/1", URL: receiver.URL})
+	require.NoError(t, err)
+
+	webhooks := notify.NewWebhooks(database, notify.WithRetries(1, 0))
+	runWebhooks(t, webhooks)
+	webhooks.Notify(ctx, []notify.Event{statusEvent("DU/2024/1")})
+
+	require.Eventually(t, func() bool {
+		return countDeadLetters(t, database) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Zero(t, calls.Load())
+	var lastError string
+	require.NoError(t, database.QueryRowContext(ctx, "SELECT error FROM webhook_dead_letters").Scan(&lastError))
+	assert.Contains(t, lastError, notify.ErrForbiddenAddress.Error())
+}
+
+func TestNotifyDoesNotWaitForDeliveries(t *testing.T) {
+	database := setupTestDB(t)
+	ctx := context.Background()
+
+	release := make(chan struct{})
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+	}))
+	defer receiver.Close()
+	defer close(release)
+
+	_, err := database.AddWatch(ctx, db.Watch{Kind: db.WatchYear, Target: "DU/2024", URL: receiver.URL})
+	require.NoError(t, err)
+
+	webhooks := notify.NewWebhooks(database, notify.WithHTTPClient(receiver.Client()))
+	runWebhooks(t, webhooks)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		webhooks.Notify(ctx, []notify.Event{statusEvent("DU/2024/1"), statusEvent("DU/2024/2")})
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Notify waited for a hanging webhook")
+	}
+}
+
+func TestNotifyRecordsUndeliveredEvents(t *testing.T) {
+	database := setupTestDB(t)
+	ctx := context.Background()
+
+	_, err := database.AddWatch(ctx, db.Watch{Kind: db.WatchYear, Target: "DU/2024", URL: "https://example.com/hook"})
+	require.NoError(t, err)
+
+	// The second event doesn't fit in the queue
+	webhooks := notify.NewWebhooks(database, notify.WithWorkers(1, 1))
+	webhooks.Notify(ctx, []notify.Event{statusEvent("DU/2024/1"), statusEvent("DU/2024/2")})
+	assert.Equal(t, 1, countDeadLetters(t, database))
+
+	// Queued events are kept when deliveries stop
+	stopped, cancel := context.WithCancel(ctx)
+	cancel()
+	webhooks.Run(stopped)
+	assert.Equal(t, 2, countDeadLetters(t, database))
+}
+
+func TestPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1::", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.0.0.1", false},
+		{"172.16.5.4", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"::ffff:127.0.0.1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			assert.Equal(t, tt.want, notify.PublicAddr(netip.MustParseAddr(tt.addr)))
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package sejm
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+// ErrNotModified is returned by GetActs when no page of the listing changed since
+// the validators of its pages were stored
+var ErrNotModified = errors.New("listing not modified")
+
+// Validators are the cache validators the API sent with a response
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+// ValidatorStore keeps validators of API responses by request URL
+type ValidatorStore interface {
+	GetValidators(ctx context.Context, url string) (*Validators, error)
+	StoreValidators(ctx context.Context, url string, validators Validators) error
+}
+
+// WithValidatorStore makes GetActs send conditional requests using validators of the
+// previously fetched pages, kept in the store
+func WithValidatorStore(store ValidatorStore) Option {
+	return func(c *Client) {
+		c.validators = store
+	}
+}
+
+type unconditionalKey struct{}
+
+// WithoutValidators makes GetActs calls with the returned context download the full
+// listing, for callers that have nothing cached to fall back to
+func WithoutValidators(ctx context.Context) context.Context {
+	return context.WithValue(ctx, unconditionalKey{}, true)
+}
+
+type deferredKey struct{}
+
+// deferredValidators holds back the validators of a listing fetched with a deferring context
+type deferredValidators struct {
+	store func(ctx context.Context)
+}
+
+// DeferValidators makes GetActs calls with the returned context hold back the validators of
+// the listing until commit is called. A caller that fails to store the listing doesn't commit,
+// so the listing is downloaded again instead of revalidated against data it never kept.
+func DeferValidators(ctx context.Context) (_ context.Context, commit func(ctx context.Context)) {
+	deferred := &deferredValidators{}
+	return context.WithValue(ctx, deferredKey{}, deferred), func(ctx context.Context) {
+		if deferred.store != nil {
+			deferred.store(ctx)
+		}
+	}
+}
+
+// conditional reports whether a listing request may be made conditional
+func (c *Client) conditional(ctx context.Context) bool {
+	unconditional, _ := ctx.Value(unconditionalKey{}).(bool)
+	return c.validators != nil && !unconditional
+}
+
+// isEmpty reports whether the response carried no validators
+func (v Validators) isEmpty() bool {
+	return v.ETag == "" && v.LastModified == ""
+}
+
+// setConditional adds the validators of a previous response to a request
+func (v *Validators) setConditional(req *http.Request) {
+	if v == nil {
+		return
+	}
+	if v.ETag != "" {
+		req.Header.Set("If-None-Match", v.ETag)
+	}
+	if v.LastModified != "" {
+		req.Header.Set("If-Modified-Since", v.LastModified)
+	}
+}
+
+// responseValidators reads the validators of a response
+func responseValidators(resp *http.Response) Validators {
+	return Validators{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+}
+
+// storedValidators looks up the validators of a URL, treating store errors as a miss
+func (c *Client) storedValidators(ctx context.Context, url string) *Validators {
+	validators, err := c.validators.GetValidators(ctx, url)
+	if err != nil {
+		slog.Error("Error reading response validators", "url", url, "error", err)
+		return nil
+	}
+	return validators
+}
+
+// keepValidators saves the validators of the pages of a complete listing, or hands them
+// to the commit of a deferring context
+func (c *Client) keepValidators(ctx context.Context, pages map[string]Validators) {
+	if deferred, ok := ctx.Value(deferredKey{}).(*deferredValidators); ok {
+		deferred.store = func(ctx context.Context) {
+			c.storeValidators(ctx, pages)
+		}
+		return
+	}
+	c.storeValidators(ctx, pages)
+}
+
+// storeValidators saves the validators of the pages of a complete listing
+func (c *Client) storeValidators(ctx context.Context, pages map[string]Validators) {
+	for url, validators := range pages {
+		if validators.isEmpty() {
+			continue
+		}
+		if err := c.validators.StoreValidators(ctx, url, validators); err != nil {
+			slog.Error("Error storing response validators", "url", url, "error", err)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package sejm_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"ustawka/sejm"
+)
+
+// memoryValidators is a ValidatorStore kept in memory
+type memoryValidators struct {
+	mu         sync.Mutex
+	validators map[string]sejm.Validators
+}
+
+func (m *memoryValidators) GetValidators(_ context.Context, url string) (*sejm.Validators, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	validators, ok := m.validators[url]
+	if !ok {
+		return nil, nil
+	}
+	return &validators, nil
+}
+
+func (m *memoryValidators) StoreValidators(_ context.Context, url string, validators sejm.Validators) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.validators[url] = validators
+	return nil
+}
+
+// conditionalServer serves a paged listing of total acts tagged with an ETag of the
+// listing version, answering matching conditional requests with 304
+type conditionalServer struct {
+	total       int
+	version     int
+	requests    int
+	conditional int
+}
+
+func (s *conditionalServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.requests++
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	etag := fmt.Sprintf(`"v%d-%d"`, s.version, offset)
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		s.conditional++
+		if match == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	items := make([]sejm.Act, 0)
+	for pos := offset + 1; pos <= s.total && pos <= offset+limit; pos++ {
+		items = append(items, sejm.Act{ID: fmt.Sprintf("DU/2024/%d", pos), Position: pos, Year: 2024})
+	}
+	w.Header().Set("ETag", etag)
+	_ = json.NewEncoder(w).Encode(map[string]any{"items": items, "offset": offset, "totalCount": s.total})
+}
+
+func TestGetActsConditional(t *testing.T) {
+	upstream := &conditionalServer{total: 5}
+	server := httptest.NewServer(upstream)
+	defer server.Close()
+
+	store := &memoryValidators{validators: make(map[string]sejm.Validators)}
+	client := sejm.NewClientWithURL(server.URL, sejm.WithPageSize(2), sejm.WithValidatorStore(store))
+	ctx := context.Background()
+
+	// The first fetch downloads all pages and stores their validators
+	acts, err := client.GetActs(ctx, sejm.PublisherDU, 2024)
+	if err != nil || len(acts) != 5 {
+		t.Fatalf("Expected 5 acts, got %d, %v", len(acts), err)
+	}
+	if len(store.validators) != 3 {
+		t.Errorf("Expected validators of 3 pages, got %d", len(store.validators))
+	}
+
+	// Unchanged pages are only revalidated
+	upstream.requests = 0
+	if _, err := client.GetActs(ctx, sejm.PublisherDU, 2024); !errors.Is(err, sejm.ErrNotModified) {
+		t.Fatalf("Expected ErrNotModified, got %v", err)
+	}
+	if upstream.requests != 3 || upstream.conditional != 3 {
+		t.Errorf("Expected 3 conditional requests, got %d of %d", upstream.conditional, upstream.requests)
+	}
+
+	// A changed listing is downloaded again, reusing the changed first page
+	upstream.version++
+	upstream.total = 6
+	upstream.requests = 0
+	acts, err = client.GetActs(ctx, sejm.PublisherDU, 2024)
+	if err != nil || len(acts) != 6 {
+		t.Fatalf("Expected 6 acts, got %d, %v", len(acts), err)
+	}
+	if upstream.requests != 3 {
+		t.Errorf("Expected 3 requests, got %d", upstream.requests)
+	}
+
+	// Callers without a cache can ask for the full listing
+	upstream.conditional = 0
+	acts, err = client.GetActs(sejm.WithoutValidators(ctx), sejm.PublisherDU, 2024)
+	if err != nil || len(acts) != 6 {
+		t.Fatalf("Expected 6 acts, got %d, %v", len(acts), err)
+	}
+	if upstream.conditional != 0 {
+		t.Errorf("Expected unconditional requests, got %d conditional", upstream.conditional)
+	}
+}
+
+func TestGetActsDeferredValidators(t *testing.T) {
+	server := httptest.NewServer(&conditionalServer{total: 3})
+	defer server.Close()
+
+	store := &memoryValidators{validators: make(map[string]sejm.Validators)}
+	client := sejm.NewClientWithURL(server.URL, sejm.WithPageSize(2), sejm.WithValidatorStore(store))
+
+	// Validators of a deferred listing are kept only once the caller commits them
+	ctx, commit := sejm.DeferValidators(context.Background())
+	if _, err := client.GetActs(ctx, sejm.PublisherDU, 2024); err != nil {
+		t.Fatalf("Failed to get acts: %v", err)
+	}
+	if len(store.validators) != 0 {
+		t.Errorf("Expected no validators before commit, got %d", len(store.validators))
+	}
+
+	commit(context.Background())
+	if len(store.validators) != 2 {
+		t.Errorf("Expected validators of 2 pages, got %d", len(store.validators))
+	}
+}
@@ -0,0 +1,73 @@
+package sejm
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidELI is returned for act identifiers that are malformed or name an unknown publisher
+var ErrInvalidELI = errors.New("invalid ELI identifier")
+
+// Forms of act identifiers accepted by ParseELI
+var (
+	// eliPattern matches IDs such as DU/2020/1234
+	eliPattern = regexp.MustCompile(`^([A-Za-z]+)/(\d{4})/(\d+)$`)
+	// addressPattern matches addresses such as WDU20200001234
+	addressPattern  = regexp.MustCompile(`^[Ww]([A-Za-z]{2})(\d{4})(\d{7})$`)
+	yearPattern     = regexp.MustCompile(`^\d{4}$`)
+	positionPattern = regexp.MustCompile(`^\d{1,7}$`)
+)
+
+// ELI identifies an act by publisher, year and position
+type ELI struct {
+	Publisher string
+	Year      int
+	Position  int
+}
+
+// NewELI builds an identifier from route parameters, checking the publisher and numbers
+func NewELI(publisher, year, position string) (ELI, error) {
+	id := ELI{Publisher: strings.ToUpper(publisher)}
+	if !IsValidPublisher(id.Publisher) {
+		return ELI{}, fmt.Errorf("%w: unknown publisher %q", ErrInvalidELI, publisher)
+	}
+
+	if !yearPattern.MatchString(year) {
+		return ELI{}, fmt.Errorf("%w: invalid year %q", ErrInvalidELI, year)
+	}
+	if !positionPattern.MatchString(position) {
+		return ELI{}, fmt.Errorf("%w: invalid position %q", ErrInvalidELI, position)
+	}
+	id.Year, _ = strconv.Atoi(year)
+	id.Position, _ = strconv.Atoi(position)
+	if id.Position == 0 {
+		return ELI{}, fmt.Errorf("%w: invalid position %q", ErrInvalidELI, position)
+	}
+
+	return id, nil
+}
+
+// ParseELI parses an act ID such as DU/2020/1234 or an address such as WDU20200001234
+func ParseELI(value string) (ELI, error) {
+	value = strings.Trim(strings.TrimSpace(value), "/")
+	if m := eliPattern.FindStringSubmatch(value); m != nil {
+		return NewELI(m[1], m[2], m[3])
+	}
+	if m := addressPattern.FindStringSubmatch(value); m != nil {
+		return NewELI(m[1], m[2], m[3])
+	}
+	return ELI{}, fmt.Errorf("%w: %q", ErrInvalidELI, value)
+}
+
+// String returns the act ID used by the ELI API, e.g. DU/2020/1234
+func (e ELI) String() string {
+	return fmt.Sprintf("%s/%d/%d", e.Publisher, e.Year, e.Position)
+}
+
+// Address returns the act address, e.g. WDU20200001234
+func (e ELI) Address() string {
+	return fmt.Sprintf("W%s%04d%07d", e.Publisher, e.Year, e.Position)
+}
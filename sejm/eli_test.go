@@ -0,0 +1,46 @@
+package sejm_test
+
+import (
+	"errors"
+	"testing"
+	"ustawka/sejm"
+)
+
+func TestParseELI(t *testing.T) {
+	tests := []struct {
+		value string
+		want  sejm.ELI
+	}{
+		{"DU/2020/1234", sejm.ELI{Publisher: "DU", Year: 2020, Position: 1234}},
+		{"mp/2024/7", sejm.ELI{Publisher: "MP", Year: 2024, Position: 7}},
+		{"/DU/2020/1234/", sejm.ELI{Publisher: "DU", Year: 2020, Position: 1234}},
+		{"WDU20200001234", sejm.ELI{Publisher: "DU", Year: 2020, Position: 1234}},
+		{"wmp20240000007", sejm.ELI{Publisher: "MP", Year: 2024, Position: 7}},
+	}
+	for _, tt := range tests {
+		got, err := sejm.ParseELI(tt.value)
+		if err != nil {
+			t.Errorf("ParseELI(%q) returned error: %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseELI(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+	}
+
+	for _, value := range []string{"", "DU/2020", "XX/2020/1", "DU/20/1", "DU/2020/0", "DU/2020/abc", "WDU2020123"} {
+		if _, err := sejm.ParseELI(value); !errors.Is(err, sejm.ErrInvalidELI) {
+			t.Errorf("ParseELI(%q) error = %v, want ErrInvalidELI", value, err)
+		}
+	}
+}
+
+func TestELIForms(t *testing.T) {
+	id := sejm.ELI{Publisher: "DU", Year: 2020, Position: 1234}
+	if got := id.String(); got != "DU/2020/1234" {
+		t.Errorf("String() = %q", got)
+	}
+	if got := id.Address(); got != "WDU20200001234" {
+		t.Errorf("Address() = %q", got)
+	}
+}
@@ -0,0 +1,276 @@
+package sejm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+	"ustawka/metrics"
+
+	"golang.org/x/time/rate"
+)
+
+// Default retry, rate limit and circuit breaker settings
+const (
+	defaultMaxAttempts     = 4
+	defaultBackoff         = 500 * time.Millisecond
+	defaultMaxBackoff      = 10 * time.Second
+	defaultRateLimit       = 5
+	defaultRateBurst       = 10
+	defaultBreakerFailures = 5
+	defaultBreakerCooldown = 30 * time.Second
+)
+
+// ErrCircuitOpen is returned without calling the API while recent calls keep failing
+var ErrCircuitOpen = errors.New("sejm API circuit breaker open")
+
+// WithMaxAttempts sets how many times a failed request is sent, 1 disabling retries
+func WithMaxAttempts(attempts int) Option {
+	return func(c *Client) {
+		if attempts > 0 {
+			c.maxAttempts = attempts
+		}
+	}
+}
+
+// WithBackoff sets the delay before the first retry, doubled for each next one up to maxDelay
+func WithBackoff(delay, maxDelay time.Duration) Option {
+	return func(c *Client) {
+		if delay > 0 && maxDelay >= delay {
+			c.backoff = delay
+			c.maxBackoff = maxDelay
+		}
+	}
+}
+
+// WithRateLimit limits requests of the client to perSecond on average, allowing bursts
+func WithRateLimit(perSecond float64, burst int) Option {
+	return func(c *Client) {
+		if perSecond > 0 && burst > 0 {
+			c.limiter = rate.NewLimiter(rate.Limit(perSecond), burst)
+		}
+	}
+}
+
+// WithCircuitBreaker opens the circuit after failures calls failed in a row,
+// rejecting calls until cooldown passes and a trial call succeeds
+func WithCircuitBreaker(failures int, cooldown time.Duration) Option {
+	return func(c *Client) {
+		if failures > 0 && cooldown > 0 {
+			c.breaker = newBreaker(failures, cooldown)
+		}
+	}
+}
+
+// durationFromEnv reads a positive duration from the environment, falling back to a default
+func durationFromEnv(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		slog.Warn("Invalid "+name+" value, using default", "value", value, "default", fallback)
+		return fallback
+	}
+
+	slog.Info("Using custom "+name, "value", duration)
+	return duration
+}
+
+// do sends a request through the circuit breaker and the rate limiter, retrying
+// transport errors, 429 and 5xx responses with exponential backoff
+func (c *Client) do(req *http.Request, endpoint string) (*http.Response, error) {
+	ctx := req.Context()
+	if !c.breaker.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	for attempt := 1; ; attempt++ {
+		if err := c.limiter.Wait(ctx); err != nil {
+			// Waiting for the limiter says nothing about the API
+			c.breaker.release()
+			return nil, err
+		}
+
+		resp, err := c.send(req, endpoint)
+		failed := isFailure(resp, err)
+		if errors.Is(ctx.Err(), context.Canceled) {
+			c.breaker.release()
+			return resp, err
+		}
+		if !failed || attempt >= c.maxAttempts || ctx.Err() != nil {
+			c.breaker.record(!failed)
+			return resp, err
+		}
+
+		delay := c.retryDelay(attempt, resp)
+		if resp != nil {
+			// Drain the body so the connection can be reused
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+		slog.Warn("Retrying Sejm API request", "url", req.URL.String(), "attempt", attempt, "delay", delay, "error", err)
+		metrics.IncrementSejmRetry(endpoint)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			// The retry budget ran out with the API still failing
+			c.breaker.record(false)
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// send sends a single request, recording its latency and status code under the endpoint name
+func (c *Client) send(req *http.Request, endpoint string) (*http.Response, error) {
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	status := 0
+	if err == nil {
+		status = resp.StatusCode
+	}
+	metrics.ObserveSejmRequest(endpoint, status, time.Since(start))
+	return resp, err
+}
+
+// retryDelay returns the delay before the next attempt: the server's Retry-After
+// if given, otherwise an exponential backoff with jitter, both capped at the maximum
+func (c *Client) retryDelay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			return min(delay, c.maxBackoff)
+		}
+	}
+
+	delay := c.maxBackoff
+	if shift := attempt - 1; shift < 32 {
+		delay = min(c.backoff<<shift, c.maxBackoff)
+	}
+	// Equal jitter keeps at least half of the delay while spreading concurrent retries
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// isFailure reports whether a request failed in a way worth retrying, counting
+// towards opening the circuit
+func isFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// Circuit breaker states
+const (
+	stateClosed = iota
+	stateOpen
+	stateHalfOpen
+)
+
+// breaker is a circuit breaker counting calls that failed in a row
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	state     int
+	openedAt  time.Time
+	probing   bool
+}
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	return &breaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow reports whether a call may proceed; once the cooldown has passed an open
+// circuit lets a single trial call through
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case stateOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.setState(stateHalfOpen)
+		b.probing = true
+		return true
+	case stateHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// release ends a call whose outcome says nothing about the API, such as one
+// cancelled by the caller
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+// record closes the circuit after a success and opens it after too many failures
+// or a failed trial call
+func (b *breaker) record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if success {
+		b.failures = 0
+		b.setState(stateClosed)
+		return
+	}
+
+	b.failures++
+	if b.state == stateHalfOpen || b.failures >= b.threshold {
+		if b.state != stateOpen {
+			slog.Warn("Sejm API circuit breaker opened", "failures", b.failures, "cooldown", b.cooldown)
+		}
+		b.openedAt = time.Now()
+		b.setState(stateOpen)
+	}
+}
+
+// setState moves the breaker to a state, exposing it in metrics
+func (b *breaker) setState(state int) {
+	if state == b.state {
+		return
+	}
+	if state == stateClosed {
+		slog.Info("Sejm API circuit breaker closed")
+	}
+	b.state = state
+	metrics.SetSejmCircuitState(state)
+}
@@ -0,0 +1,152 @@
+package sejm_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+	"ustawka/sejm"
+)
+
+const detailsJSON = `{"ELI":"DU/2024/1","title":"Ustawa"}`
+
+// newFlakyServer fails the first failures requests with status, then serves act details
+func newFlakyServer(failures int, status int, header http.Header, requests *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if int(requests.Add(1)) <= failures {
+			for name, values := range header {
+				w.Header()[name] = values
+			}
+			w.WriteHeader(status)
+			return
+		}
+		_, _ = w.Write([]byte(detailsJSON))
+	}))
+}
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		status   int
+		attempts int
+		wantErr  bool
+		wantSent int32
+	}{
+		{name: "retries server errors", failures: 2, status: http.StatusServiceUnavailable, attempts: 3, wantSent: 3},
+		{name: "retries rate limiting", failures: 1, status: http.StatusTooManyRequests, attempts: 3, wantSent: 2},
+		{name: "gives up after max attempts", failures: 5, status: http.StatusBadGateway, attempts: 3, wantErr: true, wantSent: 3},
+		{name: "does not retry client errors", failures: 1, status: http.StatusNotFound, attempts: 3, wantErr: true, wantSent: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := newFlakyServer(tt.failures, tt.status, nil, &requests)
+			defer server.Close()
+
+			client := sejm.NewClientWithURL(server.URL,
+				sejm.WithMaxAttempts(tt.attempts), sejm.WithBackoff(time.Millisecond, 5*time.Millisecond))
+
+			details, err := client.GetActDetails(context.Background(), "DU/2024/1")
+			if tt.wantErr && err == nil {
+				t.Error("Expected error, got nil")
+			}
+			if !tt.wantErr && (err != nil || details.ID != "DU/2024/1") {
+				t.Errorf("Expected details after retries, got %+v, %v", details, err)
+			}
+			if got := requests.Load(); got != tt.wantSent {
+				t.Errorf("Expected %d requests, got %d", tt.wantSent, got)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	var requests atomic.Int32
+	server := newFlakyServer(1, http.StatusTooManyRequests, http.Header{"Retry-After": {"1"}}, &requests)
+	defer server.Close()
+
+	client := sejm.NewClientWithURL(server.URL, sejm.WithBackoff(time.Millisecond, 5*time.Second))
+
+	start := time.Now()
+	if _, err := client.GetActDetails(context.Background(), "DU/2024/1"); err != nil {
+		t.Fatalf("Failed to get act details: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("Expected the retry to wait for Retry-After, waited %v", elapsed)
+	}
+}
+
+func TestRetryStopsWithContext(t *testing.T) {
+	var requests atomic.Int32
+	server := newFlakyServer(10, http.StatusServiceUnavailable, nil, &requests)
+	defer server.Close()
+
+	client := sejm.NewClientWithURL(server.URL, sejm.WithMaxAttempts(10), sejm.WithBackoff(time.Second, time.Second))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := client.GetActDetails(ctx, "DU/2024/1")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("Expected 1 request before the deadline, got %d", got)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var requests atomic.Int32
+	server := newFlakyServer(2, http.StatusInternalServerError, nil, &requests)
+	defer server.Close()
+
+	cooldown := 50 * time.Millisecond
+	client := sejm.NewClientWithURL(server.URL, sejm.WithMaxAttempts(1), sejm.WithCircuitBreaker(2, cooldown))
+	ctx := context.Background()
+
+	for range 2 {
+		if _, err := client.GetActDetails(ctx, "DU/2024/1"); err == nil || errors.Is(err, sejm.ErrCircuitOpen) {
+			t.Fatalf("Expected an API error, got %v", err)
+		}
+	}
+
+	// The circuit is open: calls fail without reaching the API
+	if _, err := client.GetActDetails(ctx, "DU/2024/1"); !errors.Is(err, sejm.ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen, got %v", err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("Expected 2 requests while open, got %d", got)
+	}
+
+	// After the cooldown a successful trial call closes it again
+	time.Sleep(cooldown)
+	for range 2 {
+		if _, err := client.GetActDetails(ctx, "DU/2024/1"); err != nil {
+			t.Errorf("Expected the circuit to close, got %v", err)
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	var requests atomic.Int32
+	server := newFlakyServer(0, http.StatusOK, nil, &requests)
+	defer server.Close()
+
+	client := sejm.NewClientWithURL(server.URL, sejm.WithRateLimit(20, 1))
+
+	start := time.Now()
+	for range 3 {
+		if _, err := client.GetActDetails(context.Background(), "DU/2024/1"); err != nil {
+			t.Fatalf("Failed to get act details: %v", err)
+		}
+	}
+	// One token is available at once, the other two are refilled every 50ms
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("Expected requests to be spread by the rate limit, took %v", elapsed)
+	}
+}
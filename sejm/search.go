@@ -0,0 +1,148 @@
+package sejm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"ustawka/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Search page size limits
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 500
+)
+
+// ErrInvalidQuery is returned for search queries the ELI API would reject
+var ErrInvalidQuery = errors.New("invalid search query")
+
+// SearchQuery holds the filters of the ELI act search
+type SearchQuery struct {
+	Publisher string `json:"publisher,omitempty"`
+	Year      int    `json:"year,omitempty"`
+	Title     string `json:"title,omitempty"`
+	Keyword   string `json:"keyword,omitempty"`
+	Type      string `json:"type,omitempty"`
+	Status    string `json:"status,omitempty"`
+	DateFrom  string `json:"dateFrom,omitempty"`
+	DateTo    string `json:"dateTo,omitempty"`
+	InForce   *bool  `json:"inForce,omitempty"`
+	Offset    int    `json:"offset,omitempty"`
+	Limit     int    `json:"limit,omitempty"`
+}
+
+// SearchResult is a single page of acts matching a search query
+type SearchResult struct {
+	Items      []Act `json:"items"`
+	Offset     int   `json:"offset"`
+	TotalCount int   `json:"totalCount"`
+}
+
+// Normalize trims the filters and applies default paging; the title and keyword
+// keep the case they were typed in, as they are sent to the API
+func (q SearchQuery) Normalize() SearchQuery {
+	q.Publisher = strings.ToUpper(strings.TrimSpace(q.Publisher))
+	q.Title = strings.Join(strings.Fields(q.Title), " ")
+	q.Keyword = strings.Join(strings.Fields(q.Keyword), " ")
+	q.Type = strings.TrimSpace(q.Type)
+	q.Status = strings.TrimSpace(q.Status)
+	q.DateFrom = strings.TrimSpace(q.DateFrom)
+	q.DateTo = strings.TrimSpace(q.DateTo)
+	q.Offset = max(q.Offset, 0)
+	if q.Limit <= 0 {
+		q.Limit = defaultSearchLimit
+	}
+	q.Limit = min(q.Limit, maxSearchLimit)
+	return q
+}
+
+// Validate checks that the filters are well-formed
+func (q SearchQuery) Validate() error {
+	if q.Publisher != "" && !IsValidPublisher(q.Publisher) {
+		return fmt.Errorf("%w: unknown publisher %s", ErrInvalidQuery, q.Publisher)
+	}
+	for name, date := range map[string]string{"dateFrom": q.DateFrom, "dateTo": q.DateTo} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse(time.DateOnly, date); err != nil {
+			return fmt.Errorf("%w: %s must be YYYY-MM-DD", ErrInvalidQuery, name)
+		}
+	}
+	return nil
+}
+
+// Values encodes the filters as ELI search parameters
+func (q SearchQuery) Values() url.Values {
+	values := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+
+	set("publisher", q.Publisher)
+	if q.Year > 0 {
+		set("year", strconv.Itoa(q.Year))
+	}
+	set("title", q.Title)
+	set("keyword", q.Keyword)
+	set("type", q.Type)
+	set("status", q.Status)
+	set("dateFrom", q.DateFrom)
+	set("dateTo", q.DateTo)
+	if q.InForce != nil {
+		inForce := "0"
+		if *q.InForce {
+			inForce = "1"
+		}
+		set("inForce", inForce)
+	}
+	set("offset", strconv.Itoa(q.Offset))
+	set("limit", strconv.Itoa(q.Limit))
+	return values
+}
+
+// Key returns a stable cache key of the normalized query, case-folding the title
+// and keyword so that queries differing only in case share it
+func (q SearchQuery) Key() string {
+	q = q.Normalize()
+	q.Title = strings.ToLower(q.Title)
+	q.Keyword = strings.ToLower(q.Keyword)
+	return q.Values().Encode()
+}
+
+// SearchActs queries the ELI act search and returns one page of results
+func (c *Client) SearchActs(ctx context.Context, query SearchQuery) (_ *SearchResult, err error) {
+	query = query.Normalize()
+	ctx, span := tracer.Start(ctx, "sejm.SearchActs", trace.WithAttributes(attribute.String("search.query", query.Key())))
+	defer tracing.End(span, &err)
+
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	page, _, err := c.fetchActsPage(ctx, endpointSearch, c.baseURL+"/acts/search?"+query.Values().Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Search spans publishers, so each act carries its own in its ELI
+	for i := range page.Items {
+		if page.Items[i].Publisher == "" {
+			publisher, _, _ := strings.Cut(page.Items[i].ID, "/")
+			page.Items[i].Publisher = publisher
+		}
+	}
+
+	slog.Debug("Successfully searched acts", "query", query.Key(), "count", len(page.Items), "total", page.TotalCount)
+	return &SearchResult{Items: page.Items, Offset: query.Offset, TotalCount: page.TotalCount}, nil
+}
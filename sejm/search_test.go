@@ -0,0 +1,96 @@
+package sejm_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"ustawka/sejm"
+)
+
+func TestSearchActs(t *testing.T) {
+	var received url.Values
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		received = r.URL.Query()
+		_, _ = w.Write([]byte(`{"items":[{"ELI":"MP/2024/3","pos":3,"year":2024}],"offset":0,"totalCount":12}`))
+	}))
+	defer server.Close()
+
+	client := sejm.NewClientWithURL(server.URL)
+	inForce := true
+
+	result, err := client.SearchActs(context.Background(), sejm.SearchQuery{
+		Title:    "  Ochrona   Środowiska ",
+		Keyword:  "odpady",
+		Type:     "Obwieszczenie",
+		DateFrom: "2024-01-01",
+		InForce:  &inForce,
+	})
+	if err != nil {
+		t.Fatalf("Failed to search acts: %v", err)
+	}
+
+	if path != "/acts/search" {
+		t.Errorf("Expected path '/acts/search', got '%s'", path)
+	}
+	expected := map[string]string{
+		"title": "Ochrona Środowiska", "keyword": "odpady", "type": "Obwieszczenie",
+		"dateFrom": "2024-01-01", "inForce": "1", "offset": "0", "limit": "50",
+	}
+	for key, value := range expected {
+		if received.Get(key) != value {
+			t.Errorf("Expected %s=%q, got %q", key, value, received.Get(key))
+		}
+	}
+	if received.Has("dateTo") || received.Has("publisher") {
+		t.Errorf("Expected empty filters to be omitted, got %v", received)
+	}
+
+	if result.TotalCount != 12 || len(result.Items) != 1 {
+		t.Fatalf("Unexpected result: %+v", result)
+	}
+	if result.Items[0].Publisher != sejm.PublisherMP {
+		t.Errorf("Expected publisher derived from ELI, got '%s'", result.Items[0].Publisher)
+	}
+}
+
+func TestSearchActsInvalidQuery(t *testing.T) {
+	client := sejm.NewClientWithURL("http://127.0.0.1:0")
+
+	queries := []sejm.SearchQuery{
+		{Publisher: "XX"},
+		{DateFrom: "2024/01/01"},
+		{DateTo: "yesterday"},
+	}
+	for _, query := range queries {
+		if _, err := client.SearchActs(context.Background(), query); !errors.Is(err, sejm.ErrInvalidQuery) {
+			t.Errorf("Expected ErrInvalidQuery for %+v, got %v", query, err)
+		}
+	}
+}
+
+func TestSearchQueryKey(t *testing.T) {
+	a := sejm.SearchQuery{Title: "Prawo  Wodne", Publisher: "du"}
+	b := sejm.SearchQuery{Title: " prawo wodne", Publisher: "DU", Limit: 50}
+
+	if a.Key() != b.Key() {
+		t.Errorf("Expected equivalent queries to share a key, got %q and %q", a.Key(), b.Key())
+	}
+
+	c := sejm.SearchQuery{Title: "prawo wodne", Publisher: "DU", Offset: 50}
+	if a.Key() == c.Key() {
+		t.Error("Expected different pages to have different keys")
+	}
+}
+
+func TestSearchQueryNormalizeKeepsCase(t *testing.T) {
+	query := sejm.SearchQuery{Title: " Prawo  Wodne ", Keyword: "Ochrona   Środowiska"}.Normalize()
+
+	if query.Title != "Prawo Wodne" || query.Keyword != "Ochrona Środowiska" {
+		t.Errorf("Expected the typed case to be kept, got %q and %q", query.Title, query.Keyword)
+	}
+}
@@ -3,25 +3,58 @@ package sejm
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"os"
+	"slices"
 	"strconv"
+	"time"
+	"ustawka/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
 )
 
+// tracer starts the spans of Sejm API calls
+var tracer = otel.Tracer("ustawka/sejm")
+
 // baseURL is the base URL for the Sejm API
 var baseURL = "https://api.sejm.gov.pl/eli"
 
 // Client provides access to the Sejm API
 type Client struct {
-	httpClient *http.Client
-	baseURL    string
+	httpClient  *http.Client
+	baseURL     string
+	pageSize    int
+	maxPages    int
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	limiter     *rate.Limiter
+	breaker     *breaker
+	validators  ValidatorStore
 }
 
+// Publishers of legislative acts available in the ELI API
+const (
+	// PublisherDU is Dziennik Ustaw
+	PublisherDU = "DU"
+	// PublisherMP is Monitor Polski
+	PublisherMP = "MP"
+)
+
+// Publishers lists all supported publishers, the default one first
+var Publishers = []string{PublisherDU, PublisherMP}
+
 // Act represents basic information about a legislative act
 type Act struct {
 	ID        string `json:"ELI"`
+	Publisher string `json:"publisher"`
 	Title     string `json:"title"`
 	Status    string `json:"status"`
 	Published string `json:"promulgation"`
@@ -33,33 +66,35 @@ type Act struct {
 
 // ActDetails contains comprehensive information about a legislative act
 type ActDetails struct {
-	ID               string      `json:"ELI"`
-	Title            string      `json:"title"`
-	Status           string      `json:"status"`
-	Published        string      `json:"promulgation"`
-	Type             string      `json:"type"`
-	Address          string      `json:"address"`
-	DisplayAddress   string      `json:"displayAddress"`
-	Position         int         `json:"pos"`
-	Year             int         `json:"year"`
-	AnnouncementDate string      `json:"announcementDate"`
-	ChangeDate       string      `json:"changeDate"`
-	Publisher        string      `json:"publisher"`
-	TextHTML         bool        `json:"textHTML"`
-	TextPDF          bool        `json:"textPDF"`
-	Volume           int         `json:"volume"`
-	EntryIntoForce   string      `json:"entryIntoForce"`
-	InForce          string      `json:"inForce"`
-	Keywords         []string    `json:"keywords"`
-	KeywordsNames    []string    `json:"keywordsNames"`
-	ReleasedBy       []string    `json:"releasedBy"`
-	Texts            []Text      `json:"texts"`
-	References       References  `json:"references"`
-	AuthorizedBody   []string    `json:"authorizedBody"`
-	Directives       any `json:"directives"`
-	Obligated        []string    `json:"obligated"`
-	PreviousTitle    []string    `json:"previousTitle"`
-	Prints           any `json:"prints"`
+	ID               string     `json:"ELI"`
+	Title            string     `json:"title"`
+	Status           string     `json:"status"`
+	Published        string     `json:"promulgation"`
+	Type             string     `json:"type"`
+	Address          string     `json:"address"`
+	DisplayAddress   string     `json:"displayAddress"`
+	Position         int        `json:"pos"`
+	Year             int        `json:"year"`
+	AnnouncementDate string     `json:"announcementDate"`
+	ChangeDate       string     `json:"changeDate"`
+	Publisher        string     `json:"publisher"`
+	TextHTML         bool       `json:"textHTML"`
+	TextPDF          bool       `json:"textPDF"`
+	Volume           int        `json:"volume"`
+	EntryIntoForce   string     `json:"entryIntoForce"`
+	InForce          string     `json:"inForce"`
+	Keywords         []string   `json:"keywords"`
+	KeywordsNames    []string   `json:"keywordsNames"`
+	ReleasedBy       []string   `json:"releasedBy"`
+	Texts            []Text     `json:"texts"`
+	References       References `json:"references"`
+	AuthorizedBody   []string   `json:"authorizedBody"`
+	Directives       any        `json:"directives"`
+	Obligated        []string   `json:"obligated"`
+	PreviousTitle    []string   `json:"previousTitle"`
+	Prints           any        `json:"prints"`
+	// ETag is the validator the API sent with the details, if any
+	ETag string `json:"-"`
 }
 
 // Text represents a text version of an act
@@ -77,6 +112,7 @@ type Text struct {
 type References struct {
 	RepealedActs        []reference `json:"Akty uznane za uchylone"`
 	AmendingActs        []reference `json:"Akty zmieniające"`
+	AmendedActs         []reference `json:"Akty zmienione"`
 	LegalBasis          []reference `json:"Podstawa prawna"`
 	LegalBasisWithArt   []reference `json:"Podstawa prawna z art."`
 	TekstJednolity      []reference `json:"Tekst jednolity dla aktu"`
@@ -89,42 +125,232 @@ type reference struct {
 	Art  string `json:"art,omitempty"`
 }
 
-
 type apiResponse struct {
 	Items      []Act `json:"items"`
 	Offset     int   `json:"offset"`
 	TotalCount int   `json:"totalCount"`
 }
 
+// Default pagination settings for act listings
+const (
+	defaultPageSize = 500
+	defaultMaxPages = 50
+)
+
+// Endpoint names labelling the metrics of API requests
+const (
+	endpointActs    = "acts"
+	endpointSearch  = "search"
+	endpointDetails = "details"
+	endpointText    = "text"
+)
+
+var (
+	// ErrTooManyPages is returned when a listing does not fit in the configured page cap
+	ErrTooManyPages = errors.New("too many pages")
+	// ErrIncompleteListing is returned when the API stops returning acts before the reported total
+	ErrIncompleteListing = errors.New("incomplete listing")
+	// ErrActNotFound is returned when the API has no act with the requested ID
+	ErrActNotFound = errors.New("act not found")
+)
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithPageSize sets how many acts are requested per page
+func WithPageSize(size int) Option {
+	return func(c *Client) {
+		if size > 0 {
+			c.pageSize = size
+		}
+	}
+}
+
+// WithMaxPages caps how many pages are fetched for a single listing
+func WithMaxPages(pages int) Option {
+	return func(c *Client) {
+		if pages > 0 {
+			c.maxPages = pages
+		}
+	}
+}
+
 // NewClient creates a new Sejm API client
-func NewClient() *Client {
-	return &Client{
-		httpClient: &http.Client{},
-		baseURL:    baseURL,
+func NewClient(opts ...Option) *Client {
+	envOpts := []Option{
+		WithPageSize(intFromEnv("SEJM_PAGE_SIZE", defaultPageSize)),
+		WithMaxPages(intFromEnv("SEJM_MAX_PAGES", defaultMaxPages)),
+		WithMaxAttempts(intFromEnv("SEJM_MAX_ATTEMPTS", defaultMaxAttempts)),
+		WithBackoff(durationFromEnv("SEJM_RETRY_BACKOFF", defaultBackoff), defaultMaxBackoff),
+		WithRateLimit(float64(intFromEnv("SEJM_RATE_LIMIT", defaultRateLimit)), intFromEnv("SEJM_RATE_BURST", defaultRateBurst)),
+		WithCircuitBreaker(intFromEnv("SEJM_BREAKER_FAILURES", defaultBreakerFailures),
+			durationFromEnv("SEJM_BREAKER_COOLDOWN", defaultBreakerCooldown)),
 	}
+	return NewClientWithURL(baseURL, append(envOpts, opts...)...)
 }
 
 // NewClientWithURL creates a new client with a custom base URL (primarily for testing)
-func NewClientWithURL(baseURL string) *Client {
-	return &Client{
-		httpClient: &http.Client{},
-		baseURL:    baseURL,
+func NewClientWithURL(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		httpClient:  &http.Client{Transport: tracing.Transport(http.DefaultTransport)},
+		baseURL:     baseURL,
+		pageSize:    defaultPageSize,
+		maxPages:    defaultMaxPages,
+		maxAttempts: defaultMaxAttempts,
+		backoff:     defaultBackoff,
+		maxBackoff:  defaultMaxBackoff,
+		limiter:     rate.NewLimiter(defaultRateLimit, defaultRateBurst),
+		breaker:     newBreaker(defaultBreakerFailures, defaultBreakerCooldown),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// intFromEnv reads a positive integer from the environment, falling back to a default
+func intFromEnv(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		slog.Warn("Invalid "+name+" value, using default", "value", value, "default", fallback)
+		return fallback
+	}
+
+	slog.Info("Using custom "+name, "value", n)
+	return n
+}
+
+// IsValidPublisher reports whether the publisher is supported
+func IsValidPublisher(publisher string) bool {
+	return slices.Contains(Publishers, publisher)
+}
+
+// GetActs retrieves all acts of a publisher for a specific year, following pages
+// until the total reported by the API has been collected. With a validator store it
+// returns ErrNotModified when no page changed since the listing was last fetched.
+func (c *Client) GetActs(ctx context.Context, publisher string, year int) (_ []Act, err error) {
+	ctx, span := tracer.Start(ctx, "sejm.GetActs", trace.WithAttributes(
+		attribute.String("act.publisher", publisher), attribute.Int("act.year", year),
+	))
+	defer func() {
+		// An unchanged listing is a successful revalidation, not a failure
+		if errors.Is(err, ErrNotModified) {
+			span.SetAttributes(attribute.Bool("sejm.not_modified", true))
+			span.End()
+			return
+		}
+		tracing.End(span, &err)
+	}()
+
+	var first *apiResponse
+	var firstValidators Validators
+	if c.conditional(ctx) {
+		var err error
+		if first, firstValidators, err = c.probeListing(ctx, publisher, year); err != nil {
+			return nil, err
+		}
+	}
+
+	acts := make([]Act, 0)
+	pages := make(map[string]Validators)
+
+	for page := 0; ; page++ {
+		if page >= c.maxPages {
+			return nil, fmt.Errorf("%w: %s %d exceeds %d pages of %d acts",
+				ErrTooManyPages, publisher, year, c.maxPages, c.pageSize)
+		}
+
+		url := c.actsURL(publisher, year, len(acts))
+		resp, validators := first, firstValidators
+		if resp == nil {
+			var err error
+			if resp, validators, err = c.fetchActsPage(ctx, endpointActs, url, nil); err != nil {
+				return nil, err
+			}
+		}
+		first = nil
+		pages[url] = validators
+		acts = append(acts, resp.Items...)
+
+		if len(acts) >= resp.TotalCount {
+			break
+		}
+		if len(resp.Items) == 0 {
+			return nil, fmt.Errorf("%w: %s %d returned %d of %d acts",
+				ErrIncompleteListing, publisher, year, len(acts), resp.TotalCount)
+		}
 	}
+
+	for i := range acts {
+		if acts[i].Publisher == "" {
+			acts[i].Publisher = publisher
+		}
+	}
+
+	if c.validators != nil {
+		c.keepValidators(ctx, pages)
+	}
+
+	slog.Debug("Successfully fetched acts", "publisher", publisher, "year", year, "count", len(acts))
+	return acts, nil
 }
 
-// GetActs retrieves all acts for a specific year
-func (c *Client) GetActs(ctx context.Context, year int) ([]Act, error) {
-	url := fmt.Sprintf("%s/acts/DU/%d", c.baseURL, year)
+// actsURL returns the URL of a page of a publisher's year listing starting at offset
+func (c *Client) actsURL(publisher string, year, offset int) string {
+	return fmt.Sprintf("%s/acts/%s/%d?offset=%d&limit=%d", c.baseURL, publisher, year, offset, c.pageSize)
+}
+
+// probeListing sends conditional requests for the previously fetched pages of a listing,
+// returning ErrNotModified if none of them changed. A changed first page is returned
+// for the full fetch to reuse; a later one means the listing is fetched from the start.
+func (c *Client) probeListing(ctx context.Context, publisher string, year int) (*apiResponse, Validators, error) {
+	for page := 0; page < c.maxPages; page++ {
+		url := c.actsURL(publisher, year, page*c.pageSize)
+		validators := c.storedValidators(ctx, url)
+		if validators == nil {
+			if page == 0 {
+				return nil, Validators{}, nil
+			}
+			break
+		}
+
+		resp, fresh, err := c.fetchActsPage(ctx, endpointActs, url, validators)
+		if errors.Is(err, ErrNotModified) {
+			continue
+		}
+		if err != nil {
+			return nil, Validators{}, err
+		}
+		if page == 0 {
+			return resp, fresh, nil
+		}
+		return nil, Validators{}, nil
+	}
+
+	slog.Debug("Acts not modified", "publisher", publisher, "year", year)
+	return nil, Validators{}, ErrNotModified
+}
+
+// fetchActsPage retrieves a single page of an act listing along with its validators,
+// sending a conditional request if validators of a previous response are given;
+// an unchanged page yields ErrNotModified
+func (c *Client) fetchActsPage(ctx context.Context, endpoint, url string, cached *Validators) (*apiResponse, Validators, error) {
 	slog.Debug("Fetching acts", "url", url)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("error creating request: %w", err)
+		return nil, Validators{}, fmt.Errorf("error creating request: %w", err)
 	}
+	cached.setConditional(req)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req, endpoint)
 	if err != nil {
-		return nil, fmt.Errorf("error fetching acts: %w", err)
+		return nil, Validators{}, fmt.Errorf("error fetching acts: %w", err)
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
@@ -132,26 +358,31 @@ func (c *Client) GetActs(ctx context.Context, year int) ([]Act, error) {
 		}
 	}()
 
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		return nil, Validators{}, ErrNotModified
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status code: %d", resp.StatusCode)
+		return nil, Validators{}, fmt.Errorf("API request failed with status code: %d", resp.StatusCode)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("error reading response body: %w", err)
+		return nil, Validators{}, fmt.Errorf("error reading response body: %w", err)
 	}
 
 	var apiResponse apiResponse
 	if err := json.Unmarshal(body, &apiResponse); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %v", err)
+		return nil, Validators{}, fmt.Errorf("failed to parse response: %v", err)
 	}
 
-	slog.Debug("Successfully fetched acts", "year", year, "count", len(apiResponse.Items))
-	return apiResponse.Items, nil
+	return &apiResponse, responseValidators(resp), nil
 }
 
 // GetActDetails retrieves detailed information about a specific act
-func (c *Client) GetActDetails(ctx context.Context, id string) (*ActDetails, error) {
+func (c *Client) GetActDetails(ctx context.Context, id string) (_ *ActDetails, err error) {
+	ctx, span := tracer.Start(ctx, "sejm.GetActDetails", trace.WithAttributes(attribute.String("act.id", id)))
+	defer tracing.End(span, &err)
+
 	url := fmt.Sprintf("%s/acts/%s", c.baseURL, id)
 	slog.Debug("Fetching act details", "url", url)
 
@@ -160,7 +391,7 @@ func (c *Client) GetActDetails(ctx context.Context, id string) (*ActDetails, err
 		return nil, fmt.Errorf("error creating request: %w", err)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req, endpointDetails)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching act details: %w", err)
 	}
@@ -170,6 +401,9 @@ func (c *Client) GetActDetails(ctx context.Context, id string) (*ActDetails, err
 		}
 	}()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrActNotFound, id)
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("API request failed with status code: %d", resp.StatusCode)
 	}
@@ -183,6 +417,7 @@ func (c *Client) GetActDetails(ctx context.Context, id string) (*ActDetails, err
 	if err := json.Unmarshal(body, &details); err != nil {
 		return nil, fmt.Errorf("failed to parse act details: %v", err)
 	}
+	details.ETag = resp.Header.Get("ETag")
 
 	slog.Debug("Successfully fetched act details", "id", id)
 	return &details, nil
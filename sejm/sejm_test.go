@@ -3,8 +3,11 @@ package sejm_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 	"ustawka/sejm"
@@ -36,7 +39,7 @@ func TestGetActs(t *testing.T) {
 	client := sejm.NewClient()
 	ctx := context.Background()
 
-	acts, err := client.GetActs(ctx, 2021)
+	acts, err := client.GetActs(ctx, sejm.PublisherDU, 2021)
 	if err != nil {
 		t.Fatalf("Failed to get acts: %v", err)
 	}
@@ -98,7 +101,7 @@ func TestGetActDetails(t *testing.T) {
 	ctx := context.Background()
 
 	// First get a list of acts to have a valid ID
-	acts, err := client.GetActs(ctx, 2021)
+	acts, err := client.GetActs(ctx, sejm.PublisherDU, 2021)
 	if err != nil {
 		t.Fatalf("Failed to get acts: %v", err)
 	}
@@ -133,16 +136,126 @@ func TestGetActsError(t *testing.T) {
 	}))
 	defer server.Close()
 
-	// Create a client with the test server URL
-	client := sejm.NewClientWithURL(server.URL)
+	// Create a client with the test server URL, retrying without delay
+	client := sejm.NewClientWithURL(server.URL, sejm.WithBackoff(time.Millisecond, time.Millisecond))
 
 	// Test GetActs with error
-	_, err := client.GetActs(context.Background(), 2024)
+	_, err := client.GetActs(context.Background(), sejm.PublisherDU, 2024)
 	if err == nil {
 		t.Error("Expected error, got nil")
 	}
 }
 
+func TestGetActsPublisher(t *testing.T) {
+	var requestedPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedPath = r.URL.Path
+		_, _ = w.Write([]byte(`{"items":[{"ELI":"MP/2024/1","pos":1,"year":2024}],"totalCount":1}`))
+	}))
+	defer server.Close()
+
+	client := sejm.NewClientWithURL(server.URL)
+
+	acts, err := client.GetActs(context.Background(), sejm.PublisherMP, 2024)
+	if err != nil {
+		t.Fatalf("Failed to get acts: %v", err)
+	}
+	if requestedPath != "/acts/MP/2024" {
+		t.Errorf("Expected path '/acts/MP/2024', got '%s'", requestedPath)
+	}
+	if len(acts) != 1 || acts[0].Publisher != sejm.PublisherMP {
+		t.Errorf("Expected one act published in MP, got %+v", acts)
+	}
+}
+
+// newPagedServer serves a listing of total acts honoring offset and limit parameters
+func newPagedServer(t *testing.T, total int, requests *int) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+		items := make([]sejm.Act, 0)
+		for pos := offset + 1; pos <= total && pos <= offset+limit; pos++ {
+			items = append(items, sejm.Act{ID: fmt.Sprintf("DU/2024/%d", pos), Position: pos, Year: 2024})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"items": items, "offset": offset, "totalCount": total})
+	}))
+}
+
+func TestGetActsPagination(t *testing.T) {
+	requests := 0
+	server := newPagedServer(t, 5, &requests)
+	defer server.Close()
+
+	client := sejm.NewClientWithURL(server.URL, sejm.WithPageSize(2))
+
+	acts, err := client.GetActs(context.Background(), sejm.PublisherDU, 2024)
+	if err != nil {
+		t.Fatalf("Failed to get acts: %v", err)
+	}
+	if len(acts) != 5 {
+		t.Fatalf("Expected 5 acts, got %d", len(acts))
+	}
+	for i, act := range acts {
+		if act.Position != i+1 {
+			t.Errorf("Act at index %d has position %d", i, act.Position)
+		}
+	}
+	if requests != 3 {
+		t.Errorf("Expected 3 page requests, got %d", requests)
+	}
+}
+
+func TestGetActsPageCap(t *testing.T) {
+	requests := 0
+	server := newPagedServer(t, 5, &requests)
+	defer server.Close()
+
+	client := sejm.NewClientWithURL(server.URL, sejm.WithPageSize(2), sejm.WithMaxPages(2))
+
+	acts, err := client.GetActs(context.Background(), sejm.PublisherDU, 2024)
+	if !errors.Is(err, sejm.ErrTooManyPages) {
+		t.Fatalf("Expected ErrTooManyPages, got %v", err)
+	}
+	if acts != nil {
+		t.Errorf("Expected no acts on a truncated listing, got %d", len(acts))
+	}
+	if requests != 2 {
+		t.Errorf("Expected 2 page requests, got %d", requests)
+	}
+}
+
+func TestGetActsIncompleteListing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("offset") == "0" {
+			_, _ = w.Write([]byte(`{"items":[{"ELI":"DU/2024/1","pos":1,"year":2024}],"totalCount":3}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"items":[],"totalCount":3}`))
+	}))
+	defer server.Close()
+
+	client := sejm.NewClientWithURL(server.URL)
+
+	_, err := client.GetActs(context.Background(), sejm.PublisherDU, 2024)
+	if !errors.Is(err, sejm.ErrIncompleteListing) {
+		t.Fatalf("Expected ErrIncompleteListing, got %v", err)
+	}
+}
+
+func TestIsValidPublisher(t *testing.T) {
+	for _, publisher := range []string{sejm.PublisherDU, sejm.PublisherMP} {
+		if !sejm.IsValidPublisher(publisher) {
+			t.Errorf("Expected '%s' to be a valid publisher", publisher)
+		}
+	}
+	if sejm.IsValidPublisher("XX") {
+		t.Error("Expected 'XX' to be an invalid publisher")
+	}
+}
+
 func TestGetActDetailsError(t *testing.T) {
 	// Create a test server that returns an error
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
@@ -150,8 +263,8 @@ func TestGetActDetailsError(t *testing.T) {
 	}))
 	defer server.Close()
 
-	// Create a client with the test server URL
-	client := sejm.NewClientWithURL(server.URL)
+	// Create a client with the test server URL, retrying without delay
+	client := sejm.NewClientWithURL(server.URL, sejm.WithBackoff(time.Millisecond, time.Millisecond))
 
 	// Test GetActDetails with error
 	_, err := client.GetActDetails(context.Background(), "DU/2024/1")
@@ -160,6 +273,19 @@ func TestGetActDetailsError(t *testing.T) {
 	}
 }
 
+func TestGetActDetailsNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := sejm.NewClientWithURL(server.URL)
+	_, err := client.GetActDetails(context.Background(), "DU/2024/99999")
+	if !errors.Is(err, sejm.ErrActNotFound) {
+		t.Errorf("Expected ErrActNotFound, got %v", err)
+	}
+}
+
 func TestGetYearString(t *testing.T) {
 	act := sejm.Act{Year: 2024}
 	if act.GetYearString() != "2024" {
@@ -188,7 +314,7 @@ func testRealAPIForYearBasic(ctx context.Context, t *testing.T, client *sejm.Cli
 func fetchAndValidateActs(ctx context.Context, t *testing.T, client *sejm.Client, year int) []sejm.Act {
 	t.Helper()
 	
-	acts, err := client.GetActs(ctx, year)
+	acts, err := client.GetActs(ctx, sejm.PublisherDU, year)
 	if err != nil {
 		t.Fatalf("Failed to fetch acts from real API for %d: %v", year, err)
 	}
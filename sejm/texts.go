@@ -0,0 +1,102 @@
+package sejm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"ustawka/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Names of act texts available in the ELI API
+const (
+	// TextPDF is the PDF text of an act
+	TextPDF = "text.pdf"
+	// TextHTML is the HTML text of an act
+	TextHTML = "text.html"
+)
+
+var (
+	// ErrTextNotFound is returned when the API has no such text for an act
+	ErrTextNotFound = errors.New("text not found")
+	// ErrInvalidTextName is returned for text names outside the ELI text endpoints
+	ErrInvalidTextName = errors.New("invalid text name")
+)
+
+// textFilePattern matches per-file texts listed in ActDetails.Texts, e.g. text/O/D20240001L.pdf
+var textFilePattern = regexp.MustCompile(`^text/[OITU]/[A-Za-z0-9_.-]+$`)
+
+// Document is a downloaded act text
+type Document struct {
+	ContentType string
+	ETag        string
+	Data        []byte
+}
+
+// TextFileName returns the name of a file listed in ActDetails.Texts, as accepted by GetActText
+func TextFileName(text Text) string {
+	return "text/" + text.Type + "/" + text.FileName
+}
+
+// IsValidTextName reports whether a text name can be requested from the API
+func IsValidTextName(name string) bool {
+	return name == TextPDF || name == TextHTML || textFilePattern.MatchString(name)
+}
+
+// GetActText downloads a text of an act: TextPDF, TextHTML or a file named with TextFileName
+func (c *Client) GetActText(ctx context.Context, id, name string) (_ *Document, err error) {
+	ctx, span := tracer.Start(ctx, "sejm.GetActText", trace.WithAttributes(
+		attribute.String("act.id", id), attribute.String("act.text", name),
+	))
+	defer tracing.End(span, &err)
+
+	if !IsValidTextName(name) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTextName, name)
+	}
+
+	url := fmt.Sprintf("%s/acts/%s/%s", c.baseURL, id, name)
+	slog.Debug("Fetching act text", "url", url)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := c.do(req, endpointText)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching act text: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			slog.Error("Error closing response body", "error", err)
+		}
+	}()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s/%s", ErrTextNotFound, id, name)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API request failed with status code: %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(name))
+	}
+
+	slog.Debug("Successfully fetched act text", "id", id, "name", name, "size", len(data))
+	return &Document{ContentType: contentType, ETag: resp.Header.Get("ETag"), Data: data}, nil
+}
@@ -0,0 +1,52 @@
+package sejm_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"ustawka/sejm"
+)
+
+func TestGetActText(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/acts/DU/2024/1/text.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			_, _ = w.Write([]byte("%PDF-1.7"))
+		case "/acts/DU/2024/1/text/O/D20240001L.pdf":
+			_, _ = w.Write([]byte("%PDF-1.4"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := sejm.NewClientWithURL(server.URL)
+	ctx := context.Background()
+
+	doc, err := client.GetActText(ctx, "DU/2024/1", sejm.TextPDF)
+	if err != nil {
+		t.Fatalf("Failed to get act text: %v", err)
+	}
+	if doc.ContentType != "application/pdf" || string(doc.Data) != "%PDF-1.7" {
+		t.Errorf("Unexpected document: %s %q", doc.ContentType, doc.Data)
+	}
+
+	name := sejm.TextFileName(sejm.Text{FileName: "D20240001L.pdf", Type: "O"})
+	doc, err = client.GetActText(ctx, "DU/2024/1", name)
+	if err != nil {
+		t.Fatalf("Failed to get act text file: %v", err)
+	}
+	if string(doc.Data) != "%PDF-1.4" {
+		t.Errorf("Unexpected document data: %q", doc.Data)
+	}
+
+	if _, err := client.GetActText(ctx, "DU/2024/1", sejm.TextHTML); !errors.Is(err, sejm.ErrTextNotFound) {
+		t.Errorf("Expected ErrTextNotFound, got %v", err)
+	}
+	if _, err := client.GetActText(ctx, "DU/2024/1", "text/O/../../2023/1/text.pdf"); !errors.Is(err, sejm.ErrInvalidTextName) {
+		t.Errorf("Expected ErrInvalidTextName, got %v", err)
+	}
+}
@@ -1,24 +1,40 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"html/template"
 	"log/slog"
 	"net/http"
 	"os"
-	"ustawka/db"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+	"ustawka/blob"
 	"ustawka/handlers"
+	"ustawka/metrics"
+	"ustawka/notify"
 	"ustawka/sejm"
 	"ustawka/service"
+	"ustawka/tracing"
+	"ustawka/worker"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 )
 
+// shutdownTimeout bounds how long in-flight requests may finish on shutdown
+const shutdownTimeout = 10 * time.Second
+
 // Server represents the HTTP server instance
 type Server struct {
-	router  *chi.Mux
-	handler *handlers.Handler
+	router     *chi.Mux
+	handler    *handlers.Handler
+	scheduler  *worker.Scheduler
+	actService *service.ActService
+	webhooks   *notify.Webhooks
 }
 
 // NewServer creates a new server instance with all dependencies
@@ -28,24 +44,55 @@ func NewServer() (*Server, error) {
 		"templates/base.html",
 		"templates/board.html",
 		"templates/act_details.html",
+		"templates/search_results.html",
+		"templates/act_text.html",
+		"templates/act_diff.html",
+		"templates/act_graph.html",
 	))
 
-	// Create SEJM client
-	sejmClient := sejm.NewClient()
-
 	// Initialize database
-	dbPath := os.Getenv("SEJM_DB_PATH")
-	if dbPath == "" {
-		dbPath = "sejm.db"
-	}
-	database, err := db.New(dbPath)
+	database, err := NewStore()
 	if err != nil {
 		return nil, err
 	}
 
+	// Create SEJM client revalidating listings with validators kept in the database
+	sejmClient := sejm.NewClient(sejm.WithValidatorStore(database))
+
 	// Create service layer with the concrete client and database
 	actService := service.NewActService(sejmClient, database)
 
+	// Keep downloaded act texts next to the SQLite database; replicas sharing a
+	// PostgreSQL database download the texts they are missing
+	blobs, err := blob.NewStore(TextDir())
+	if err != nil {
+		return nil, err
+	}
+	actService.SetBlobStore(blobs)
+
+	// Offline, only cached data such as an imported snapshot is served and nothing is synced
+	syncConfig := worker.ConfigFromEnv(sejm.Publishers, service.FirstYear)
+	if Offline() {
+		snapshot, err := database.GetSnapshot(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		var snapshotAt time.Time
+		if snapshot != nil {
+			snapshotAt = snapshot.CreatedAt
+		}
+		actService.SetOffline(snapshotAt)
+		syncConfig.Interval = 0
+		slog.Info("Running offline", "snapshot", snapshotAt)
+	}
+
+	// Notify watchers about changes found by background refreshes
+	webhooks := notify.NewWebhooks(database)
+	actService.SetNotifier(webhooks)
+
+	// Create background sync scheduler
+	scheduler := worker.NewScheduler(actService, syncConfig)
+
 	// Create handler
 	handler := handlers.NewHandler(templates, actService)
 
@@ -53,7 +100,9 @@ func NewServer() (*Server, error) {
 	r := chi.NewRouter()
 
 	// Middleware
+	r.Use(tracing.Middleware)
 	r.Use(middleware.Logger)
+	r.Use(metrics.Middleware)
 	r.Use(middleware.Recoverer)
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"*"},
@@ -71,19 +120,125 @@ func NewServer() (*Server, error) {
 	// Routes
 	r.Get("/", handler.Home)
 	r.Get("/api/years", handler.HandleYears)
-	r.Get("/api/acts/DU/{year}", handler.HandleActs)
-	r.Get("/api/acts/DU/{year}/{position}", handler.HandleActDetails)
-	r.Get("/acts/DU/{year}/{position}", handler.ViewActDetails)
+	r.Get("/api/search", handler.HandleSearch)
+	r.Get("/api/acts/search", handler.HandleActSearch)
+	r.Get("/api/acts/{publisher}/{year}", handler.HandleActs)
+	r.Get("/api/acts/{publisher}/{year}/{position}", handler.HandleActDetails)
+	r.Get("/api/acts/{publisher}/{year}/{position}/history", handler.HandleActHistory)
+	r.Get("/acts/{publisher}/{year}/{position}", handler.ViewActDetails)
+	r.Get("/api/acts/{address}", handler.RedirectActAddress)
+	r.Get("/acts/{address}", handler.RedirectActAddress)
+	r.Get("/api/acts/{publisher}/{year}/{position}/text", handler.HandleActDocument)
+	r.Get("/acts/{publisher}/{year}/{position}/text", handler.ViewActText)
+	r.Get("/api/acts/{publisher}/{year}/{position}/diff", handler.HandleActDiff)
+	r.Get("/api/acts/{publisher}/{year}/{position}/graph", handler.HandleActGraph)
+	r.Get("/api/acts/{publisher}/{year}/{position}/incoming", handler.HandleActIncoming)
+	r.Get("/acts/{publisher}/{year}/{position}/graph", handler.ViewActGraph)
+	r.Get("/acts/{publisher}/{year}/{position}/diff", handler.ViewActDiff)
+	r.Get("/acts/{publisher}/{year}/{position}/text.pdf", handler.HandleActText)
+	r.Get("/acts/{publisher}/{year}/{position}/text.html", handler.HandleActText)
+	r.Get("/acts/{publisher}/{year}/{position}/text/{type}/{fileName}", handler.HandleActText)
+	r.Get("/feeds/keyword/{keyword}.atom", handler.HandleKeywordFeed)
+	r.Get("/feeds/status/{status}.atom", handler.HandleStatusFeed)
+	r.Get("/feeds/{publisher}/{year}.atom", handler.HandleYearFeed)
 	r.Get("/metrics", handlers.MetricsHandler)
 
+	// Watches and cache invalidation need the admin token
+	r.Group(func(r chi.Router) {
+		r.Use(handlers.AdminOnly(os.Getenv("USTAWKA_ADMIN_TOKEN")))
+		r.Get("/api/watches", handler.HandleListWatches)
+		r.Post("/api/watches", handler.HandleCreateWatch)
+		r.Delete("/api/watches/{id}", handler.HandleDeleteWatch)
+		r.Post("/api/admin/cache/invalidate", handler.HandleInvalidateCache)
+	})
+
 	return &Server{
-		router:  r,
-		handler: handler,
+		router:     r,
+		handler:    handler,
+		scheduler:  scheduler,
+		actService: actService,
+		webhooks:   webhooks,
 	}, nil
 }
 
-// Start starts the HTTP server on the specified port
-func (s *Server) Start(port string) error {
-	slog.Info("Server starting", "port", port)
-	return http.ListenAndServe(":"+port, s.router)
+// DBPath returns the location of the SQLite database, SEJM_DB_PATH or sejm.db by default
+func DBPath() string {
+	if dbPath := os.Getenv("SEJM_DB_PATH"); dbPath != "" {
+		return dbPath
+	}
+	return "sejm.db"
+}
+
+// TextDir returns the directory of downloaded act texts, SEJM_TEXT_DIR or texts next to
+// the SQLite database by default
+func TextDir() string {
+	if textDir := os.Getenv("SEJM_TEXT_DIR"); textDir != "" {
+		return textDir
+	}
+	return filepath.Join(filepath.Dir(DBPath()), "texts")
+}
+
+// Offline reports whether USTAWKA_OFFLINE asks to serve cached data without calling the API
+func Offline() bool {
+	value := os.Getenv("USTAWKA_OFFLINE")
+	if value == "" {
+		return false
+	}
+	offline, err := strconv.ParseBool(value)
+	if err != nil {
+		slog.Warn("Invalid USTAWKA_OFFLINE value, staying online", "value", value)
+		return false
+	}
+	return offline
+}
+
+// Start starts the HTTP server, the background sync and webhook deliveries on the specified
+// port, shutting them down gracefully once the context is cancelled
+func (s *Server) Start(ctx context.Context, port string) error {
+	httpServer := &http.Server{
+		Addr:              ":" + port,
+		Handler:           s.router,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.scheduler.Run(ctx)
+	}()
+
+	// Deliveries outlive the sync so that changes found by its last refreshes are queued
+	deliveryCtx, stopDeliveries := context.WithCancel(context.WithoutCancel(ctx))
+	deliveriesDone := make(chan struct{})
+	go func() {
+		defer close(deliveriesDone)
+		s.webhooks.Run(deliveryCtx)
+	}()
+
+	errCh := make(chan error, 1)
+	go func() {
+		slog.Info("Server starting", "port", port)
+		errCh <- httpServer.ListenAndServe()
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		slog.Info("Server shutting down")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		err = httpServer.Shutdown(shutdownCtx)
+	}
+
+	wg.Wait()
+	// Let background refreshes of stale cache entries store their results
+	s.actService.Wait()
+	stopDeliveries()
+	<-deliveriesDone
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
 }
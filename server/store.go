@@ -0,0 +1,42 @@
+package server
+
+import (
+	"context"
+	"os"
+	"ustawka/db"
+	"ustawka/db/postgres"
+	"ustawka/notify"
+	"ustawka/sejm"
+	"ustawka/service"
+	"ustawka/snapshot"
+)
+
+// Store is the database the server keeps its cache in, SQLite or PostgreSQL
+type Store interface {
+	service.Database
+	notify.Store
+	sejm.ValidatorStore
+	snapshot.Source
+	snapshot.Target
+	GetSnapshot(ctx context.Context) (*db.Snapshot, error)
+	Migrations(ctx context.Context) ([]db.Migration, error)
+	Migrate(ctx context.Context) (int, error)
+	Close() error
+}
+
+// NewStore opens the database, applying pending schema migrations: the PostgreSQL
+// database at USTAWKA_DB_URL if set, shared by replicas, or the SQLite database at DBPath
+func NewStore() (Store, error) {
+	if url := os.Getenv("USTAWKA_DB_URL"); url != "" {
+		return postgres.New(url)
+	}
+	return db.New(DBPath())
+}
+
+// OpenStore opens the database selected like in NewStore without changing its schema
+func OpenStore() (Store, error) {
+	if url := os.Getenv("USTAWKA_DB_URL"); url != "" {
+		return postgres.Open(url)
+	}
+	return db.Open(DBPath())
+}
@@ -8,31 +8,73 @@ import (
 	"os"
 	"strings"
 	"time"
+	"ustawka/acttext"
+	"ustawka/db"
 	"ustawka/metrics"
 	"ustawka/sejm"
+	"ustawka/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
 )
 
+// tracer starts the spans of service operations
+var tracer = otel.Tracer("ustawka/service")
+
 // SejmClient defines the interface for Sejm API operations
 type SejmClient interface {
-	GetActs(ctx context.Context, year int) ([]sejm.Act, error)
+	GetActs(ctx context.Context, publisher string, year int) ([]sejm.Act, error)
 	GetActDetails(ctx context.Context, actID string) (*sejm.ActDetails, error)
+	SearchActs(ctx context.Context, query sejm.SearchQuery) (*sejm.SearchResult, error)
+	GetActText(ctx context.Context, actID, name string) (*sejm.Document, error)
 }
 
 // Database defines the interface for database operations
 type Database interface {
-	GetActs(ctx context.Context, year int) ([]sejm.Act, error)
-	StoreActs(ctx context.Context, year int, acts []sejm.Act) error
-	GetActDetails(ctx context.Context, actID string) (*sejm.ActDetails, error)
+	GetActs(ctx context.Context, publisher string, year int) ([]sejm.Act, error)
+	StoreActs(ctx context.Context, publisher string, year int, acts []sejm.Act) ([]db.ActChange, error)
+	GetActDetails(ctx context.Context, id sejm.ELI) (*sejm.ActDetails, error)
 	StoreActDetails(ctx context.Context, details *sejm.ActDetails) error
-	GetCacheAge(ctx context.Context, year int) (time.Duration, error)
+	DeleteActDetails(ctx context.Context, id string) error
+	GetCacheEntry(ctx context.Context, kind, key string) (*db.CacheEntry, error)
+	StoreCacheEntry(ctx context.Context, entry db.CacheEntry) error
+	TouchCacheEntry(ctx context.Context, kind, key string) (bool, error)
+	InvalidateCacheEntries(ctx context.Context, prefix string) (int64, error)
+	SearchActs(ctx context.Context, query string, limit int) ([]sejm.Act, error)
+	GetSearchResult(ctx context.Context, key string) (*sejm.SearchResult, time.Duration, error)
+	StoreSearchResult(ctx context.Context, key string, result *sejm.SearchResult) error
+	GetActChanges(ctx context.Context, id sejm.ELI) ([]db.ActChange, error)
+	AddWatch(ctx context.Context, watch db.Watch) (*db.Watch, error)
+	DeleteWatch(ctx context.Context, id int64) error
+	ListWatches(ctx context.Context) ([]db.Watch, error)
+	GetFeedEntries(ctx context.Context, filter db.FeedFilter, limit int) ([]db.FeedEntry, error)
+	GetActText(ctx context.Context, id sejm.ELI, name string) (*db.ActText, error)
+	StoreActText(ctx context.Context, text db.ActText) error
+	GetActTextVersions(ctx context.Context, id sejm.ELI, name string) ([]db.ActTextVersion, error)
+	GetActDocument(ctx context.Context, hash string) (*acttext.Document, error)
+	GetActLinks(ctx context.Context, sources []string) ([]db.ActLink, error)
+	GetIncomingLinks(ctx context.Context, targets []string) ([]db.ActLink, error)
+	GetActTitles(ctx context.Context, ids []string) (map[string]string, error)
+	StoreActDocument(ctx context.Context, hash string, doc *acttext.Document) error
 }
 
 // ActService provides business logic for legislative acts
 type ActService struct {
-	sejmClient SejmClient
-	db         Database
-	timeout    time.Duration
-	cacheTTL   time.Duration
+	sejmClient  SejmClient
+	db          Database
+	timeout     time.Duration
+	cacheTTL    time.Duration
+	negativeTTL time.Duration
+	ttls        map[string]time.Duration
+	views       *recentViews
+	notifier    Notifier
+	blobs       BlobStore
+	revalidate  *revalidations
+	flights     singleflight.Group
+	offline     bool
+	snapshotAt  time.Time
 }
 
 // BoardData organizes acts by status for the Kanban board view
@@ -40,12 +82,39 @@ type BoardData struct {
 	Obowiazujace []sejm.Act
 	Pending      []sejm.Act
 	Uchylone     []sejm.Act
+	// Stale is set when the acts come from an expired cache being refreshed in the background
+	Stale     bool      `json:"-"`
+	UpdatedAt time.Time `json:"-"`
+	// Offline is set when the service runs offline, from a snapshot exported at SnapshotAt
+	Offline    bool      `json:"-"`
+	SnapshotAt time.Time `json:"-"`
+}
+
+// SearchResults holds cached acts matching a full-text query, best matches first
+type SearchResults struct {
+	Query string
+	Acts  []sejm.Act
 }
 
+// FirstYear is the earliest year of acts offered by the service
+const FirstYear = 2021
+
 // Default values
 const (
-	defaultTimeout  = 5 * time.Second
-	defaultCacheTTL = 24 * time.Hour
+	defaultTimeout     = 5 * time.Second
+	defaultCacheTTL    = 24 * time.Hour
+	defaultNegativeTTL = time.Hour
+	defaultDetailsTTL  = 7 * 24 * time.Hour
+	defaultTextsTTL    = 30 * 24 * time.Hour
+	defaultSearchLimit = 50
+	maxSearchLimit     = 200
+)
+
+var (
+	// ErrUnknownPublisher is returned for publishers not supported by the ELI API
+	ErrUnknownPublisher = errors.New("unknown publisher")
+	// ErrEmptyQuery is returned when a search has nothing to look for
+	ErrEmptyQuery = errors.New("empty search query")
 )
 
 // NewActService creates a new ActService with configured dependencies
@@ -72,34 +141,76 @@ func NewActService(client SejmClient, database Database) *ActService {
 		}
 	}
 
-	return &ActService{
-		sejmClient: client,
-		db:         database,
-		timeout:    timeout,
-		cacheTTL:   cacheTTL,
+	s := NewActServiceWithConfig(client, database, timeout, cacheTTL)
+
+	// Configure how long empty listings and missing acts or texts are remembered
+	if ttlStr := os.Getenv("SEJM_NEGATIVE_CACHE_TTL"); ttlStr != "" {
+		if duration, err := time.ParseDuration(ttlStr); err == nil {
+			s.negativeTTL = duration
+			slog.Info("Using custom negative cache TTL", "ttl", duration)
+		} else {
+			slog.Warn("Invalid SEJM_NEGATIVE_CACHE_TTL value, using default", "value", ttlStr, "default", defaultNegativeTTL)
+		}
+	}
+
+	// Configure how long cached act details stay fresh
+	if ttlStr := os.Getenv("SEJM_DETAILS_TTL"); ttlStr != "" {
+		if duration, err := time.ParseDuration(ttlStr); err == nil {
+			s.ttls[db.CacheKindDetails] = duration
+			slog.Info("Using custom details TTL", "ttl", duration)
+		} else {
+			slog.Warn("Invalid SEJM_DETAILS_TTL value, using default", "value", ttlStr, "default", defaultDetailsTTL)
+		}
+	}
+
+	// Configure how long downloaded act texts stay fresh
+	if ttlStr := os.Getenv("SEJM_TEXTS_TTL"); ttlStr != "" {
+		if duration, err := time.ParseDuration(ttlStr); err == nil {
+			s.ttls[db.CacheKindTexts] = duration
+			slog.Info("Using custom texts TTL", "ttl", duration)
+		} else {
+			slog.Warn("Invalid SEJM_TEXTS_TTL value, using default", "value", ttlStr, "default", defaultTextsTTL)
+		}
 	}
+
+	return s
 }
 
 // NewActServiceWithConfig creates a new ActService with explicit configuration (primarily for testing)
 func NewActServiceWithConfig(client SejmClient, database Database, timeout, cacheTTL time.Duration) *ActService {
 	return &ActService{
-		sejmClient: client,
-		db:         database,
-		timeout:    timeout,
-		cacheTTL:   cacheTTL,
+		sejmClient:  client,
+		db:          database,
+		timeout:     timeout,
+		cacheTTL:    cacheTTL,
+		negativeTTL: defaultNegativeTTL,
+		ttls: map[string]time.Duration{
+			db.CacheKindActs:    cacheTTL,
+			db.CacheKindDetails: defaultDetailsTTL,
+			db.CacheKindTexts:   defaultTextsTTL,
+		},
+		views:      newRecentViews(),
+		revalidate: newRevalidations(),
 	}
 }
 
-// GetAvailableYears returns a list of years that have acts available
-func (s *ActService) GetAvailableYears(ctx context.Context) ([]int, error) {
+// GetAvailableYears returns a list of years that have acts of the publisher available
+func (s *ActService) GetAvailableYears(ctx context.Context, publisher string) (_ []int, err error) {
+	ctx, span := tracer.Start(ctx, "service.GetAvailableYears", trace.WithAttributes(attribute.String("act.publisher", publisher)))
+	defer tracing.End(span, &err)
+
 	metrics.IncrementAPI()
+	if !sejm.IsValidPublisher(publisher) {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPublisher, publisher)
+	}
+
 	currentYear := time.Now().Year()
 	years := make([]int, 0)
 	var lastErr error
 
 	// Check each year from 2021 to current year
-	for year := 2021; year <= currentYear; year++ {
-		acts, err := s.getActsForYear(ctx, year)
+	for year := FirstYear; year <= currentYear; year++ {
+		acts, _, err := s.getActsForYear(ctx, publisher, year)
 		if err != nil {
 			lastErr = err
 			continue
@@ -113,32 +224,43 @@ func (s *ActService) GetAvailableYears(ctx context.Context) ([]int, error) {
 	return validateYearResults(years, lastErr)
 }
 
-// getActsForYear retrieves acts for a specific year from cache or API
-func (s *ActService) getActsForYear(ctx context.Context, year int) ([]sejm.Act, error) {
+// getActsForYear retrieves acts of a publisher for a specific year from cache or API,
+// along with the cache entry they come from, nil when just fetched. Expired cached
+// acts are returned at once and refreshed in the background.
+func (s *ActService) getActsForYear(ctx context.Context, publisher string, year int) ([]sejm.Act, *db.CacheEntry, error) {
 	// Check cache first
-	cacheAge, err := s.db.GetCacheAge(ctx, year)
+	entry, err := s.db.GetCacheEntry(ctx, db.CacheKindActs, db.YearKey(publisher, year))
 	if err != nil {
-		slog.Error("Error checking cache age", "year", year, "error", err)
+		slog.Error("Error checking cache age", "publisher", publisher, "year", year, "error", err)
 		// Continue to fetch from API if cache check fails
 	}
+	if entry == nil {
+		acts, err := s.fetchAndCacheActs(ctx, publisher, year)
+		return acts, nil, err
+	}
 
-	var acts []sejm.Act
-	if err == nil && cacheAge < s.cacheTTL {
-		// Use cached data
-		acts, err = s.db.GetActs(ctx, year)
+	// Years without acts are remembered as such for the negative TTL
+	acts := []sejm.Act{}
+	if entry.Outcome != db.OutcomeEmpty {
+		acts, err = s.db.GetActs(ctx, publisher, year)
 		if err != nil {
-			slog.Error("Error reading from cache", "year", year, "error", err)
+			slog.Error("Error reading from cache", "publisher", publisher, "year", year, "error", err)
+		}
+		if len(acts) == 0 {
 			// Continue to fetch from API if cache read fails
-		} else {
-			metrics.IncrementCacheHit()
+			acts, err := s.fetchAndCacheActs(ctx, publisher, year)
+			return acts, nil, err
 		}
 	}
 
-	if len(acts) == 0 {
-		return s.fetchAndCacheActs(ctx, year)
+	if s.fresh(entry) {
+		metrics.IncrementCacheHit(metrics.CacheActs)
+		return acts, entry, nil
 	}
 
-	return acts, nil
+	metrics.IncrementCacheStale(metrics.CacheActs)
+	s.revalidateYear(ctx, publisher, year)
+	return acts, entry, nil
 }
 
 // validateYearResults validates and returns the final year results
@@ -157,52 +279,121 @@ func validateYearResults(years []int, lastErr error) ([]int, error) {
 }
 
 // fetchAndCacheActs fetches acts from API and stores them in cache
-func (s *ActService) fetchAndCacheActs(ctx context.Context, year int) ([]sejm.Act, error) {
-	metrics.IncrementCacheMiss()
-	// Create a new context with timeout only for the API call
+func (s *ActService) fetchAndCacheActs(ctx context.Context, publisher string, year int) ([]sejm.Act, error) {
+	metrics.IncrementCacheMiss(metrics.CacheActs)
+	return s.loadActs(ctx, publisher, year)
+}
+
+// loadActs fetches acts from API and replaces them in cache, sharing the fetch
+// between concurrent callers of the same year
+func (s *ActService) loadActs(ctx context.Context, publisher string, year int) ([]sejm.Act, error) {
+	return coalesce(ctx, &s.flights, metrics.CacheActs, actsKey(publisher, year), func(ctx context.Context) ([]sejm.Act, error) {
+		return s.fetchActs(ctx, publisher, year)
+	})
+}
+
+// fetchActs fetches acts from API and replaces them in cache
+func (s *ActService) fetchActs(ctx context.Context, publisher string, year int) ([]sejm.Act, error) {
+	if s.offline {
+		return nil, ErrOffline
+	}
+
+	// Create a new context with timeout only for the API call; validators of the listing
+	// are only kept once the acts are stored, so a failed store is never revalidated
 	apiCtx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
-	
+	apiCtx, commitValidators := sejm.DeferValidators(apiCtx)
+
 	// Fetch from API and update cache
-	acts, err := s.sejmClient.GetActs(apiCtx, year)
+	acts, err := s.sejmClient.GetActs(apiCtx, publisher, year)
+	if errors.Is(err, sejm.ErrNotModified) {
+		if acts, ok := s.keepCachedActs(ctx, publisher, year); ok {
+			return acts, nil
+		}
+		// Nothing cached to keep, so the listing is downloaded in full
+		acts, err = s.sejmClient.GetActs(sejm.WithoutValidators(apiCtx), publisher, year)
+	}
 	if err != nil {
 		if err == context.DeadlineExceeded {
-			slog.Warn("Timeout checking year", "year", year, "timeout", s.timeout)
+			slog.Warn("Timeout checking year", "publisher", publisher, "year", year, "timeout", s.timeout)
 		} else {
-			slog.Error("Error checking year", "year", year, "error", err)
+			slog.Error("Error checking year", "publisher", publisher, "year", year, "error", err)
 		}
 		return nil, err
 	}
 
 	metrics.IncrementSejmAPI()
 
-	// Store in cache using the original context
-	if err := s.db.StoreActs(ctx, year, acts); err != nil {
-		slog.Error("Error storing in cache", "year", year, "error", err)
+	// Store in cache using the original context; the client only returns
+	// complete listings, so a partial result never replaces cached acts
+	changes, err := s.db.StoreActs(ctx, publisher, year, acts)
+	if err != nil {
+		slog.Error("Error storing in cache", "publisher", publisher, "year", year, "error", err)
 		// Continue even if cache store fails
+		return acts, nil
 	}
+	commitValidators(ctx)
+
+	// Only the store that recorded a change reports it, so concurrent refreshes of the
+	// year, in this process or another replica, notify watchers once
+	s.notifyListingChanges(ctx, changes, acts)
 
 	return acts, nil
 }
 
-// GetActsByYear retrieves acts for a specific year and organizes them for the board
-func (s *ActService) GetActsByYear(ctx context.Context, year int) (*BoardData, error) {
+// keepCachedActs marks cached acts of an unchanged listing as fresh and returns them,
+// reporting false if the listing was never cached
+func (s *ActService) keepCachedActs(ctx context.Context, publisher string, year int) ([]sejm.Act, bool) {
+	cached, err := s.db.TouchCacheEntry(ctx, db.CacheKindActs, db.YearKey(publisher, year))
+	if err != nil {
+		slog.Error("Error updating cache age", "publisher", publisher, "year", year, "error", err)
+		return nil, false
+	}
+	if !cached {
+		return nil, false
+	}
+
+	acts, err := s.db.GetActs(ctx, publisher, year)
+	if err != nil {
+		slog.Error("Error reading from cache", "publisher", publisher, "year", year, "error", err)
+		return nil, false
+	}
+
+	slog.Debug("Acts not modified, keeping cache", "publisher", publisher, "year", year)
+	return acts, true
+}
+
+// GetActsByYear retrieves acts of a publisher for a specific year and organizes them for the board
+func (s *ActService) GetActsByYear(ctx context.Context, publisher string, year int) (_ *BoardData, err error) {
+	ctx, span := tracer.Start(ctx, "service.GetActsByYear", trace.WithAttributes(attribute.String("act.publisher", publisher), attribute.Int("act.year", year)))
+	defer tracing.End(span, &err)
+
 	metrics.IncrementAPI()
-	
-	acts, err := s.getActsForYear(ctx, year)
+	if !sejm.IsValidPublisher(publisher) {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPublisher, publisher)
+	}
+
+	acts, entry, err := s.getActsForYear(ctx, publisher, year)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch acts: %w", err)
 	}
 
 	if len(acts) == 0 {
-		return nil, fmt.Errorf("no data available for year %d", year)
+		return nil, fmt.Errorf("no data available for year %d in %s", year, publisher)
 	}
 
-	return organizeActsByStatus(acts), nil
+	data := OrganizeActsByStatus(acts)
+	data.UpdatedAt = time.Now()
+	if entry != nil {
+		data.Stale = !s.fresh(entry)
+		data.UpdatedAt = entry.FetchedAt
+	}
+	data.Offline, data.SnapshotAt = s.Offline()
+	return data, nil
 }
 
-// organizeActsByStatus organizes acts by their status for the board view
-func organizeActsByStatus(acts []sejm.Act) *BoardData {
+// OrganizeActsByStatus organizes acts by their status for the board view
+func OrganizeActsByStatus(acts []sejm.Act) *BoardData {
 	data := &BoardData{
 		Obowiazujace: make([]sejm.Act, 0),
 		Pending:      make([]sejm.Act, 0),
@@ -228,25 +419,77 @@ func organizeActsByStatus(acts []sejm.Act) *BoardData {
 	return data
 }
 
-// GetActDetails retrieves details for a specific act
-func (s *ActService) GetActDetails(ctx context.Context, year, position string) (*sejm.ActDetails, error) {
+// GetActDetails retrieves details for a specific act. Details past the details TTL or
+// invalidated are fetched again, falling back to the cached ones if the API fails.
+func (s *ActService) GetActDetails(ctx context.Context, id sejm.ELI) (_ *sejm.ActDetails, err error) {
+	ctx, span := tracer.Start(ctx, "service.GetActDetails", trace.WithAttributes(attribute.String("act.id", id.String())))
+	defer tracing.End(span, &err)
+
 	metrics.IncrementAPI()
-	actID := fmt.Sprintf("DU/%s/%s", year, position)
+	s.views.record(id.String())
 
 	// Check cache first
-	details, err := s.db.GetActDetails(ctx, actID)
-	if err == nil && details != nil {
-		metrics.IncrementCacheHit()
+	details, err := s.db.GetActDetails(ctx, id)
+	if err != nil {
+		slog.Error("Error reading from cache", "act_id", id, "error", err)
+	}
+	entry, err := s.db.GetCacheEntry(ctx, db.CacheKindDetails, id.String())
+	if err != nil {
+		slog.Error("Error reading cache entry", "act_id", id, "error", err)
+	}
+	if entry != nil && s.fresh(entry) {
+		// A recent 404 outweighs details cached before the act disappeared
+		if entry.Outcome == db.OutcomeNotFound {
+			metrics.IncrementCacheHit(metrics.CacheDetails)
+			return nil, fmt.Errorf("%w: %s", sejm.ErrActNotFound, id)
+		}
+		if details != nil {
+			metrics.IncrementCacheHit(metrics.CacheDetails)
+			return details, nil
+		}
+	}
+
+	metrics.IncrementCacheMiss(metrics.CacheDetails)
+	fetched, err := s.loadActDetails(ctx, id.String())
+	if err != nil && details != nil && !errors.Is(err, sejm.ErrActNotFound) {
+		slog.Warn("Error refreshing act details, serving expired cache", "act_id", id, "error", err)
 		return details, nil
 	}
+	return fetched, err
+}
+
+// loadActDetails fetches act details from API and stores them in cache, sharing the
+// fetch between concurrent callers of the same act
+func (s *ActService) loadActDetails(ctx context.Context, actID string) (*sejm.ActDetails, error) {
+	return coalesce(ctx, &s.flights, metrics.CacheDetails, "details/"+actID, func(ctx context.Context) (*sejm.ActDetails, error) {
+		return s.fetchActDetails(ctx, actID)
+	})
+}
+
+// fetchActDetails fetches act details from API and stores them in cache, notifying watchers
+// about changes whether the fetch comes from a background refresh or an expired cache
+func (s *ActService) fetchActDetails(ctx context.Context, actID string) (*sejm.ActDetails, error) {
+	if s.offline {
+		return nil, ErrOffline
+	}
+
+	var before *sejm.ActDetails
+	if s.notifier != nil {
+		before = s.cachedActDetails(ctx, actID)
+	}
 
-	metrics.IncrementCacheMiss()
 	// Create a new context with timeout only for the API call
 	apiCtx, cancel := context.WithTimeout(ctx, s.timeout)
 	// Fetch from API
-	details, err = s.sejmClient.GetActDetails(apiCtx, actID)
+	details, err := s.sejmClient.GetActDetails(apiCtx, actID)
 	cancel() // Cancel right after the API call
 
+	if errors.Is(err, sejm.ErrActNotFound) {
+		// Details cached before the act disappeared are dropped with the 404
+		if err := s.db.DeleteActDetails(ctx, actID); err != nil {
+			slog.Error("Error removing act details from cache", "act_id", actID, "error", err)
+		}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch act details: %w", err)
 	}
@@ -259,5 +502,109 @@ func (s *ActService) GetActDetails(ctx context.Context, year, position string) (
 		// Continue even if cache store fails
 	}
 
+	s.notifyDetailsChanges(ctx, before, details)
 	return details, nil
 }
+
+// cachedActDetails returns cached details of an act, nil if there are none or they can't be read
+func (s *ActService) cachedActDetails(ctx context.Context, actID string) *sejm.ActDetails {
+	id, err := sejm.ParseELI(actID)
+	if err != nil {
+		return nil
+	}
+	details, err := s.db.GetActDetails(ctx, id)
+	if err != nil {
+		slog.Error("Error reading from cache", "act_id", actID, "error", err)
+		return nil
+	}
+	return details
+}
+
+// GetActHistory returns changes recorded for an act between syncs, most recent first
+func (s *ActService) GetActHistory(ctx context.Context, id sejm.ELI) (_ []db.ActChange, err error) {
+	ctx, span := tracer.Start(ctx, "service.GetActHistory", trace.WithAttributes(attribute.String("act.id", id.String())))
+	defer tracing.End(span, &err)
+
+	metrics.IncrementAPI()
+
+	changes, err := s.db.GetActChanges(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get act history: %w", err)
+	}
+
+	return changes, nil
+}
+
+// SearchCachedActs performs a full-text search over acts cached for all publishers and years
+func (s *ActService) SearchCachedActs(ctx context.Context, query string, limit int) (_ *SearchResults, err error) {
+	ctx, span := tracer.Start(ctx, "service.SearchCachedActs")
+	defer tracing.End(span, &err)
+
+	metrics.IncrementAPI()
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, ErrEmptyQuery
+	}
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	limit = min(limit, maxSearchLimit)
+
+	acts, err := s.db.SearchActs(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search acts: %w", err)
+	}
+
+	return &SearchResults{Query: query, Acts: acts}, nil
+}
+
+// SearchActs queries the ELI act search, serving pages cached within the cache TTL,
+// or cached at any time when offline
+func (s *ActService) SearchActs(ctx context.Context, query sejm.SearchQuery) (_ *sejm.SearchResult, err error) {
+	ctx, span := tracer.Start(ctx, "service.SearchActs")
+	defer tracing.End(span, &err)
+
+	metrics.IncrementAPI()
+	query = query.Normalize()
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	key := query.Key()
+
+	// Check cache first
+	cached, age, err := s.db.GetSearchResult(ctx, key)
+	if err != nil {
+		slog.Error("Error reading search from cache", "query", key, "error", err)
+	}
+	if err == nil && cached != nil && (age < s.cacheTTL || s.offline) {
+		metrics.IncrementCacheHit(metrics.CacheSearch)
+		return cached, nil
+	}
+	if s.offline {
+		return nil, ErrOffline
+	}
+
+	metrics.IncrementCacheMiss(metrics.CacheSearch)
+	// Create a new context with timeout only for the API call
+	apiCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	result, err := s.sejmClient.SearchActs(apiCtx, query)
+	cancel()
+
+	if err != nil && cached != nil {
+		slog.Warn("Error searching acts, serving stale search", "query", key, "error", err)
+		return cached, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to search acts: %w", err)
+	}
+
+	metrics.IncrementSejmAPI()
+
+	// Store in cache using the original context
+	if err := s.db.StoreSearchResult(ctx, key, result); err != nil {
+		slog.Error("Error storing search in cache", "query", key, "error", err)
+		// Continue even if cache store fails
+	}
+
+	return result, nil
+}
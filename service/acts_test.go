@@ -4,13 +4,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
+	"ustawka/acttext"
+	"ustawka/db"
 	"ustawka/sejm"
 	"ustawka/service"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockSejmClient is a mock implementation of the Sejm client
@@ -21,8 +27,8 @@ type MockSejmClient struct {
 // Ensure MockSejmClient implements service.SejmClient
 var _ service.SejmClient = (*MockSejmClient)(nil)
 
-func (m *MockSejmClient) GetActs(ctx context.Context, year int) ([]sejm.Act, error) {
-	args := m.Called(ctx, year)
+func (m *MockSejmClient) GetActs(ctx context.Context, publisher string, year int) ([]sejm.Act, error) {
+	args := m.Called(ctx, publisher, year)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -45,6 +51,30 @@ func (m *MockSejmClient) GetActDetails(ctx context.Context, actID string) (*sejm
 	return details, args.Error(1)
 }
 
+func (m *MockSejmClient) SearchActs(ctx context.Context, query sejm.SearchQuery) (*sejm.SearchResult, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	result, ok := args.Get(0).(*sejm.SearchResult)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return result, args.Error(1)
+}
+
+func (m *MockSejmClient) GetActText(ctx context.Context, actID, name string) (*sejm.Document, error) {
+	args := m.Called(ctx, actID, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	doc, ok := args.Get(0).(*sejm.Document)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return doc, args.Error(1)
+}
+
 // MockDB is a mock implementation of the database
 type MockDB struct {
 	mock.Mock
@@ -53,8 +83,8 @@ type MockDB struct {
 // Ensure MockDB implements service.Database
 var _ service.Database = (*MockDB)(nil)
 
-func (m *MockDB) GetActs(ctx context.Context, year int) ([]sejm.Act, error) {
-	args := m.Called(ctx, year)
+func (m *MockDB) GetActs(ctx context.Context, publisher string, year int) ([]sejm.Act, error) {
+	args := m.Called(ctx, publisher, year)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -65,13 +95,17 @@ func (m *MockDB) GetActs(ctx context.Context, year int) ([]sejm.Act, error) {
 	return acts, args.Error(1)
 }
 
-func (m *MockDB) StoreActs(ctx context.Context, year int, acts []sejm.Act) error {
-	args := m.Called(ctx, year, acts)
-	return args.Error(0)
+func (m *MockDB) StoreActs(ctx context.Context, publisher string, year int, acts []sejm.Act) ([]db.ActChange, error) {
+	args := m.Called(ctx, publisher, year, acts)
+	changes, ok := args.Get(0).([]db.ActChange)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return changes, args.Error(1)
 }
 
-func (m *MockDB) GetActDetails(ctx context.Context, actID string) (*sejm.ActDetails, error) {
-	args := m.Called(ctx, actID)
+func (m *MockDB) GetActDetails(ctx context.Context, id sejm.ELI) (*sejm.ActDetails, error) {
+	args := m.Called(ctx, id.String())
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -87,13 +121,199 @@ func (m *MockDB) StoreActDetails(ctx context.Context, details *sejm.ActDetails)
 	return args.Error(0)
 }
 
-func (m *MockDB) GetCacheAge(ctx context.Context, year int) (time.Duration, error) {
-	args := m.Called(ctx, year)
-	duration, ok := args.Get(0).(time.Duration)
+func (m *MockDB) DeleteActDetails(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockDB) GetActChanges(ctx context.Context, id sejm.ELI) ([]db.ActChange, error) {
+	args := m.Called(ctx, id.String())
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	changes, ok := args.Get(0).([]db.ActChange)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return changes, args.Error(1)
+}
+
+func (m *MockDB) AddWatch(ctx context.Context, watch db.Watch) (*db.Watch, error) {
+	args := m.Called(ctx, watch)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	created, ok := args.Get(0).(*db.Watch)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return created, args.Error(1)
+}
+
+func (m *MockDB) DeleteWatch(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockDB) ListWatches(ctx context.Context) ([]db.Watch, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	watches, ok := args.Get(0).([]db.Watch)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return watches, args.Error(1)
+}
+
+func (m *MockDB) GetFeedEntries(ctx context.Context, filter db.FeedFilter, limit int) ([]db.FeedEntry, error) {
+	args := m.Called(ctx, filter, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	entries, ok := args.Get(0).([]db.FeedEntry)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return entries, args.Error(1)
+}
+
+func (m *MockDB) GetActText(ctx context.Context, id sejm.ELI, name string) (*db.ActText, error) {
+	args := m.Called(ctx, id.String(), name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	text, ok := args.Get(0).(*db.ActText)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return text, args.Error(1)
+}
+
+func (m *MockDB) StoreActText(ctx context.Context, text db.ActText) error {
+	args := m.Called(ctx, text)
+	return args.Error(0)
+}
+
+func (m *MockDB) GetActTextVersions(ctx context.Context, id sejm.ELI, name string) ([]db.ActTextVersion, error) {
+	args := m.Called(ctx, id.String(), name)
+	versions, ok := args.Get(0).([]db.ActTextVersion)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return versions, args.Error(1)
+}
+
+func (m *MockDB) GetActDocument(ctx context.Context, hash string) (*acttext.Document, error) {
+	args := m.Called(ctx, hash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	doc, ok := args.Get(0).(*acttext.Document)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return doc, args.Error(1)
+}
+
+func (m *MockDB) StoreActDocument(ctx context.Context, hash string, doc *acttext.Document) error {
+	args := m.Called(ctx, hash, doc)
+	return args.Error(0)
+}
+
+func (m *MockDB) GetActLinks(ctx context.Context, sources []string) ([]db.ActLink, error) {
+	args := m.Called(ctx, sources)
+	links, ok := args.Get(0).([]db.ActLink)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return links, args.Error(1)
+}
+
+func (m *MockDB) GetIncomingLinks(ctx context.Context, targets []string) ([]db.ActLink, error) {
+	args := m.Called(ctx, targets)
+	links, ok := args.Get(0).([]db.ActLink)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return links, args.Error(1)
+}
+
+func (m *MockDB) GetActTitles(ctx context.Context, ids []string) (map[string]string, error) {
+	args := m.Called(ctx, ids)
+	titles, ok := args.Get(0).(map[string]string)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return titles, args.Error(1)
+}
+
+func (m *MockDB) GetCacheEntry(ctx context.Context, kind, key string) (*db.CacheEntry, error) {
+	args := m.Called(ctx, kind, key)
+	entry, ok := args.Get(0).(*db.CacheEntry)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return entry, args.Error(1)
+}
+
+func (m *MockDB) StoreCacheEntry(ctx context.Context, entry db.CacheEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (m *MockDB) TouchCacheEntry(ctx context.Context, kind, key string) (bool, error) {
+	args := m.Called(ctx, kind, key)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDB) InvalidateCacheEntries(ctx context.Context, prefix string) (int64, error) {
+	args := m.Called(ctx, prefix)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// yearEntry returns cache metadata of a year listing fetched age ago
+func yearEntry(age time.Duration) *db.CacheEntry {
+	return &db.CacheEntry{Kind: db.CacheKindActs, FetchedAt: time.Now().Add(-age), Outcome: db.OutcomeOK}
+}
+
+// detailsEntry returns cache metadata of act details fetched age ago
+func detailsEntry(age time.Duration) *db.CacheEntry {
+	return &db.CacheEntry{Kind: db.CacheKindDetails, FetchedAt: time.Now().Add(-age), Outcome: db.OutcomeOK}
+}
+
+// yearsUntilNow returns all years from 2021 to the current year
+func yearsUntilNow() []int {
+	years := make([]int, 0)
+	for year := 2021; year <= time.Now().Year(); year++ {
+		years = append(years, year)
+	}
+	return years
+}
+
+func (m *MockDB) SearchActs(ctx context.Context, query string, limit int) ([]sejm.Act, error) {
+	args := m.Called(ctx, query, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	acts, ok := args.Get(0).([]sejm.Act)
 	if !ok {
-		return 0, args.Error(1)
+		return nil, args.Error(1)
 	}
-	return duration, args.Error(1)
+	return acts, args.Error(1)
+}
+
+func (m *MockDB) GetSearchResult(ctx context.Context, key string) (*sejm.SearchResult, time.Duration, error) {
+	args := m.Called(ctx, key)
+	result, _ := args.Get(0).(*sejm.SearchResult)
+	age, _ := args.Get(1).(time.Duration)
+	return result, age, args.Error(2)
+}
+
+func (m *MockDB) StoreSearchResult(ctx context.Context, key string, result *sejm.SearchResult) error {
+	args := m.Called(ctx, key, result)
+	return args.Error(0)
 }
 
 func TestGetAvailableYears(t *testing.T) {
@@ -122,85 +342,100 @@ func getAvailableYearsTestCases() []struct {
 		errorContains string
 	}{
 		{
-			name: "All years available from API",
-			setupMocks: setupAllYearsAvailable,
-			expectedYears: []int{2021, 2022, 2023, 2024, 2025},
+			name:          "All years available from API",
+			setupMocks:    setupAllYearsAvailable,
+			expectedYears: yearsUntilNow(),
 			expectedError: false,
 		},
 		{
-			name: "Mixed cache and API data",
-			setupMocks: setupMixedCacheAndAPI,
+			name:          "Mixed cache and API data",
+			setupMocks:    setupMixedCacheAndAPI,
 			expectedYears: []int{2022, 2023, 2024},
 			expectedError: false,
 		},
 		{
-			name: "All cache errors",
-			setupMocks: setupAllCacheErrors,
+			name:          "All cache errors",
+			setupMocks:    setupAllCacheErrors,
 			expectedYears: nil,
 			expectedError: true,
 			errorContains: "failed to fetch any years",
 		},
 		{
-			name: "Cache store errors",
-			setupMocks: setupCacheStoreErrors,
-			expectedYears: []int{2021, 2022, 2023, 2024, 2025},
+			name:          "Cache store errors",
+			setupMocks:    setupCacheStoreErrors,
+			expectedYears: yearsUntilNow(),
 			expectedError: false,
 		},
 	}
 }
 
+// mustParseELI parses an act ID used in a test
+func mustParseELI(t *testing.T, value string) sejm.ELI {
+	t.Helper()
+	id, err := sejm.ParseELI(value)
+	require.NoError(t, err)
+	return id
+}
+
+// expectEmptyCache sets up mocks for a year with nothing cached yet
+func expectEmptyCache(md *MockDB, publisher string, year int) {
+	md.On("GetCacheEntry", mock.Anything, db.CacheKindActs, db.YearKey(publisher, year)).Return(nil, nil).Once()
+}
+
 // setupAllYearsAvailable sets up mocks for all years available from API
 func setupAllYearsAvailable(mc *MockSejmClient, md *MockDB) {
-	for _, year := range []int{2021, 2022, 2023, 2024, 2025} {
-		md.On("GetCacheAge", mock.Anything, year).Return(25*time.Hour, nil).Once()
+	for _, year := range yearsUntilNow() {
+		expectEmptyCache(md, sejm.PublisherDU, year)
 		actID := fmt.Sprintf("DU/%d/1", year)
-		mc.On("GetActs", mock.Anything, year).Return([]sejm.Act{{ID: actID}}, nil).Once()
-		md.On("StoreActs", mock.Anything, year, mock.Anything).Return(nil).Once()
+		mc.On("GetActs", mock.Anything, sejm.PublisherDU, year).Return([]sejm.Act{{ID: actID}}, nil).Once()
+		md.On("StoreActs", mock.Anything, sejm.PublisherDU, year, mock.Anything).Return(nil, nil).Once()
 	}
 }
 
 // setupMixedCacheAndAPI sets up mocks for mixed cache and API scenarios
 func setupMixedCacheAndAPI(mc *MockSejmClient, md *MockDB) {
 	// 2021: not in cache, no data
-	md.On("GetCacheAge", mock.Anything, 2021).Return(25*time.Hour, nil).Once()
-	mc.On("GetActs", mock.Anything, 2021).Return([]sejm.Act{}, nil).Once()
-	md.On("StoreActs", mock.Anything, 2021, mock.Anything).Return(nil).Once()
+	expectEmptyCache(md, sejm.PublisherDU, 2021)
+	mc.On("GetActs", mock.Anything, sejm.PublisherDU, 2021).Return([]sejm.Act{}, nil).Once()
+	md.On("StoreActs", mock.Anything, sejm.PublisherDU, 2021, mock.Anything).Return(nil, nil).Once()
 
 	// 2022: in cache, has data
-	md.On("GetCacheAge", mock.Anything, 2022).Return(1*time.Hour, nil).Once()
-	md.On("GetActs", mock.Anything, 2022).Return([]sejm.Act{{ID: "DU/2022/1"}}, nil).Once()
+	md.On("GetCacheEntry", mock.Anything, db.CacheKindActs, db.YearKey(sejm.PublisherDU, 2022)).Return(yearEntry(1*time.Hour), nil).Once()
+	md.On("GetActs", mock.Anything, sejm.PublisherDU, 2022).Return([]sejm.Act{{ID: "DU/2022/1"}}, nil).Once()
 
 	// 2023: cache error, API success
-	md.On("GetCacheAge", mock.Anything, 2023).Return(0*time.Hour, errors.New("cache error")).Once()
-	mc.On("GetActs", mock.Anything, 2023).Return([]sejm.Act{{ID: "DU/2023/1"}}, nil).Once()
-	md.On("StoreActs", mock.Anything, 2023, mock.Anything).Return(nil).Once()
+	md.On("GetCacheEntry", mock.Anything, db.CacheKindActs, db.YearKey(sejm.PublisherDU, 2023)).Return(nil, errors.New("cache error")).Once()
+	mc.On("GetActs", mock.Anything, sejm.PublisherDU, 2023).Return([]sejm.Act{{ID: "DU/2023/1"}}, nil).Once()
+	md.On("StoreActs", mock.Anything, sejm.PublisherDU, 2023, mock.Anything).Return(nil, nil).Once()
 
 	// 2024: cache read error, API success
-	md.On("GetCacheAge", mock.Anything, 2024).Return(1*time.Hour, nil).Once()
-	md.On("GetActs", mock.Anything, 2024).Return([]sejm.Act{}, errors.New("cache read error")).Once()
-	mc.On("GetActs", mock.Anything, 2024).Return([]sejm.Act{{ID: "DU/2024/1"}}, nil).Once()
-	md.On("StoreActs", mock.Anything, 2024, mock.Anything).Return(nil).Once()
+	md.On("GetCacheEntry", mock.Anything, db.CacheKindActs, db.YearKey(sejm.PublisherDU, 2024)).Return(yearEntry(1*time.Hour), nil).Once()
+	md.On("GetActs", mock.Anything, sejm.PublisherDU, 2024).Return([]sejm.Act{}, errors.New("cache read error")).Once()
+	mc.On("GetActs", mock.Anything, sejm.PublisherDU, 2024).Return([]sejm.Act{{ID: "DU/2024/1"}}, nil).Once()
+	md.On("StoreActs", mock.Anything, sejm.PublisherDU, 2024, mock.Anything).Return(nil, nil).Once()
 
-	// 2025: API error
-	md.On("GetCacheAge", mock.Anything, 2025).Return(25*time.Hour, nil).Once()
-	mc.On("GetActs", mock.Anything, 2025).Return([]sejm.Act{}, errors.New("API error")).Once()
+	// 2025 onwards: API error
+	for year := 2025; year <= time.Now().Year(); year++ {
+		expectEmptyCache(md, sejm.PublisherDU, year)
+		mc.On("GetActs", mock.Anything, sejm.PublisherDU, year).Return([]sejm.Act{}, errors.New("API error")).Once()
+	}
 }
 
 // setupAllCacheErrors sets up mocks for all cache errors scenario
 func setupAllCacheErrors(mc *MockSejmClient, md *MockDB) {
-	for _, year := range []int{2021, 2022, 2023, 2024, 2025} {
-		md.On("GetCacheAge", mock.Anything, year).Return(0*time.Hour, errors.New("cache error")).Once()
-		mc.On("GetActs", mock.Anything, year).Return(nil, errors.New("API error")).Once()
+	for _, year := range yearsUntilNow() {
+		md.On("GetCacheEntry", mock.Anything, db.CacheKindActs, db.YearKey(sejm.PublisherDU, year)).Return(nil, errors.New("cache error")).Once()
+		mc.On("GetActs", mock.Anything, sejm.PublisherDU, year).Return(nil, errors.New("API error")).Once()
 	}
 }
 
 // setupCacheStoreErrors sets up mocks for cache store errors scenario
 func setupCacheStoreErrors(mc *MockSejmClient, md *MockDB) {
-	for _, year := range []int{2021, 2022, 2023, 2024, 2025} {
-		md.On("GetCacheAge", mock.Anything, year).Return(25*time.Hour, nil).Once()
+	for _, year := range yearsUntilNow() {
+		expectEmptyCache(md, sejm.PublisherDU, year)
 		actID := fmt.Sprintf("DU/%d/1", year)
-		mc.On("GetActs", mock.Anything, year).Return([]sejm.Act{{ID: actID}}, nil).Once()
-		md.On("StoreActs", mock.Anything, year, mock.Anything).Return(errors.New("store error")).Once()
+		mc.On("GetActs", mock.Anything, sejm.PublisherDU, year).Return([]sejm.Act{{ID: actID}}, nil).Once()
+		md.On("StoreActs", mock.Anything, sejm.PublisherDU, year, mock.Anything).Return(nil, errors.New("store error")).Once()
 	}
 }
 
@@ -219,7 +454,7 @@ func runAvailableYearsTest(t *testing.T, tt struct {
 
 	tt.setupMocks(mockClient, mockDB)
 
-	years, err := srv.GetAvailableYears(context.Background())
+	years, err := srv.GetAvailableYears(context.Background(), sejm.PublisherDU)
 	if tt.expectedError {
 		assert.Error(t, err)
 		if tt.errorContains != "" {
@@ -247,8 +482,8 @@ func TestGetActsByYear(t *testing.T) {
 			name: "Data from cache",
 			year: 2024,
 			setupMocks: func(_ *MockSejmClient, md *MockDB) {
-				md.On("GetCacheAge", mock.Anything, 2024).Return(1*time.Hour, nil).Once()
-				md.On("GetActs", mock.Anything, 2024).Return([]sejm.Act{
+				md.On("GetCacheEntry", mock.Anything, db.CacheKindActs, db.YearKey(sejm.PublisherDU, 2024)).Return(yearEntry(1*time.Hour), nil).Once()
+				md.On("GetActs", mock.Anything, sejm.PublisherDU, 2024).Return([]sejm.Act{
 					{ID: "DU/2024/1", Status: "obowiązujący"},
 					{ID: "DU/2024/2", Status: "uchylony"},
 					{ID: "DU/2024/3", Status: "W przygotowaniu"},
@@ -262,15 +497,15 @@ func TestGetActsByYear(t *testing.T) {
 			expectedError: false,
 		},
 		{
-			name: "Cache expired, data from API",
+			name: "Cache empty, data from API",
 			year: 2024,
 			setupMocks: func(mc *MockSejmClient, md *MockDB) {
-				md.On("GetCacheAge", mock.Anything, 2024).Return(25*time.Hour, nil).Once()
-				mc.On("GetActs", mock.Anything, 2024).Return([]sejm.Act{
+				expectEmptyCache(md, sejm.PublisherDU, 2024)
+				mc.On("GetActs", mock.Anything, sejm.PublisherDU, 2024).Return([]sejm.Act{
 					{ID: "DU/2024/1", Status: "obowiązujący"},
 					{ID: "DU/2024/2", Status: "uchylony"},
 				}, nil).Once()
-				md.On("StoreActs", mock.Anything, 2024, mock.Anything).Return(nil).Once()
+				md.On("StoreActs", mock.Anything, sejm.PublisherDU, 2024, mock.Anything).Return(nil, nil).Once()
 			},
 			expectedData: &service.BoardData{
 				Obowiazujace: []sejm.Act{{ID: "DU/2024/1", Status: "obowiązujący"}},
@@ -279,15 +514,59 @@ func TestGetActsByYear(t *testing.T) {
 			},
 			expectedError: false,
 		},
+		{
+			name: "Cache expired, stale data served and refreshed",
+			year: 2024,
+			setupMocks: func(mc *MockSejmClient, md *MockDB) {
+				md.On("GetCacheEntry", mock.Anything, db.CacheKindActs, db.YearKey(sejm.PublisherDU, 2024)).Return(yearEntry(25*time.Hour), nil).Once()
+				md.On("GetActs", mock.Anything, sejm.PublisherDU, 2024).Return([]sejm.Act{
+					{ID: "DU/2024/1", Status: "obowiązujący"},
+				}, nil).Once()
+				mc.On("GetActs", mock.Anything, sejm.PublisherDU, 2024).Return([]sejm.Act{
+					{ID: "DU/2024/1", Status: "uchylony"},
+				}, nil).Once()
+				md.On("StoreActs", mock.Anything, sejm.PublisherDU, 2024, mock.Anything).Return(nil, nil).Once()
+			},
+			expectedData: &service.BoardData{
+				Obowiazujace: []sejm.Act{{ID: "DU/2024/1", Status: "obowiązujący"}},
+				Uchylone:     []sejm.Act{},
+				Pending:      []sejm.Act{},
+				Stale:        true,
+			},
+			expectedError: false,
+		},
+		{
+			name: "Cache invalidated, stale data served and refreshed",
+			year: 2024,
+			setupMocks: func(mc *MockSejmClient, md *MockDB) {
+				entry := yearEntry(time.Minute)
+				entry.Invalidated = true
+				md.On("GetCacheEntry", mock.Anything, db.CacheKindActs, db.YearKey(sejm.PublisherDU, 2024)).Return(entry, nil).Once()
+				md.On("GetActs", mock.Anything, sejm.PublisherDU, 2024).Return([]sejm.Act{
+					{ID: "DU/2024/1", Status: "obowiązujący"},
+				}, nil).Once()
+				mc.On("GetActs", mock.Anything, sejm.PublisherDU, 2024).Return([]sejm.Act{
+					{ID: "DU/2024/1", Status: "uchylony"},
+				}, nil).Once()
+				md.On("StoreActs", mock.Anything, sejm.PublisherDU, 2024, mock.Anything).Return(nil, nil).Once()
+			},
+			expectedData: &service.BoardData{
+				Obowiazujace: []sejm.Act{{ID: "DU/2024/1", Status: "obowiązujący"}},
+				Uchylone:     []sejm.Act{},
+				Pending:      []sejm.Act{},
+				Stale:        true,
+			},
+			expectedError: false,
+		},
 		{
 			name: "Cache error, data from API",
 			year: 2024,
 			setupMocks: func(mc *MockSejmClient, md *MockDB) {
-				md.On("GetCacheAge", mock.Anything, 2024).Return(0*time.Hour, errors.New("cache error")).Once()
-				mc.On("GetActs", mock.Anything, 2024).Return([]sejm.Act{
+				md.On("GetCacheEntry", mock.Anything, db.CacheKindActs, db.YearKey(sejm.PublisherDU, 2024)).Return(nil, errors.New("cache error")).Once()
+				mc.On("GetActs", mock.Anything, sejm.PublisherDU, 2024).Return([]sejm.Act{
 					{ID: "DU/2024/1", Status: "obowiązujący"},
 				}, nil).Once()
-				md.On("StoreActs", mock.Anything, 2024, mock.Anything).Return(nil).Once()
+				md.On("StoreActs", mock.Anything, sejm.PublisherDU, 2024, mock.Anything).Return(nil, nil).Once()
 			},
 			expectedData: &service.BoardData{
 				Obowiazujace: []sejm.Act{{ID: "DU/2024/1", Status: "obowiązujący"}},
@@ -300,12 +579,12 @@ func TestGetActsByYear(t *testing.T) {
 			name: "Cache read error, data from API",
 			year: 2024,
 			setupMocks: func(mc *MockSejmClient, md *MockDB) {
-				md.On("GetCacheAge", mock.Anything, 2024).Return(1*time.Hour, nil).Once()
-				md.On("GetActs", mock.Anything, 2024).Return(nil, errors.New("cache read error")).Once()
-				mc.On("GetActs", mock.Anything, 2024).Return([]sejm.Act{
+				md.On("GetCacheEntry", mock.Anything, db.CacheKindActs, db.YearKey(sejm.PublisherDU, 2024)).Return(yearEntry(1*time.Hour), nil).Once()
+				md.On("GetActs", mock.Anything, sejm.PublisherDU, 2024).Return(nil, errors.New("cache read error")).Once()
+				mc.On("GetActs", mock.Anything, sejm.PublisherDU, 2024).Return([]sejm.Act{
 					{ID: "DU/2024/1", Status: "obowiązujący"},
 				}, nil).Once()
-				md.On("StoreActs", mock.Anything, 2024, mock.Anything).Return(nil).Once()
+				md.On("StoreActs", mock.Anything, sejm.PublisherDU, 2024, mock.Anything).Return(nil, nil).Once()
 			},
 			expectedData: &service.BoardData{
 				Obowiazujace: []sejm.Act{{ID: "DU/2024/1", Status: "obowiązujący"}},
@@ -318,20 +597,61 @@ func TestGetActsByYear(t *testing.T) {
 			name: "API error",
 			year: 2024,
 			setupMocks: func(mc *MockSejmClient, md *MockDB) {
-				md.On("GetCacheAge", mock.Anything, 2024).Return(25*time.Hour, nil).Once()
-				mc.On("GetActs", mock.Anything, 2024).Return(nil, errors.New("API error")).Once()
+				expectEmptyCache(md, sejm.PublisherDU, 2024)
+				mc.On("GetActs", mock.Anything, sejm.PublisherDU, 2024).Return(nil, errors.New("API error")).Once()
 			},
 			expectedData:  nil,
 			expectedError: true,
 			errorContains: "failed to fetch acts",
 		},
+		{
+			name: "Cache expired, refresh failing",
+			year: 2024,
+			setupMocks: func(mc *MockSejmClient, md *MockDB) {
+				md.On("GetCacheEntry", mock.Anything, db.CacheKindActs, db.YearKey(sejm.PublisherDU, 2024)).Return(yearEntry(25*time.Hour), nil).Once()
+				md.On("GetActs", mock.Anything, sejm.PublisherDU, 2024).Return([]sejm.Act{
+					{ID: "DU/2024/1", Status: "obowiązujący"},
+				}, nil).Once()
+				mc.On("GetActs", mock.Anything, sejm.PublisherDU, 2024).Return(nil, fmt.Errorf("error fetching acts: %w", sejm.ErrCircuitOpen)).Once()
+			},
+			expectedData: &service.BoardData{
+				Obowiazujace: []sejm.Act{{ID: "DU/2024/1", Status: "obowiązujący"}},
+				Uchylone:     []sejm.Act{},
+				Pending:      []sejm.Act{},
+				Stale:        true,
+			},
+			expectedError: false,
+		},
+		{
+			name: "Circuit open, nothing cached",
+			year: 2024,
+			setupMocks: func(mc *MockSejmClient, md *MockDB) {
+				expectEmptyCache(md, sejm.PublisherDU, 2024)
+				mc.On("GetActs", mock.Anything, sejm.PublisherDU, 2024).Return(nil, sejm.ErrCircuitOpen).Once()
+			},
+			expectedData:  nil,
+			expectedError: true,
+			errorContains: "circuit breaker open",
+		},
 		{
 			name: "No data available",
 			year: 2024,
 			setupMocks: func(mc *MockSejmClient, md *MockDB) {
-				md.On("GetCacheAge", mock.Anything, 2024).Return(25*time.Hour, nil).Once()
-				mc.On("GetActs", mock.Anything, 2024).Return([]sejm.Act{}, nil).Once()
-				md.On("StoreActs", mock.Anything, 2024, mock.Anything).Return(nil).Once()
+				expectEmptyCache(md, sejm.PublisherDU, 2024)
+				mc.On("GetActs", mock.Anything, sejm.PublisherDU, 2024).Return([]sejm.Act{}, nil).Once()
+				md.On("StoreActs", mock.Anything, sejm.PublisherDU, 2024, mock.Anything).Return(nil, nil).Once()
+			},
+			expectedData:  nil,
+			expectedError: true,
+			errorContains: "no data available for year",
+		},
+		{
+			name: "No data available, known from cache",
+			year: 2024,
+			setupMocks: func(_ *MockSejmClient, md *MockDB) {
+				md.On("GetCacheEntry", mock.Anything, db.CacheKindActs, db.YearKey(sejm.PublisherDU, 2024)).Return(&db.CacheEntry{
+					Kind: db.CacheKindActs, FetchedAt: time.Now().Add(-time.Minute), Outcome: db.OutcomeEmpty,
+				}, nil).Once()
 			},
 			expectedData:  nil,
 			expectedError: true,
@@ -367,6 +687,7 @@ func TestGetActDetails(t *testing.T) {
 					Status:    "obowiązujący",
 					Published: "2024-01-01",
 				}, nil).Once()
+				md.On("GetCacheEntry", mock.Anything, db.CacheKindDetails, "DU/2024/123").Return(detailsEntry(time.Hour), nil).Once()
 			},
 			expectedData: &sejm.ActDetails{
 				ID:        "DU/2024/123",
@@ -376,12 +697,53 @@ func TestGetActDetails(t *testing.T) {
 			},
 			expectedError: false,
 		},
+		{
+			name:     "Cache expired, data from API",
+			year:     "2024",
+			position: "123",
+			setupMocks: func(mc *MockSejmClient, md *MockDB) {
+				md.On("GetActDetails", mock.Anything, "DU/2024/123").Return(&sejm.ActDetails{ID: "DU/2024/123", Status: "obowiązujący"}, nil).Once()
+				md.On("GetCacheEntry", mock.Anything, db.CacheKindDetails, "DU/2024/123").Return(detailsEntry(8*24*time.Hour), nil).Once()
+				mc.On("GetActDetails", mock.Anything, "DU/2024/123").Return(&sejm.ActDetails{ID: "DU/2024/123", Status: "uchylony"}, nil).Once()
+				md.On("StoreActDetails", mock.Anything, mock.Anything).Return(nil).Once()
+			},
+			expectedData:  &sejm.ActDetails{ID: "DU/2024/123", Status: "uchylony"},
+			expectedError: false,
+		},
+		{
+			name:     "Cache invalidated, data from API",
+			year:     "2024",
+			position: "123",
+			setupMocks: func(mc *MockSejmClient, md *MockDB) {
+				entry := detailsEntry(time.Minute)
+				entry.Invalidated = true
+				md.On("GetActDetails", mock.Anything, "DU/2024/123").Return(&sejm.ActDetails{ID: "DU/2024/123", Status: "obowiązujący"}, nil).Once()
+				md.On("GetCacheEntry", mock.Anything, db.CacheKindDetails, "DU/2024/123").Return(entry, nil).Once()
+				mc.On("GetActDetails", mock.Anything, "DU/2024/123").Return(&sejm.ActDetails{ID: "DU/2024/123", Status: "uchylony"}, nil).Once()
+				md.On("StoreActDetails", mock.Anything, mock.Anything).Return(nil).Once()
+			},
+			expectedData:  &sejm.ActDetails{ID: "DU/2024/123", Status: "uchylony"},
+			expectedError: false,
+		},
+		{
+			name:     "Cache expired, API error serves cached data",
+			year:     "2024",
+			position: "123",
+			setupMocks: func(mc *MockSejmClient, md *MockDB) {
+				md.On("GetActDetails", mock.Anything, "DU/2024/123").Return(&sejm.ActDetails{ID: "DU/2024/123", Status: "obowiązujący"}, nil).Once()
+				md.On("GetCacheEntry", mock.Anything, db.CacheKindDetails, "DU/2024/123").Return(detailsEntry(8*24*time.Hour), nil).Once()
+				mc.On("GetActDetails", mock.Anything, "DU/2024/123").Return(nil, sejm.ErrCircuitOpen).Once()
+			},
+			expectedData:  &sejm.ActDetails{ID: "DU/2024/123", Status: "obowiązujący"},
+			expectedError: false,
+		},
 		{
 			name:     "Cache miss, data from API",
 			year:     "2024",
 			position: "123",
 			setupMocks: func(mc *MockSejmClient, md *MockDB) {
 				md.On("GetActDetails", mock.Anything, "DU/2024/123").Return(nil, nil).Once()
+				md.On("GetCacheEntry", mock.Anything, db.CacheKindDetails, "DU/2024/123").Return(nil, nil).Once()
 				mc.On("GetActDetails", mock.Anything, "DU/2024/123").Return(&sejm.ActDetails{
 					ID:        "DU/2024/123",
 					Title:     "Test Act",
@@ -404,6 +766,7 @@ func TestGetActDetails(t *testing.T) {
 			position: "123",
 			setupMocks: func(mc *MockSejmClient, md *MockDB) {
 				md.On("GetActDetails", mock.Anything, "DU/2024/123").Return(nil, errors.New("cache error")).Once()
+				md.On("GetCacheEntry", mock.Anything, db.CacheKindDetails, "DU/2024/123").Return(nil, nil).Once()
 				mc.On("GetActDetails", mock.Anything, "DU/2024/123").Return(&sejm.ActDetails{
 					ID:        "DU/2024/123",
 					Title:     "Test Act",
@@ -426,18 +789,77 @@ func TestGetActDetails(t *testing.T) {
 			position: "123",
 			setupMocks: func(mc *MockSejmClient, md *MockDB) {
 				md.On("GetActDetails", mock.Anything, "DU/2024/123").Return(nil, nil).Once()
+				md.On("GetCacheEntry", mock.Anything, db.CacheKindDetails, "DU/2024/123").Return(nil, nil).Once()
 				mc.On("GetActDetails", mock.Anything, "DU/2024/123").Return(nil, errors.New("API error")).Once()
 			},
 			expectedData:  nil,
 			expectedError: true,
 			errorContains: "failed to fetch act details",
 		},
+		{
+			name:     "Missing act, remembered",
+			year:     "2024",
+			position: "123",
+			setupMocks: func(mc *MockSejmClient, md *MockDB) {
+				md.On("GetActDetails", mock.Anything, "DU/2024/123").Return(nil, nil).Once()
+				md.On("GetCacheEntry", mock.Anything, db.CacheKindDetails, "DU/2024/123").Return(nil, nil).Once()
+				mc.On("GetActDetails", mock.Anything, "DU/2024/123").Return(nil, sejm.ErrActNotFound).Once()
+				md.On("DeleteActDetails", mock.Anything, "DU/2024/123").Return(nil).Once()
+			},
+			expectedData:  nil,
+			expectedError: true,
+			errorContains: "act not found",
+		},
+		{
+			name:     "Missing act, known from cache",
+			year:     "2024",
+			position: "123",
+			setupMocks: func(_ *MockSejmClient, md *MockDB) {
+				md.On("GetActDetails", mock.Anything, "DU/2024/123").Return(nil, nil).Once()
+				md.On("GetCacheEntry", mock.Anything, db.CacheKindDetails, "DU/2024/123").Return(&db.CacheEntry{
+					Kind: db.CacheKindDetails, FetchedAt: time.Now().Add(-time.Minute), Outcome: db.OutcomeNotFound,
+				}, nil).Once()
+			},
+			expectedData:  nil,
+			expectedError: true,
+			errorContains: "act not found",
+		},
+		{
+			name:     "Missing act, cached details outdated",
+			year:     "2024",
+			position: "123",
+			setupMocks: func(_ *MockSejmClient, md *MockDB) {
+				md.On("GetActDetails", mock.Anything, "DU/2024/123").Return(&sejm.ActDetails{ID: "DU/2024/123"}, nil).Once()
+				md.On("GetCacheEntry", mock.Anything, db.CacheKindDetails, "DU/2024/123").Return(&db.CacheEntry{
+					Kind: db.CacheKindDetails, FetchedAt: time.Now().Add(-time.Minute), Outcome: db.OutcomeNotFound,
+				}, nil).Once()
+			},
+			expectedData:  nil,
+			expectedError: true,
+			errorContains: "act not found",
+		},
+		{
+			name:     "Missing act, negative cache expired",
+			year:     "2024",
+			position: "123",
+			setupMocks: func(mc *MockSejmClient, md *MockDB) {
+				md.On("GetActDetails", mock.Anything, "DU/2024/123").Return(nil, nil).Once()
+				md.On("GetCacheEntry", mock.Anything, db.CacheKindDetails, "DU/2024/123").Return(&db.CacheEntry{
+					Kind: db.CacheKindDetails, FetchedAt: time.Now().Add(-2 * time.Hour), Outcome: db.OutcomeNotFound,
+				}, nil).Once()
+				mc.On("GetActDetails", mock.Anything, "DU/2024/123").Return(&sejm.ActDetails{ID: "DU/2024/123"}, nil).Once()
+				md.On("StoreActDetails", mock.Anything, mock.Anything).Return(nil).Once()
+			},
+			expectedData:  &sejm.ActDetails{ID: "DU/2024/123"},
+			expectedError: false,
+		},
 		{
 			name:     "Cache store error",
 			year:     "2024",
 			position: "123",
 			setupMocks: func(mc *MockSejmClient, md *MockDB) {
 				md.On("GetActDetails", mock.Anything, "DU/2024/123").Return(nil, nil).Once()
+				md.On("GetCacheEntry", mock.Anything, db.CacheKindDetails, "DU/2024/123").Return(nil, nil).Once()
 				mc.On("GetActDetails", mock.Anything, "DU/2024/123").Return(&sejm.ActDetails{
 					ID:        "DU/2024/123",
 					Title:     "Test Act",
@@ -479,14 +901,17 @@ func runActsByYearTest(t *testing.T, tt struct {
 
 	tt.setupMocks(mockClient, mockDB)
 
-	data, err := srv.GetActsByYear(context.Background(), tt.year)
+	data, err := srv.GetActsByYear(context.Background(), sejm.PublisherDU, tt.year)
+	srv.Wait()
 	if tt.expectedError {
 		assert.Error(t, err)
 		if tt.errorContains != "" {
 			assert.Contains(t, err.Error(), tt.errorContains)
 		}
 	} else {
-		assert.NoError(t, err)
+		require.NoError(t, err)
+		assert.False(t, data.UpdatedAt.IsZero())
+		data.UpdatedAt = time.Time{}
 		assert.Equal(t, tt.expectedData, data)
 	}
 
@@ -511,7 +936,9 @@ func runActDetailsTest(t *testing.T, tt struct {
 
 	tt.setupMocks(mockClient, mockDB)
 
-	data, err := srv.GetActDetails(context.Background(), tt.year, tt.position)
+	id, err := sejm.NewELI(sejm.PublisherDU, tt.year, tt.position)
+	require.NoError(t, err)
+	data, err := srv.GetActDetails(context.Background(), id)
 	if tt.expectedError {
 		assert.Error(t, err)
 		if tt.errorContains != "" {
@@ -525,3 +952,353 @@ func runActDetailsTest(t *testing.T, tt struct {
 	mockClient.AssertExpectations(t)
 	mockDB.AssertExpectations(t)
 }
+
+func TestInvalidateCache(t *testing.T) {
+	tests := []struct {
+		name    string
+		scope   service.CacheScope
+		prefix  string
+		wantErr bool
+	}{
+		{name: "act", scope: service.CacheScope{Act: "WDU20240000001"}, prefix: "DU/2024/1"},
+		{name: "year", scope: service.CacheScope{Year: " mp/2023 "}, prefix: "MP/2023"},
+		{name: "all", scope: service.CacheScope{All: true}, prefix: ""},
+		{name: "nothing", scope: service.CacheScope{}, wantErr: true},
+		{name: "act and year", scope: service.CacheScope{Act: "DU/2024/1", Year: "DU/2024"}, wantErr: true},
+		{name: "malformed act", scope: service.CacheScope{Act: "DU/2024"}, wantErr: true},
+		{name: "unknown publisher", scope: service.CacheScope{Year: "XX/2024"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			srv := service.NewActServiceWithConfig(new(MockSejmClient), mockDB, 5*time.Second, 24*time.Hour)
+			if !tt.wantErr {
+				mockDB.On("InvalidateCacheEntries", mock.Anything, tt.prefix).Return(int64(3), nil).Once()
+			}
+
+			count, err := srv.InvalidateCache(context.Background(), tt.scope)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, service.ErrInvalidScope)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, int64(3), count)
+			}
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetActsByYearMonitorPolski(t *testing.T) {
+	mockClient := new(MockSejmClient)
+	mockDB := new(MockDB)
+	srv := service.NewActServiceWithConfig(mockClient, mockDB, 5*time.Second, 24*time.Hour)
+
+	acts := []sejm.Act{{ID: "MP/2024/1", Publisher: sejm.PublisherMP, Status: "obowiązujący"}}
+	expectEmptyCache(mockDB, sejm.PublisherMP, 2024)
+	mockClient.On("GetActs", mock.Anything, sejm.PublisherMP, 2024).Return(acts, nil).Once()
+	mockDB.On("StoreActs", mock.Anything, sejm.PublisherMP, 2024, acts).Return(nil, nil).Once()
+
+	data, err := srv.GetActsByYear(context.Background(), sejm.PublisherMP, 2024)
+	assert.NoError(t, err)
+	assert.Equal(t, acts, data.Obowiazujace)
+
+	mockClient.AssertExpectations(t)
+	mockDB.AssertExpectations(t)
+}
+
+func TestGetActsByYearTruncatedListingKeepsCache(t *testing.T) {
+	mockClient := new(MockSejmClient)
+	mockDB := new(MockDB)
+	srv := service.NewActServiceWithConfig(mockClient, mockDB, 5*time.Second, 24*time.Hour)
+
+	// StoreActs is not expected: a partial listing must never replace the cache
+	cached := []sejm.Act{{ID: "DU/2024/1", Status: "obowiązujący"}}
+	mockDB.On("GetCacheEntry", mock.Anything, db.CacheKindActs, db.YearKey(sejm.PublisherDU, 2024)).Return(yearEntry(25*time.Hour), nil).Once()
+	mockDB.On("GetActs", mock.Anything, sejm.PublisherDU, 2024).Return(cached, nil).Once()
+	mockClient.On("GetActs", mock.Anything, sejm.PublisherDU, 2024).Return(nil, sejm.ErrTooManyPages).Once()
+
+	data, err := srv.GetActsByYear(context.Background(), sejm.PublisherDU, 2024)
+	srv.Wait()
+	require.NoError(t, err)
+	assert.True(t, data.Stale)
+	assert.Equal(t, cached, data.Obowiazujace)
+
+	mockClient.AssertExpectations(t)
+	mockDB.AssertExpectations(t)
+}
+
+func TestUnknownPublisher(t *testing.T) {
+	mockClient := new(MockSejmClient)
+	mockDB := new(MockDB)
+	srv := service.NewActServiceWithConfig(mockClient, mockDB, 5*time.Second, 24*time.Hour)
+	ctx := context.Background()
+
+	_, err := srv.GetAvailableYears(ctx, "XX")
+	assert.ErrorIs(t, err, service.ErrUnknownPublisher)
+
+	_, err = srv.GetActsByYear(ctx, "XX", 2024)
+	assert.ErrorIs(t, err, service.ErrUnknownPublisher)
+
+	mockClient.AssertExpectations(t)
+	mockDB.AssertExpectations(t)
+}
+
+func TestSearchCachedActs(t *testing.T) {
+	mockClient := new(MockSejmClient)
+	mockDB := new(MockDB)
+	srv := service.NewActServiceWithConfig(mockClient, mockDB, 5*time.Second, 24*time.Hour)
+	ctx := context.Background()
+
+	acts := []sejm.Act{{ID: "DU/2024/7", Title: "Ustawa o podatku"}}
+	mockDB.On("SearchActs", mock.Anything, "podatek", 50).Return(acts, nil).Once()
+	mockDB.On("SearchActs", mock.Anything, "vat", 200).Return([]sejm.Act{}, nil).Once()
+
+	results, err := srv.SearchCachedActs(ctx, "  podatek ", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, &service.SearchResults{Query: "podatek", Acts: acts}, results)
+
+	// Limits are capped
+	_, err = srv.SearchCachedActs(ctx, "vat", 1000)
+	assert.NoError(t, err)
+
+	_, err = srv.SearchCachedActs(ctx, "   ", 0)
+	assert.ErrorIs(t, err, service.ErrEmptyQuery)
+
+	mockClient.AssertExpectations(t)
+	mockDB.AssertExpectations(t)
+}
+
+func TestSearchActs(t *testing.T) {
+	query := sejm.SearchQuery{Title: "  Podatek ", Publisher: "du"}
+	normalized := query.Normalize()
+	key := query.Key()
+	result := &sejm.SearchResult{Items: []sejm.Act{{ID: "DU/2024/7"}}, TotalCount: 1}
+
+	tests := []struct {
+		name       string
+		setupMocks func(*MockSejmClient, *MockDB)
+	}{
+		{
+			name: "Fresh page from cache",
+			setupMocks: func(_ *MockSejmClient, md *MockDB) {
+				md.On("GetSearchResult", mock.Anything, key).Return(result, time.Hour, nil).Once()
+			},
+		},
+		{
+			name: "Expired page from API",
+			setupMocks: func(mc *MockSejmClient, md *MockDB) {
+				md.On("GetSearchResult", mock.Anything, key).Return(result, 25*time.Hour, nil).Once()
+				mc.On("SearchActs", mock.Anything, normalized).Return(result, nil).Once()
+				md.On("StoreSearchResult", mock.Anything, key, result).Return(nil).Once()
+			},
+		},
+		{
+			name: "Cache error, page from API",
+			setupMocks: func(mc *MockSejmClient, md *MockDB) {
+				md.On("GetSearchResult", mock.Anything, key).Return(nil, 0*time.Hour, errors.New("cache error")).Once()
+				mc.On("SearchActs", mock.Anything, normalized).Return(result, nil).Once()
+				md.On("StoreSearchResult", mock.Anything, key, result).Return(errors.New("store error")).Once()
+			},
+		},
+		{
+			name: "Circuit open, stale page from cache",
+			setupMocks: func(mc *MockSejmClient, md *MockDB) {
+				md.On("GetSearchResult", mock.Anything, key).Return(result, 25*time.Hour, nil).Once()
+				mc.On("SearchActs", mock.Anything, normalized).Return(nil, sejm.ErrCircuitOpen).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := new(MockSejmClient)
+			mockDB := new(MockDB)
+			srv := service.NewActServiceWithConfig(mockClient, mockDB, 5*time.Second, 24*time.Hour)
+			tt.setupMocks(mockClient, mockDB)
+
+			got, err := srv.SearchActs(context.Background(), query)
+			assert.NoError(t, err)
+			assert.Equal(t, result, got)
+
+			mockClient.AssertExpectations(t)
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSearchActsInvalidQuery(t *testing.T) {
+	mockClient := new(MockSejmClient)
+	mockDB := new(MockDB)
+	srv := service.NewActServiceWithConfig(mockClient, mockDB, 5*time.Second, 24*time.Hour)
+
+	_, err := srv.SearchActs(context.Background(), sejm.SearchQuery{DateFrom: "01.01.2024"})
+	assert.ErrorIs(t, err, sejm.ErrInvalidQuery)
+
+	mockClient.AssertExpectations(t)
+	mockDB.AssertExpectations(t)
+}
+
+func TestRefreshYear(t *testing.T) {
+	mockClient := new(MockSejmClient)
+	mockDB := new(MockDB)
+	srv := service.NewActServiceWithConfig(mockClient, mockDB, 5*time.Second, 24*time.Hour)
+
+	// Refreshing ignores the cache age and always replaces the year
+	acts := []sejm.Act{{ID: "DU/2024/1"}}
+	mockClient.On("GetActs", mock.Anything, sejm.PublisherDU, 2024).Return(acts, nil).Once()
+	mockDB.On("StoreActs", mock.Anything, sejm.PublisherDU, 2024, acts).Return(nil, nil).Once()
+	mockClient.On("GetActs", mock.Anything, sejm.PublisherDU, 2023).Return(nil, errors.New("API error")).Once()
+
+	assert.NoError(t, srv.RefreshYear(context.Background(), sejm.PublisherDU, 2024))
+	assert.Error(t, srv.RefreshYear(context.Background(), sejm.PublisherDU, 2023))
+
+	mockClient.AssertExpectations(t)
+	mockDB.AssertExpectations(t)
+}
+
+func TestRefreshYearNotModified(t *testing.T) {
+	mockClient := new(MockSejmClient)
+	mockDB := new(MockDB)
+	srv := service.NewActServiceWithConfig(mockClient, mockDB, 5*time.Second, 24*time.Hour)
+
+	// An unchanged listing only marks the cached acts as fresh
+	cached := []sejm.Act{{ID: "DU/2024/1"}}
+	mockClient.On("GetActs", mock.Anything, sejm.PublisherDU, 2024).Return(nil, sejm.ErrNotModified).Once()
+	mockDB.On("TouchCacheEntry", mock.Anything, db.CacheKindActs, db.YearKey(sejm.PublisherDU, 2024)).Return(true, nil).Once()
+	mockDB.On("GetActs", mock.Anything, sejm.PublisherDU, 2024).Return(cached, nil).Once()
+
+	// With nothing cached the listing is downloaded in full
+	acts := []sejm.Act{{ID: "DU/2023/1"}}
+	mockClient.On("GetActs", mock.Anything, sejm.PublisherDU, 2023).Return(nil, sejm.ErrNotModified).Once()
+	mockDB.On("TouchCacheEntry", mock.Anything, db.CacheKindActs, db.YearKey(sejm.PublisherDU, 2023)).Return(false, nil).Once()
+	mockClient.On("GetActs", mock.Anything, sejm.PublisherDU, 2023).Return(acts, nil).Once()
+	mockDB.On("StoreActs", mock.Anything, sejm.PublisherDU, 2023, acts).Return(nil, nil).Once()
+
+	assert.NoError(t, srv.RefreshYear(context.Background(), sejm.PublisherDU, 2024))
+	assert.NoError(t, srv.RefreshYear(context.Background(), sejm.PublisherDU, 2023))
+
+	mockClient.AssertExpectations(t)
+	mockDB.AssertExpectations(t)
+}
+
+// memoryValidators is a sejm.ValidatorStore kept in memory
+type memoryValidators struct {
+	mu         sync.Mutex
+	validators map[string]sejm.Validators
+}
+
+func (m *memoryValidators) GetValidators(_ context.Context, url string) (*sejm.Validators, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	validators, ok := m.validators[url]
+	if !ok {
+		return nil, nil
+	}
+	return &validators, nil
+}
+
+func (m *memoryValidators) StoreValidators(_ context.Context, url string, validators sejm.Validators) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.validators[url] = validators
+	return nil
+}
+
+func TestRefreshYearKeepsValidatorsOfStoredListings(t *testing.T) {
+	var conditional int
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" {
+			conditional++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(`{"items":[{"ELI":"DU/2024/1","pos":1,"year":2024}],"totalCount":1}`))
+	}))
+	defer upstream.Close()
+
+	validators := &memoryValidators{validators: make(map[string]sejm.Validators)}
+	client := sejm.NewClientWithURL(upstream.URL, sejm.WithValidatorStore(validators))
+	mockDB := new(MockDB)
+	srv := service.NewActServiceWithConfig(client, mockDB, 5*time.Second, 24*time.Hour)
+	ctx := context.Background()
+
+	// A listing that could not be stored leaves no validators to revalidate against
+	mockDB.On("StoreActs", mock.Anything, sejm.PublisherDU, 2024, mock.Anything).Return(nil, errors.New("store error")).Once()
+	require.NoError(t, srv.RefreshYear(ctx, sejm.PublisherDU, 2024))
+	assert.Empty(t, validators.validators)
+
+	// So the next refresh downloads it in full, keeping the validators once it is stored
+	mockDB.On("StoreActs", mock.Anything, sejm.PublisherDU, 2024, mock.Anything).Return(nil, nil).Once()
+	require.NoError(t, srv.RefreshYear(ctx, sejm.PublisherDU, 2024))
+	assert.Zero(t, conditional)
+	assert.Len(t, validators.validators, 1)
+
+	mockDB.AssertExpectations(t)
+}
+
+func TestRefreshRecentlyViewedActs(t *testing.T) {
+	mockClient := new(MockSejmClient)
+	mockDB := new(MockDB)
+	srv := service.NewActServiceWithConfig(mockClient, mockDB, 5*time.Second, 24*time.Hour)
+	ctx := context.Background()
+
+	for _, id := range []string{"DU/2024/1", "DU/2024/2"} {
+		mockDB.On("GetActDetails", mock.Anything, id).Return(&sejm.ActDetails{ID: id}, nil).Once()
+		mockDB.On("GetCacheEntry", mock.Anything, db.CacheKindDetails, id).Return(detailsEntry(time.Hour), nil).Once()
+	}
+	_, err := srv.GetActDetails(ctx, mustParseELI(t, "DU/2024/1"))
+	assert.NoError(t, err)
+	time.Sleep(time.Millisecond)
+	_, err = srv.GetActDetails(ctx, mustParseELI(t, "DU/2024/2"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"DU/2024/2", "DU/2024/1"}, srv.RecentlyViewedActs())
+
+	fresh := &sejm.ActDetails{ID: "DU/2024/1", Status: "uchylony"}
+	mockClient.On("GetActDetails", mock.Anything, "DU/2024/1").Return(fresh, nil).Once()
+	mockDB.On("StoreActDetails", mock.Anything, fresh).Return(nil).Once()
+	assert.NoError(t, srv.RefreshActDetails(ctx, "DU/2024/1"))
+
+	mockClient.AssertExpectations(t)
+	mockDB.AssertExpectations(t)
+}
+
+func TestGetActHistory(t *testing.T) {
+	mockClient := new(MockSejmClient)
+	mockDB := new(MockDB)
+	srv := service.NewActServiceWithConfig(mockClient, mockDB, 5*time.Second, 24*time.Hour)
+	ctx := context.Background()
+
+	changes := []db.ActChange{{ActID: "DU/2024/1", Field: "status", OldValue: "obowiązujący", NewValue: "uchylony"}}
+	mockDB.On("GetActChanges", mock.Anything, "DU/2024/1").Return(changes, nil).Once()
+	mockDB.On("GetActChanges", mock.Anything, "DU/2024/2").Return(nil, errors.New("db error")).Once()
+
+	history, err := srv.GetActHistory(ctx, mustParseELI(t, "DU/2024/1"))
+	assert.NoError(t, err)
+	assert.Equal(t, changes, history)
+
+	_, err = srv.GetActHistory(ctx, mustParseELI(t, "DU/2024/2"))
+	assert.Error(t, err)
+
+	mockDB.AssertExpectations(t)
+}
+
+func TestGetFeedEntries(t *testing.T) {
+	mockDB := new(MockDB)
+	srv := service.NewActServiceWithConfig(new(MockSejmClient), mockDB, 5*time.Second, 24*time.Hour)
+	ctx := context.Background()
+
+	entries := []db.FeedEntry{{Act: sejm.Act{ID: "DU/2024/1"}}}
+	mockDB.On("GetFeedEntries", mock.Anything, db.FeedFilter{Status: "uchylony"}, 100).Return(entries, nil).Once()
+
+	result, err := srv.GetFeedEntries(ctx, db.FeedFilter{Status: " Uchylony "})
+	assert.NoError(t, err)
+	assert.Equal(t, entries, result)
+
+	_, err = srv.GetFeedEntries(ctx, db.FeedFilter{Publisher: "XX", Year: 2024})
+	assert.ErrorIs(t, err, service.ErrUnknownPublisher)
+
+	mockDB.AssertExpectations(t)
+}
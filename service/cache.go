@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"ustawka/db"
+	"ustawka/sejm"
+	"ustawka/tracing"
+)
+
+// ErrInvalidScope is returned for cache invalidations not naming exactly one of an act,
+// a year or all cached data
+var ErrInvalidScope = errors.New("invalid cache scope")
+
+// CacheScope selects cached data to invalidate: an act (DU/2024/1) with its texts,
+// a year (DU/2024) with its acts, or all of it
+type CacheScope struct {
+	Act  string `json:"act,omitempty"`
+	Year string `json:"year,omitempty"`
+	All  bool   `json:"all,omitempty"`
+}
+
+// fresh reports whether a cache entry is within the TTL of its kind and wasn't invalidated;
+// results the API had no data for expire after the negative TTL, and kinds without a TTL never do.
+// Everything cached is fresh while offline.
+func (s *ActService) fresh(entry *db.CacheEntry) bool {
+	if s.offline {
+		return true
+	}
+	if entry.Invalidated {
+		return false
+	}
+	ttl, ok := s.ttls[entry.Kind]
+	if entry.Outcome != db.OutcomeOK {
+		ttl, ok = s.negativeTTL, true
+	}
+	return !ok || entry.Age() < ttl
+}
+
+// knownMissing reports whether the API recently answered 404 for a resource
+func (s *ActService) knownMissing(ctx context.Context, kind, key string) bool {
+	entry, err := s.db.GetCacheEntry(ctx, kind, key)
+	if err != nil {
+		slog.Error("Error reading cache entry", "kind", kind, "key", key, "error", err)
+		return false
+	}
+	return entry != nil && entry.Outcome == db.OutcomeNotFound && s.fresh(entry)
+}
+
+// rememberMissing records that the API answered 404 for a resource
+func (s *ActService) rememberMissing(ctx context.Context, kind, key string) {
+	entry := db.CacheEntry{Kind: kind, Key: key, Outcome: db.OutcomeNotFound}
+	if err := s.db.StoreCacheEntry(ctx, entry); err != nil {
+		slog.Error("Error storing cache entry", "kind", kind, "key", key, "error", err)
+	}
+}
+
+// rememberFailedRefresh records that refreshing a cached resource failed
+func (s *ActService) rememberFailedRefresh(ctx context.Context, kind, key string) {
+	entry := db.CacheEntry{Kind: kind, Key: key, Outcome: db.OutcomeFailed}
+	if err := s.db.StoreCacheEntry(ctx, entry); err != nil {
+		slog.Error("Error storing cache entry", "kind", kind, "key", key, "error", err)
+	}
+}
+
+// InvalidateCache marks cached data within the scope to be fetched again from the API when
+// next requested, returning the number of resources marked. Cached data is kept meanwhile,
+// so an invalidated year is still served while it is refreshed in the background.
+func (s *ActService) InvalidateCache(ctx context.Context, scope CacheScope) (_ int64, err error) {
+	ctx, span := tracer.Start(ctx, "service.InvalidateCache")
+	defer tracing.End(span, &err)
+
+	prefix, err := scope.prefix()
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := s.db.InvalidateCacheEntries(ctx, prefix)
+	if err != nil {
+		return 0, fmt.Errorf("failed to invalidate cache: %w", err)
+	}
+
+	slog.Info("Cache invalidated", "scope", prefix, "entries", count)
+	return count, nil
+}
+
+// prefix checks the scope and returns the cache key prefix it covers
+func (c CacheScope) prefix() (string, error) {
+	act, year := strings.TrimSpace(c.Act), strings.ToUpper(strings.TrimSpace(c.Year))
+	switch {
+	case c.All && act == "" && year == "":
+		return "", nil
+	case act != "" && year == "" && !c.All:
+		id, err := sejm.ParseELI(act)
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", ErrInvalidScope, err)
+		}
+		return id.String(), nil
+	case year != "" && act == "" && !c.All:
+		if !yearTargetPattern.MatchString(year) {
+			return "", fmt.Errorf("%w: malformed year %q", ErrInvalidScope, year)
+		}
+		if publisher, _, _ := strings.Cut(year, "/"); !sejm.IsValidPublisher(publisher) {
+			return "", fmt.Errorf("%w: %w: %s", ErrInvalidScope, ErrUnknownPublisher, publisher)
+		}
+		return year, nil
+	default:
+		return "", fmt.Errorf("%w: expected one of act, year or all", ErrInvalidScope)
+	}
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"ustawka/metrics"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// coalesce runs load once for concurrent callers with the same key, counting the callers
+// of a cache kind that shared another's result. The load ignores cancellation of the
+// caller that started it, so the callers waiting for it don't fail along with it.
+func coalesce[T any](ctx context.Context, group *singleflight.Group, kind, key string, load func(context.Context) (T, error)) (T, error) {
+	leader := false
+	results := group.DoChan(key, func() (any, error) {
+		leader = true
+		return load(context.WithoutCancel(ctx))
+	})
+
+	var zero T
+	select {
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case result := <-results:
+		if !leader {
+			metrics.IncrementCoalesced(kind)
+		}
+		if result.Err != nil {
+			return zero, result.Err
+		}
+		return result.Val.(T), nil
+	}
+}
+
+// actsKey identifies loads of a publisher's year
+func actsKey(publisher string, year int) string {
+	return fmt.Sprintf("acts/%s/%d", publisher, year)
+}
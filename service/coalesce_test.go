@@ -0,0 +1,105 @@
+package service_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+	"ustawka/db"
+	"ustawka/metrics"
+	"ustawka/sejm"
+	"ustawka/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// joinDelay is how long callers get to join a fetch held open by a test
+const joinDelay = 50 * time.Millisecond
+
+func TestConcurrentCacheMissesShareOneFetch(t *testing.T) {
+	mockClient := new(MockSejmClient)
+	mockDB := new(MockDB)
+	srv := service.NewActServiceWithConfig(mockClient, mockDB, 5*time.Second, 24*time.Hour)
+
+	const callers = 10
+	id := mustParseELI(t, "DU/2024/1")
+	acts := []sejm.Act{{ID: "DU/2024/1", Status: "obowiązujący"}}
+	details := &sejm.ActDetails{ID: "DU/2024/1", Title: "Ustawa"}
+	release := make(chan time.Time)
+
+	// Every caller misses the cache, but the API is called once per year and act
+	mockDB.On("GetCacheEntry", mock.Anything, db.CacheKindActs, db.YearKey(sejm.PublisherDU, 2024)).Return(nil, nil).Times(callers)
+	mockClient.On("GetActs", mock.Anything, sejm.PublisherDU, 2024).WaitUntil(release).Return(acts, nil).Once()
+	mockDB.On("StoreActs", mock.Anything, sejm.PublisherDU, 2024, acts).Return(nil, nil).Once()
+	mockDB.On("GetActDetails", mock.Anything, id.String()).Return(nil, nil).Times(callers)
+	mockDB.On("GetCacheEntry", mock.Anything, db.CacheKindDetails, id.String()).Return(nil, nil).Times(callers)
+	mockClient.On("GetActDetails", mock.Anything, id.String()).WaitUntil(release).Return(details, nil).Once()
+	mockDB.On("StoreActDetails", mock.Anything, details).Return(nil).Once()
+
+	before := metrics.GetMetrics()["coalesced"]
+	var wg sync.WaitGroup
+	for range callers {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			data, err := srv.GetActsByYear(context.Background(), sejm.PublisherDU, 2024)
+			if assert.NoError(t, err) {
+				assert.Equal(t, acts, data.Obowiazujace)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			got, err := srv.GetActDetails(context.Background(), id)
+			assert.NoError(t, err)
+			assert.Equal(t, details, got)
+		}()
+	}
+	time.Sleep(joinDelay)
+	close(release)
+	wg.Wait()
+
+	mockClient.AssertExpectations(t)
+	mockDB.AssertExpectations(t)
+	assert.Equal(t, uint64(2*(callers-1)), metrics.GetMetrics()["coalesced"]-before)
+}
+
+func TestCoalescedFetchOutlivesCancelledCaller(t *testing.T) {
+	mockClient := new(MockSejmClient)
+	mockDB := new(MockDB)
+	srv := service.NewActServiceWithConfig(mockClient, mockDB, 5*time.Second, 24*time.Hour)
+
+	id := mustParseELI(t, "DU/2024/1")
+	details := &sejm.ActDetails{ID: "DU/2024/1"}
+	release := make(chan time.Time)
+
+	mockDB.On("GetActDetails", mock.Anything, id.String()).Return(nil, nil).Twice()
+	mockDB.On("GetCacheEntry", mock.Anything, db.CacheKindDetails, id.String()).Return(nil, nil).Twice()
+	mockClient.On("GetActDetails", mock.Anything, id.String()).WaitUntil(release).Return(details, nil).Once()
+	mockDB.On("StoreActDetails", mock.Anything, details).Return(nil).Once()
+
+	// The caller that started the fetch gives up while another one waits for it
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error)
+	go func() {
+		_, err := srv.GetActDetails(ctx, id)
+		first <- err
+	}()
+	time.Sleep(joinDelay)
+
+	second := make(chan error)
+	go func() {
+		got, err := srv.GetActDetails(context.Background(), id)
+		assert.Equal(t, details, got)
+		second <- err
+	}()
+	time.Sleep(joinDelay)
+
+	cancel()
+	assert.ErrorIs(t, <-first, context.Canceled)
+	close(release)
+	assert.NoError(t, <-second)
+
+	mockClient.AssertExpectations(t)
+	mockDB.AssertExpectations(t)
+}
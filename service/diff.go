@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"ustawka/acttext"
+	"ustawka/db"
+	"ustawka/sejm"
+	"ustawka/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrTextVersionNotFound is returned when a requested text version was never downloaded
+var ErrTextVersionNotFound = errors.New("text version not found")
+
+// TextDiff compares two downloaded versions of the HTML text of an act
+type TextDiff struct {
+	Versions []db.ActTextVersion   `json:"versions"`
+	From     *db.ActTextVersion    `json:"from,omitempty"`
+	To       *db.ActTextVersion    `json:"to,omitempty"`
+	Articles []acttext.ArticleDiff `json:"articles"`
+}
+
+// GetActTextDiff compares the articles of two versions of the HTML text of an act.
+// Zero version IDs select the latest version and the one before it.
+func (s *ActService) GetActTextDiff(ctx context.Context, id sejm.ELI, from, to int64) (_ *TextDiff, err error) {
+	ctx, span := tracer.Start(ctx, "service.GetActTextDiff", trace.WithAttributes(attribute.String("act.id", id.String())))
+	defer tracing.End(span, &err)
+
+	// Fetching the current text records a new version if the act has changed
+	text, err := s.GetActText(ctx, id, sejm.TextHTML)
+	if err != nil {
+		return nil, err
+	}
+	if err := text.Content.Close(); err != nil {
+		slog.Error("Error closing act text", "error", err)
+	}
+
+	versions, err := s.db.GetActTextVersions(ctx, id, sejm.TextHTML)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read text versions: %w", err)
+	}
+
+	diff := &TextDiff{Versions: versions, Articles: make([]acttext.ArticleDiff, 0)}
+	if diff.To, err = selectVersion(versions, to, len(versions)-1); err != nil {
+		return nil, err
+	}
+	if diff.From, err = selectVersion(versions, from, versionIndex(versions, diff.To)-1); err != nil {
+		return nil, err
+	}
+	if diff.From == nil || diff.To == nil {
+		// A single version has nothing to compare with
+		return diff, nil
+	}
+
+	fromDoc, err := s.versionDocument(ctx, text.ActID, diff.From.Hash)
+	if err != nil {
+		return nil, err
+	}
+	toDoc, err := s.versionDocument(ctx, text.ActID, diff.To.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	diff.Articles = acttext.Diff(fromDoc, toDoc)
+	return diff, nil
+}
+
+// versionDocument returns the structured form of a stored text version
+func (s *ActService) versionDocument(ctx context.Context, actID, hash string) (*acttext.Document, error) {
+	content, err := s.blobs.Open(hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open text version: %w", err)
+	}
+	defer func() {
+		if err := content.Close(); err != nil {
+			slog.Error("Error closing act text", "error", err)
+		}
+	}()
+
+	return s.parseDocument(ctx, actID, hash, content)
+}
+
+// selectVersion returns the version with an ID, or the version at a default index if the ID is zero
+func selectVersion(versions []db.ActTextVersion, id int64, defaultIndex int) (*db.ActTextVersion, error) {
+	if id == 0 {
+		if defaultIndex < 0 || defaultIndex >= len(versions) {
+			return nil, nil
+		}
+		return &versions[defaultIndex], nil
+	}
+
+	for i := range versions {
+		if versions[i].ID == id {
+			return &versions[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %d", ErrTextVersionNotFound, id)
+}
+
+// versionIndex returns the position of a version in the list, or len(versions) if it is nil
+func versionIndex(versions []db.ActTextVersion, version *db.ActTextVersion) int {
+	for i := range versions {
+		if version != nil && versions[i].ID == version.ID {
+			return i
+		}
+	}
+	return len(versions)
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"ustawka/db"
+	"ustawka/metrics"
+	"ustawka/sejm"
+	"ustawka/tracing"
+)
+
+// feedLimit is the number of most recently changed acts in a feed
+const feedLimit = 100
+
+// GetFeedEntries returns cached acts for a feed, most recently changed first
+func (s *ActService) GetFeedEntries(ctx context.Context, filter db.FeedFilter) (_ []db.FeedEntry, err error) {
+	ctx, span := tracer.Start(ctx, "service.GetFeedEntries")
+	defer tracing.End(span, &err)
+
+	metrics.IncrementAPI()
+	if filter.Publisher != "" && !sejm.IsValidPublisher(filter.Publisher) {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPublisher, filter.Publisher)
+	}
+	filter.Status = strings.ToLower(strings.TrimSpace(filter.Status))
+	filter.Keyword = strings.TrimSpace(filter.Keyword)
+
+	entries, err := s.db.GetFeedEntries(ctx, filter, feedLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feed entries: %w", err)
+	}
+
+	return entries, nil
+}
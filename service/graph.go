@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"ustawka/db"
+	"ustawka/sejm"
+	"ustawka/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Limits of the reference graph
+const (
+	DefaultGraphDepth = 1
+	MaxGraphDepth     = 3
+	// maxGraphNodes keeps graphs of heavily amended acts readable
+	maxGraphNodes = 200
+)
+
+// Graph is the neighbourhood of an act in the reference graph
+type Graph struct {
+	Nodes []GraphNode  `json:"nodes"`
+	Edges []db.ActLink `json:"edges"`
+}
+
+// GraphNode is an act in the reference graph; Title is empty for acts missing from the cache
+type GraphNode struct {
+	ID    string `json:"id"`
+	Title string `json:"title,omitempty"`
+	// Depth is the number of references followed from the root act
+	Depth int `json:"depth"`
+}
+
+// GetActGraph follows references from an act up to depth levels, using details already in
+// the cache for all acts but the root, which is fetched if needed
+func (s *ActService) GetActGraph(ctx context.Context, id sejm.ELI, depth int) (_ *Graph, err error) {
+	ctx, span := tracer.Start(ctx, "service.GetActGraph", trace.WithAttributes(attribute.String("act.id", id.String())))
+	defer tracing.End(span, &err)
+
+	depth = max(1, min(depth, MaxGraphDepth))
+
+	// Loading the root details stores its references
+	root, err := s.GetActDetails(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := &Graph{
+		Nodes: []GraphNode{{ID: root.ID, Depth: 0}},
+		Edges: make([]db.ActLink, 0),
+	}
+	seen := map[string]bool{root.ID: true}
+	frontier := []string{root.ID}
+
+	for level := 1; level <= depth && len(frontier) > 0; level++ {
+		links, err := s.db.GetActLinks(ctx, frontier)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read act links: %w", err)
+		}
+
+		frontier = nil
+		for _, link := range links {
+			if !seen[link.Target] {
+				if len(graph.Nodes) >= maxGraphNodes {
+					continue
+				}
+				seen[link.Target] = true
+				graph.Nodes = append(graph.Nodes, GraphNode{ID: link.Target, Depth: level})
+				frontier = append(frontier, link.Target)
+			}
+			graph.Edges = append(graph.Edges, link)
+		}
+	}
+
+	ids := make([]string, len(graph.Nodes))
+	for i, node := range graph.Nodes {
+		ids[i] = node.ID
+	}
+	titles, err := s.db.GetActTitles(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read act titles: %w", err)
+	}
+	for i := range graph.Nodes {
+		graph.Nodes[i].Title = titles[graph.Nodes[i].ID]
+	}
+	graph.Nodes[0].Title = root.Title
+
+	return graph, nil
+}
+
+// IncomingReferences lists cached acts referring to an act
+type IncomingReferences struct {
+	// CitedBy lists acts giving the act as their legal basis
+	CitedBy []ReferringAct `json:"citedBy"`
+	// AmendedBy lists acts listing the act among the acts they amend
+	AmendedBy []ReferringAct `json:"amendedBy"`
+}
+
+// ReferringAct is a cached act referring to another act
+type ReferringAct struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Date  string `json:"date,omitempty"`
+}
+
+// GetIncomingReferences finds cached acts that cite or amend an act, from the references
+// stored with their details
+func (s *ActService) GetIncomingReferences(ctx context.Context, id sejm.ELI) (_ *IncomingReferences, err error) {
+	ctx, span := tracer.Start(ctx, "service.GetIncomingReferences", trace.WithAttributes(attribute.String("act.id", id.String())))
+	defer tracing.End(span, &err)
+
+	links, err := s.db.GetIncomingLinks(ctx, []string{id.String()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read incoming links: %w", err)
+	}
+
+	sources := make([]string, 0, len(links))
+	for _, link := range links {
+		sources = append(sources, link.Source)
+	}
+	titles, err := s.db.GetActTitles(ctx, sources)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read act titles: %w", err)
+	}
+
+	incoming := &IncomingReferences{CitedBy: make([]ReferringAct, 0), AmendedBy: make([]ReferringAct, 0)}
+	for _, link := range links {
+		act := ReferringAct{ID: link.Source, Title: titles[link.Source], Date: link.Date}
+		switch link.Kind {
+		case db.LinkLegalBasis:
+			incoming.CitedBy = append(incoming.CitedBy, act)
+		case db.LinkAmendedAct:
+			incoming.AmendedBy = append(incoming.AmendedBy, act)
+		}
+	}
+
+	return incoming, nil
+}
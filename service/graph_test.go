@@ -0,0 +1,74 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+	"ustawka/db"
+	"ustawka/sejm"
+	"ustawka/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetActGraph(t *testing.T) {
+	mockDB := new(MockDB)
+	srv := service.NewActServiceWithConfig(new(MockSejmClient), mockDB, 5*time.Second, 24*time.Hour)
+	ctx := context.Background()
+
+	mockDB.On("GetActDetails", mock.Anything, "DU/2024/1").
+		Return(&sejm.ActDetails{ID: "DU/2024/1", Title: "Ustawa o przykładach"}, nil)
+	mockDB.On("GetCacheEntry", mock.Anything, db.CacheKindDetails, "DU/2024/1").Return(detailsEntry(time.Hour), nil)
+	mockDB.On("GetActLinks", mock.Anything, []string{"DU/2024/1"}).Return([]db.ActLink{
+		{Source: "DU/2024/1", Target: "DU/2024/50", Kind: db.LinkAmendingAct},
+		{Source: "DU/2024/1", Target: "DU/1997/78", Kind: db.LinkLegalBasis},
+	}, nil)
+	mockDB.On("GetActLinks", mock.Anything, []string{"DU/2024/50", "DU/1997/78"}).Return([]db.ActLink{
+		// Cycles do not add nodes twice
+		{Source: "DU/2024/50", Target: "DU/2024/1", Kind: db.LinkRepealedAct},
+	}, nil)
+	mockDB.On("GetActTitles", mock.Anything, mock.Anything).
+		Return(map[string]string{"DU/2024/50": "Ustawa zmieniająca"}, nil)
+
+	graph, err := srv.GetActGraph(ctx, mustParseELI(t, "DU/2024/1"), 2)
+	require.NoError(t, err)
+	assert.Equal(t, []service.GraphNode{
+		{ID: "DU/2024/1", Title: "Ustawa o przykładach", Depth: 0},
+		{ID: "DU/2024/50", Title: "Ustawa zmieniająca", Depth: 1},
+		{ID: "DU/1997/78", Depth: 1},
+	}, graph.Nodes)
+	assert.Len(t, graph.Edges, 3)
+
+	// Depth is limited to the first level by default
+	graph, err = srv.GetActGraph(ctx, mustParseELI(t, "DU/2024/1"), 0)
+	require.NoError(t, err)
+	assert.Len(t, graph.Nodes, 3)
+	assert.Len(t, graph.Edges, 2)
+	mockDB.AssertNumberOfCalls(t, "GetActLinks", 3)
+}
+
+func TestGetIncomingReferences(t *testing.T) {
+	mockDB := new(MockDB)
+	srv := service.NewActServiceWithConfig(new(MockSejmClient), mockDB, 5*time.Second, 24*time.Hour)
+	ctx := context.Background()
+
+	mockDB.On("GetIncomingLinks", mock.Anything, []string{"DU/1997/78"}).Return([]db.ActLink{
+		{Source: "DU/2024/1", Target: "DU/1997/78", Kind: db.LinkLegalBasis},
+		{Source: "DU/2024/50", Target: "DU/1997/78", Kind: db.LinkAmendedAct, Date: "2024-03-01"},
+		// Other kinds of references are not listed
+		{Source: "DU/2024/60", Target: "DU/1997/78", Kind: db.LinkRepealedAct},
+	}, nil).Once()
+	mockDB.On("GetActTitles", mock.Anything, []string{"DU/2024/1", "DU/2024/50", "DU/2024/60"}).
+		Return(map[string]string{"DU/2024/1": "Ustawa o przykładach"}, nil).Once()
+
+	incoming, err := srv.GetIncomingReferences(ctx, mustParseELI(t, "DU/1997/78"))
+	require.NoError(t, err)
+	assert.Equal(t, &service.IncomingReferences{
+		CitedBy:   []service.ReferringAct{{ID: "DU/2024/1", Title: "Ustawa o przykładach"}},
+		AmendedBy: []service.ReferringAct{{ID: "DU/2024/50", Date: "2024-03-01"}},
+	}, incoming)
+
+	mockDB.AssertExpectations(t)
+}
@@ -0,0 +1,22 @@
+package service
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrOffline is returned for data that isn't cached while the service runs offline
+var ErrOffline = errors.New("not available offline")
+
+// SetOffline makes the service serve cached data only, never calling the ELI API, and
+// treat all of it as fresh; snapshotAt is when the imported snapshot was exported,
+// zero if none was imported
+func (s *ActService) SetOffline(snapshotAt time.Time) {
+	s.offline = true
+	s.snapshotAt = snapshotAt
+}
+
+// Offline reports whether the service runs offline and when its snapshot was exported
+func (s *ActService) Offline() (bool, time.Time) {
+	return s.offline, s.snapshotAt
+}
@@ -0,0 +1,81 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+	"ustawka/db"
+	"ustawka/sejm"
+	"ustawka/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOfflineServesExpiredCache(t *testing.T) {
+	mockClient := new(MockSejmClient)
+	mockDB := new(MockDB)
+	srv := service.NewActServiceWithConfig(mockClient, mockDB, 5*time.Second, 24*time.Hour)
+	snapshotAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	srv.SetOffline(snapshotAt)
+
+	// The API is never called, however old the cache
+	cached := []sejm.Act{{ID: "DU/2024/1", Status: "obowiązujący"}}
+	mockDB.On("GetCacheEntry", mock.Anything, db.CacheKindActs, db.YearKey(sejm.PublisherDU, 2024)).Return(yearEntry(90*24*time.Hour), nil).Once()
+	mockDB.On("GetActs", mock.Anything, sejm.PublisherDU, 2024).Return(cached, nil).Once()
+
+	id := mustParseELI(t, "DU/2024/1")
+	details := &sejm.ActDetails{ID: "DU/2024/1", Title: "Ustawa"}
+	mockDB.On("GetActDetails", mock.Anything, id.String()).Return(details, nil).Once()
+	mockDB.On("GetCacheEntry", mock.Anything, db.CacheKindDetails, id.String()).Return(detailsEntry(90*24*time.Hour), nil).Once()
+
+	query := sejm.SearchQuery{Title: "podatek"}
+	result := &sejm.SearchResult{Items: cached, TotalCount: 1}
+	mockDB.On("GetSearchResult", mock.Anything, query.Key()).Return(result, 90*24*time.Hour, nil).Once()
+
+	data, err := srv.GetActsByYear(context.Background(), sejm.PublisherDU, 2024)
+	srv.Wait()
+	require.NoError(t, err)
+	assert.Equal(t, cached, data.Obowiazujace)
+	assert.False(t, data.Stale)
+	assert.True(t, data.Offline)
+	assert.Equal(t, snapshotAt, data.SnapshotAt)
+
+	got, err := srv.GetActDetails(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, details, got)
+
+	found, err := srv.SearchActs(context.Background(), query)
+	require.NoError(t, err)
+	assert.Equal(t, result, found)
+
+	mockClient.AssertExpectations(t)
+	mockDB.AssertExpectations(t)
+}
+
+func TestOfflineMissingData(t *testing.T) {
+	mockClient := new(MockSejmClient)
+	mockDB := new(MockDB)
+	srv := service.NewActServiceWithConfig(mockClient, mockDB, 5*time.Second, 24*time.Hour)
+	srv.SetOffline(time.Time{})
+
+	id := mustParseELI(t, "DU/2024/1")
+	query := sejm.SearchQuery{Title: "podatek"}
+	expectEmptyCache(mockDB, sejm.PublisherDU, 2024)
+	mockDB.On("GetActDetails", mock.Anything, id.String()).Return(nil, nil).Once()
+	mockDB.On("GetCacheEntry", mock.Anything, db.CacheKindDetails, id.String()).Return(nil, nil).Once()
+	mockDB.On("GetSearchResult", mock.Anything, query.Key()).Return(nil, time.Duration(0), nil).Once()
+
+	_, err := srv.GetActsByYear(context.Background(), sejm.PublisherDU, 2024)
+	assert.ErrorIs(t, err, service.ErrOffline)
+
+	_, err = srv.GetActDetails(context.Background(), id)
+	assert.ErrorIs(t, err, service.ErrOffline)
+
+	_, err = srv.SearchActs(context.Background(), query)
+	assert.ErrorIs(t, err, service.ErrOffline)
+
+	mockClient.AssertExpectations(t)
+	mockDB.AssertExpectations(t)
+}
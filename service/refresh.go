@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+	"ustawka/db"
+	"ustawka/notify"
+	"ustawka/sejm"
+	"ustawka/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Recently viewed acts tracked for background refresh
+const (
+	recentViewsWindow = 7 * 24 * time.Hour
+	maxRecentViews    = 500
+)
+
+// RefreshYear replaces cached acts of a publisher's year with fresh data from the API;
+// storing the listing notifies watchers about status changes, which details refreshes leave out
+func (s *ActService) RefreshYear(ctx context.Context, publisher string, year int) (err error) {
+	ctx, span := tracer.Start(ctx, "service.RefreshYear", trace.WithAttributes(attribute.String("act.publisher", publisher), attribute.Int("act.year", year)))
+	defer tracing.End(span, &err)
+
+	_, err = s.loadActs(ctx, publisher, year)
+	return err
+}
+
+// withKeywords fills in keywords of listing events from cached act details, so that
+// keyword watches learn about status changes too
+func (s *ActService) withKeywords(ctx context.Context, events []notify.Event) []notify.Event {
+	for i, event := range events {
+		id, err := sejm.ParseELI(event.ActID)
+		if err != nil {
+			continue
+		}
+		details, err := s.db.GetActDetails(ctx, id)
+		if err != nil {
+			slog.Error("Error reading from cache", "act_id", event.ActID, "error", err)
+			continue
+		}
+		if details != nil {
+			events[i].Keywords = details.Keywords
+		}
+	}
+	return events
+}
+
+// RefreshActDetails replaces cached details of an act with fresh data from the API,
+// notifying watchers about relevant changes
+func (s *ActService) RefreshActDetails(ctx context.Context, actID string) (err error) {
+	ctx, span := tracer.Start(ctx, "service.RefreshActDetails", trace.WithAttributes(attribute.String("act.id", actID)))
+	defer tracing.End(span, &err)
+
+	if _, err := sejm.ParseELI(actID); err != nil {
+		return err
+	}
+
+	_, err = s.loadActDetails(ctx, actID)
+	return err
+}
+
+// notifyListingChanges tells watchers about status changes recorded when storing a listing
+func (s *ActService) notifyListingChanges(ctx context.Context, changes []db.ActChange, acts []sejm.Act) {
+	if s.notifier != nil {
+		s.notifier.Notify(ctx, s.withKeywords(ctx, notify.StatusChanges(changes, acts)))
+	}
+}
+
+// notifyDetailsChanges tells watchers about changes between cached and fetched act details
+func (s *ActService) notifyDetailsChanges(ctx context.Context, before, after *sejm.ActDetails) {
+	if s.notifier != nil {
+		s.notifier.Notify(ctx, notify.DetectChanges(before, after))
+	}
+}
+
+// RecentlyViewedActs returns IDs of acts whose details were requested recently, most recent first
+func (s *ActService) RecentlyViewedActs() []string {
+	return s.views.list(time.Now().Add(-recentViewsWindow))
+}
+
+// ActsToRefresh returns IDs of acts whose details are kept fresh in the background:
+// recently viewed acts followed by watched ones
+func (s *ActService) ActsToRefresh(ctx context.Context) []string {
+	ctx, span := tracer.Start(ctx, "service.ActsToRefresh")
+	defer span.End()
+
+	ids := s.RecentlyViewedActs()
+	for _, id := range s.watchedActs(ctx) {
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// recentViews remembers when act details were last requested
+type recentViews struct {
+	mu    sync.Mutex
+	views map[string]time.Time
+}
+
+// newRecentViews creates an empty view tracker
+func newRecentViews() *recentViews {
+	return &recentViews{views: make(map[string]time.Time)}
+}
+
+// record marks an act as viewed now, evicting the oldest views beyond the limit
+func (v *recentViews) record(actID string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.views[actID] = time.Now()
+	if len(v.views) <= maxRecentViews {
+		return
+	}
+
+	oldest := ""
+	for id, at := range v.views {
+		if oldest == "" || at.Before(v.views[oldest]) {
+			oldest = id
+		}
+	}
+	delete(v.views, oldest)
+}
+
+// list returns acts viewed after the cutoff, most recent first
+func (v *recentViews) list(cutoff time.Time) []string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	ids := make([]string, 0, len(v.views))
+	for id, at := range v.views {
+		if at.After(cutoff) {
+			ids = append(ids, id)
+		}
+	}
+	slices.SortFunc(ids, func(a, b string) int {
+		return v.views[b].Compare(v.views[a])
+	})
+	return ids
+}
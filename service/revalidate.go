@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// revalidations tracks background refreshes of expired cache entries, running at
+// most one per entry at a time
+type revalidations struct {
+	mu      sync.Mutex
+	running map[string]bool
+	wg      sync.WaitGroup
+}
+
+func newRevalidations() *revalidations {
+	return &revalidations{running: make(map[string]bool)}
+}
+
+// start runs refresh in the background unless a refresh of the key is already running
+func (r *revalidations) start(key string, refresh func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.running[key] {
+		return
+	}
+	r.running[key] = true
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer func() {
+			r.mu.Lock()
+			delete(r.running, key)
+			r.mu.Unlock()
+		}()
+		refresh()
+	}()
+}
+
+// revalidateYear refreshes expired cached acts of a publisher's year in the background;
+// the refresh outlives the request but keeps its trace
+func (s *ActService) revalidateYear(ctx context.Context, publisher string, year int) {
+	ctx = context.WithoutCancel(ctx)
+	s.revalidate.start(actsKey(publisher, year), func() {
+		if err := s.RefreshYear(ctx, publisher, year); err != nil {
+			slog.Warn("Error refreshing stale cache", "publisher", publisher, "year", year, "error", err)
+		}
+	})
+}
+
+// Wait blocks until background refreshes of expired cache entries have finished
+func (s *ActService) Wait() {
+	s.revalidate.wg.Wait()
+}
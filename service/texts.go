@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+	"ustawka/acttext"
+	"ustawka/db"
+	"ustawka/metrics"
+	"ustawka/sejm"
+	"ustawka/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// BlobStore keeps downloaded act texts addressed by content hash
+type BlobStore interface {
+	Put(data []byte) (string, error)
+	Open(hash string) (*os.File, error)
+}
+
+// ErrTextsUnavailable is returned when no blob store is configured for act texts
+var ErrTextsUnavailable = errors.New("act texts are unavailable")
+
+// ActText is a cached act text ready to be served; the caller closes Content
+type ActText struct {
+	db.ActText
+	Content io.ReadSeekCloser
+}
+
+// SetBlobStore enables downloading and caching act texts
+func (s *ActService) SetBlobStore(store BlobStore) {
+	s.blobs = store
+}
+
+// GetActText returns a text of an act from the blob store, downloading it on first use
+func (s *ActService) GetActText(ctx context.Context, id sejm.ELI, name string) (_ *ActText, err error) {
+	ctx, span := tracer.Start(ctx, "service.GetActText", trace.WithAttributes(attribute.String("act.id", id.String())))
+	defer tracing.End(span, &err)
+
+	metrics.IncrementAPI()
+	if !sejm.IsValidTextName(name) {
+		return nil, fmt.Errorf("%w: %s", sejm.ErrInvalidTextName, name)
+	}
+	if s.blobs == nil {
+		return nil, ErrTextsUnavailable
+	}
+	actID := id.String()
+	key := db.TextKey(actID, name)
+
+	// Check cache first
+	cached, err := s.db.GetActText(ctx, id, name)
+	if err != nil {
+		slog.Error("Error reading text index", "act_id", actID, "name", name, "error", err)
+	}
+	if err == nil && cached != nil {
+		// A changed act may have a new text; it is downloaded as a new version
+		changeDate := s.cachedChangeDate(ctx, id)
+		if s.textRefreshDue(ctx, key, changeDate != cached.ChangeDate) && !s.offline {
+			text, err := s.loadActText(ctx, actID, name, changeDate)
+			if err == nil {
+				return text, nil
+			}
+			slog.Error("Error refreshing act text, serving previous version", "act_id", actID, "name", name, "error", err)
+			if !errors.Is(err, sejm.ErrTextNotFound) {
+				s.rememberFailedRefresh(ctx, db.CacheKindTexts, key)
+			}
+		}
+
+		content, err := s.blobs.Open(cached.Hash)
+		if err == nil {
+			metrics.IncrementCacheHit(metrics.CacheTexts)
+			return &ActText{ActText: *cached, Content: content}, nil
+		}
+		slog.Error("Error opening cached text", "act_id", actID, "name", name, "error", err)
+	}
+
+	if s.knownMissing(ctx, db.CacheKindTexts, key) {
+		metrics.IncrementCacheHit(metrics.CacheTexts)
+		return nil, fmt.Errorf("%w: %s/%s", sejm.ErrTextNotFound, actID, name)
+	}
+
+	metrics.IncrementCacheMiss(metrics.CacheTexts)
+	return s.loadActText(ctx, actID, name, s.cachedChangeDate(ctx, id))
+}
+
+// textRefreshDue reports whether a cached text is downloaded again: when it expired or was
+// invalidated, or when the act changed unless refreshing it recently failed or found no text
+func (s *ActService) textRefreshDue(ctx context.Context, key string, changed bool) bool {
+	entry, err := s.db.GetCacheEntry(ctx, db.CacheKindTexts, key)
+	if err != nil {
+		slog.Error("Error reading cache entry", "kind", db.CacheKindTexts, "key", key, "error", err)
+		return changed
+	}
+	if entry == nil {
+		return changed
+	}
+	if !s.fresh(entry) {
+		return true
+	}
+	return changed && entry.Outcome == db.OutcomeOK
+}
+
+// cachedChangeDate returns the change date of the cached details of an act, or an empty string
+func (s *ActService) cachedChangeDate(ctx context.Context, id sejm.ELI) string {
+	details, err := s.db.GetActDetails(ctx, id)
+	if err != nil {
+		slog.Error("Error reading cached act details", "act_id", id, "error", err)
+		return ""
+	}
+	if details == nil {
+		return ""
+	}
+	return details.ChangeDate
+}
+
+// loadActText downloads an act text into the blob store and indexes it
+func (s *ActService) loadActText(ctx context.Context, actID, name, changeDate string) (*ActText, error) {
+	if s.offline {
+		return nil, ErrOffline
+	}
+
+	// Create a new context with timeout only for the API call
+	apiCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	doc, err := s.sejmClient.GetActText(apiCtx, actID, name)
+	cancel()
+
+	if errors.Is(err, sejm.ErrTextNotFound) {
+		s.rememberMissing(ctx, db.CacheKindTexts, db.TextKey(actID, name))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch act text: %w", err)
+	}
+
+	metrics.IncrementSejmAPI()
+
+	hash, err := s.blobs.Put(doc.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store act text: %w", err)
+	}
+
+	text := db.ActText{
+		ActID:       actID,
+		Name:        name,
+		ContentType: doc.ContentType,
+		Hash:        hash,
+		Size:        int64(len(doc.Data)),
+		ChangeDate:  changeDate,
+		FetchedAt:   time.Now(),
+		ETag:        doc.ETag,
+	}
+	if err := s.db.StoreActText(ctx, text); err != nil {
+		slog.Error("Error storing text index", "act_id", actID, "name", name, "error", err)
+		// Continue even if the index update fails
+	}
+
+	content, err := s.blobs.Open(hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open act text: %w", err)
+	}
+
+	return &ActText{ActText: text, Content: content}, nil
+}
+
+// GetActDocument returns the structured HTML text of an act, parsing each downloaded version once
+func (s *ActService) GetActDocument(ctx context.Context, id sejm.ELI) (_ *acttext.Document, err error) {
+	ctx, span := tracer.Start(ctx, "service.GetActDocument", trace.WithAttributes(attribute.String("act.id", id.String())))
+	defer tracing.End(span, &err)
+
+	text, err := s.GetActText(ctx, id, sejm.TextHTML)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := text.Content.Close(); err != nil {
+			slog.Error("Error closing act text", "error", err)
+		}
+	}()
+
+	return s.parseDocument(ctx, text.ActID, text.Hash, text.Content)
+}
+
+// parseDocument returns the structured form of an HTML text version, parsing content only
+// when no parse of the same hash is cached
+func (s *ActService) parseDocument(ctx context.Context, actID, hash string, content io.Reader) (*acttext.Document, error) {
+	doc, err := s.db.GetActDocument(ctx, hash)
+	if err != nil {
+		slog.Error("Error reading parsed text", "act_id", actID, "error", err)
+	}
+	if err == nil && doc != nil {
+		return doc, nil
+	}
+
+	doc, err = acttext.Parse(content)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.StoreActDocument(ctx, hash, doc); err != nil {
+		slog.Error("Error storing parsed text", "act_id", actID, "error", err)
+		// Continue even if cache store fails
+	}
+
+	return doc, nil
+}
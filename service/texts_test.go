@@ -0,0 +1,260 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+	"ustawka/acttext"
+	"ustawka/blob"
+	"ustawka/db"
+	"ustawka/sejm"
+	"ustawka/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetActText(t *testing.T) {
+	mockClient := new(MockSejmClient)
+	mockDB := new(MockDB)
+	srv := service.NewActServiceWithConfig(mockClient, mockDB, 5*time.Second, 24*time.Hour)
+	ctx := context.Background()
+
+	_, err := srv.GetActText(ctx, mustParseELI(t, "DU/2024/1"), sejm.TextPDF)
+	assert.ErrorIs(t, err, service.ErrTextsUnavailable)
+
+	blobs, err := blob.NewStore(t.TempDir())
+	require.NoError(t, err)
+	srv.SetBlobStore(blobs)
+
+	// The first request downloads and indexes the text
+	doc := &sejm.Document{ContentType: "application/pdf", Data: []byte("%PDF-1.7")}
+	mockDB.On("GetActText", mock.Anything, "DU/2024/1", sejm.TextPDF).Return(nil, nil).Once()
+	mockDB.On("GetCacheEntry", mock.Anything, db.CacheKindTexts, "DU/2024/1/text.pdf").Return(nil, nil).Once()
+	mockDB.On("GetActDetails", mock.Anything, "DU/2024/1").Return(&sejm.ActDetails{ChangeDate: "2024-01-02T10:00:00"}, nil)
+	mockClient.On("GetActText", mock.Anything, "DU/2024/1", sejm.TextPDF).Return(doc, nil).Once()
+	var indexed db.ActText
+	mockDB.On("StoreActText", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		indexed = args.Get(1).(db.ActText)
+	}).Return(nil).Once()
+
+	text, err := srv.GetActText(ctx, mustParseELI(t, "DU/2024/1"), sejm.TextPDF)
+	require.NoError(t, err)
+	assert.Equal(t, "application/pdf", text.ContentType)
+	assert.Equal(t, int64(8), indexed.Size)
+	assert.Equal(t, "2024-01-02T10:00:00", indexed.ChangeDate)
+	assert.Equal(t, text.Hash, indexed.Hash)
+	data, err := io.ReadAll(text.Content)
+	require.NoError(t, err)
+	assert.Equal(t, doc.Data, data)
+	require.NoError(t, text.Content.Close())
+
+	// Later requests are served from the blob store
+	mockDB.On("GetActText", mock.Anything, "DU/2024/1", sejm.TextPDF).Return(&indexed, nil).Once()
+	mockDB.On("GetCacheEntry", mock.Anything, db.CacheKindTexts, "DU/2024/1/text.pdf").Return(&db.CacheEntry{
+		Kind: db.CacheKindTexts, Key: "DU/2024/1/text.pdf", FetchedAt: time.Now(), Outcome: db.OutcomeOK,
+	}, nil).Once()
+	text, err = srv.GetActText(ctx, mustParseELI(t, "DU/2024/1"), sejm.TextPDF)
+	require.NoError(t, err)
+	data, err = io.ReadAll(text.Content)
+	require.NoError(t, err)
+	assert.Equal(t, doc.Data, data)
+	require.NoError(t, text.Content.Close())
+
+	_, err = srv.GetActText(ctx, mustParseELI(t, "DU/2024/1"), "../secret")
+	assert.ErrorIs(t, err, sejm.ErrInvalidTextName)
+
+	// A text the API doesn't have is remembered as missing
+	mockDB.On("GetActText", mock.Anything, "DU/2024/1", sejm.TextHTML).Return(nil, nil).Twice()
+	mockDB.On("GetCacheEntry", mock.Anything, db.CacheKindTexts, "DU/2024/1/text.html").Return(nil, nil).Once()
+	mockClient.On("GetActText", mock.Anything, "DU/2024/1", sejm.TextHTML).Return(nil, sejm.ErrTextNotFound).Once()
+	missing := db.CacheEntry{Kind: db.CacheKindTexts, Key: "DU/2024/1/text.html", Outcome: db.OutcomeNotFound}
+	mockDB.On("StoreCacheEntry", mock.Anything, missing).Return(nil).Once()
+	_, err = srv.GetActText(ctx, mustParseELI(t, "DU/2024/1"), sejm.TextHTML)
+	assert.ErrorIs(t, err, sejm.ErrTextNotFound)
+
+	missing.FetchedAt = time.Now()
+	mockDB.On("GetCacheEntry", mock.Anything, db.CacheKindTexts, "DU/2024/1/text.html").Return(&missing, nil).Once()
+	_, err = srv.GetActText(ctx, mustParseELI(t, "DU/2024/1"), sejm.TextHTML)
+	assert.ErrorIs(t, err, sejm.ErrTextNotFound)
+
+	mockClient.AssertExpectations(t)
+	mockDB.AssertExpectations(t)
+}
+
+func TestGetActDocument(t *testing.T) {
+	mockClient := new(MockSejmClient)
+	mockDB := new(MockDB)
+	srv := service.NewActServiceWithConfig(mockClient, mockDB, 5*time.Second, 24*time.Hour)
+	blobs, err := blob.NewStore(t.TempDir())
+	require.NoError(t, err)
+	srv.SetBlobStore(blobs)
+	ctx := context.Background()
+
+	doc := &sejm.Document{ContentType: "text/html", Data: []byte("<p>USTAWA</p><p>Art. 1. Tekst.</p>")}
+	mockDB.On("GetActText", mock.Anything, "DU/2024/1", sejm.TextHTML).Return(nil, nil).Once()
+	mockDB.On("GetCacheEntry", mock.Anything, db.CacheKindTexts, "DU/2024/1/text.html").Return(nil, nil).Once()
+	mockDB.On("GetActDetails", mock.Anything, "DU/2024/1").Return(nil, nil)
+	mockClient.On("GetActText", mock.Anything, "DU/2024/1", sejm.TextHTML).Return(doc, nil).Once()
+	mockDB.On("StoreActText", mock.Anything, mock.Anything).Return(nil).Once()
+	mockDB.On("GetActDocument", mock.Anything, mock.Anything).Return(nil, nil).Once()
+	mockDB.On("StoreActDocument", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+
+	parsed, err := srv.GetActDocument(ctx, mustParseELI(t, "DU/2024/1"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"USTAWA"}, parsed.Preamble)
+	require.Len(t, parsed.Units, 1)
+	assert.Equal(t, "art-1", parsed.Units[0].Anchor)
+
+	mockClient.AssertExpectations(t)
+	mockDB.AssertExpectations(t)
+}
+
+func TestGetActTextDiff(t *testing.T) {
+	mockClient := new(MockSejmClient)
+	mockDB := new(MockDB)
+	srv := service.NewActServiceWithConfig(mockClient, mockDB, 5*time.Second, 24*time.Hour)
+	blobs, err := blob.NewStore(t.TempDir())
+	require.NoError(t, err)
+	srv.SetBlobStore(blobs)
+	ctx := context.Background()
+
+	oldHash, err := blobs.Put([]byte("<p>Art. 1. Termin wynosi 7 dni.</p><p>Art. 2. Bez zmian.</p>"))
+	require.NoError(t, err)
+	newDoc := &sejm.Document{ContentType: "text/html", Data: []byte("<p>Art. 1. Termin wynosi 14 dni.</p><p>Art. 2. Bez zmian.</p>")}
+	// Blobs are content addressed, so the hash of the new version is known up front
+	newHash, err := blobs.Put(newDoc.Data)
+	require.NoError(t, err)
+
+	// The act changed since the cached text was downloaded, so a new version is fetched
+	cached := &db.ActText{ActID: "DU/2024/1", Name: sejm.TextHTML, Hash: oldHash, ChangeDate: "2024-01-02T10:00:00"}
+	mockDB.On("GetActText", mock.Anything, "DU/2024/1", sejm.TextHTML).Return(cached, nil)
+	mockDB.On("GetActDetails", mock.Anything, "DU/2024/1").Return(&sejm.ActDetails{ChangeDate: "2024-06-01T10:00:00"}, nil)
+	mockDB.On("GetCacheEntry", mock.Anything, db.CacheKindTexts, "DU/2024/1/text.html").Return(nil, nil)
+	mockClient.On("GetActText", mock.Anything, "DU/2024/1", sejm.TextHTML).Return(newDoc, nil)
+	var stored db.ActText
+	mockDB.On("StoreActText", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(db.ActText)
+	}).Return(nil)
+	mockDB.On("GetActTextVersions", mock.Anything, "DU/2024/1", sejm.TextHTML).Return([]db.ActTextVersion{
+		{ID: 1, Hash: oldHash, ChangeDate: "2024-01-02T10:00:00"},
+		{ID: 2, Hash: newHash, ChangeDate: "2024-06-01T10:00:00"},
+	}, nil)
+	mockDB.On("GetActDocument", mock.Anything, mock.Anything).Return(nil, nil)
+	mockDB.On("StoreActDocument", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	diff, err := srv.GetActTextDiff(ctx, mustParseELI(t, "DU/2024/1"), 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, newHash, stored.Hash)
+	assert.Equal(t, "2024-06-01T10:00:00", stored.ChangeDate)
+	assert.Equal(t, int64(1), diff.From.ID)
+	assert.Equal(t, int64(2), diff.To.ID)
+	require.Len(t, diff.Articles, 1)
+	assert.Equal(t, "art-1", diff.Articles[0].Anchor)
+	assert.Equal(t, []acttext.Op{
+		{Type: acttext.OpEqual, Text: "Termin wynosi"},
+		{Type: acttext.OpDelete, Text: "7"},
+		{Type: acttext.OpInsert, Text: "14"},
+		{Type: acttext.OpEqual, Text: "dni."},
+	}, diff.Articles[0].Ops)
+
+	// Comparing a version with itself shows no changes
+	diff, err = srv.GetActTextDiff(ctx, mustParseELI(t, "DU/2024/1"), 2, 2)
+	require.NoError(t, err)
+	assert.Empty(t, diff.Articles)
+
+	_, err = srv.GetActTextDiff(ctx, mustParseELI(t, "DU/2024/1"), 7, 0)
+	assert.ErrorIs(t, err, service.ErrTextVersionNotFound)
+}
+
+func TestGetActTextRemembersFailedRefresh(t *testing.T) {
+	mockClient := new(MockSejmClient)
+	mockDB := new(MockDB)
+	srv := service.NewActServiceWithConfig(mockClient, mockDB, 5*time.Second, 24*time.Hour)
+	blobs, err := blob.NewStore(t.TempDir())
+	require.NoError(t, err)
+	srv.SetBlobStore(blobs)
+	ctx := context.Background()
+
+	hash, err := blobs.Put([]byte("<p>Art. 1. Tekst.</p>"))
+	require.NoError(t, err)
+
+	// The act changed since the cached text was downloaded, but the API fails once
+	cached := &db.ActText{ActID: "DU/2024/1", Name: sejm.TextHTML, Hash: hash, ChangeDate: "2024-01-02T10:00:00"}
+	mockDB.On("GetActText", mock.Anything, "DU/2024/1", sejm.TextHTML).Return(cached, nil)
+	mockDB.On("GetActDetails", mock.Anything, "DU/2024/1").Return(&sejm.ActDetails{ChangeDate: "2024-06-01T10:00:00"}, nil)
+	mockDB.On("GetCacheEntry", mock.Anything, db.CacheKindTexts, "DU/2024/1/text.html").Return(nil, nil).Once()
+	mockClient.On("GetActText", mock.Anything, "DU/2024/1", sejm.TextHTML).Return(nil, errors.New("API error")).Once()
+	var failed db.CacheEntry
+	mockDB.On("StoreCacheEntry", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		failed = args.Get(1).(db.CacheEntry)
+	}).Return(nil).Once()
+
+	text, err := srv.GetActText(ctx, mustParseELI(t, "DU/2024/1"), sejm.TextHTML)
+	require.NoError(t, err)
+	assert.Equal(t, hash, text.Hash)
+	require.NoError(t, text.Content.Close())
+	assert.Equal(t, db.CacheKindTexts, failed.Kind)
+	assert.Equal(t, "DU/2024/1/text.html", failed.Key)
+	assert.Equal(t, db.OutcomeFailed, failed.Outcome)
+
+	// Until the negative TTL passes the previous version is served without calling the API
+	failed.FetchedAt = time.Now()
+	mockDB.On("GetCacheEntry", mock.Anything, db.CacheKindTexts, "DU/2024/1/text.html").Return(&failed, nil).Once()
+
+	text, err = srv.GetActText(ctx, mustParseELI(t, "DU/2024/1"), sejm.TextHTML)
+	require.NoError(t, err)
+	assert.Equal(t, hash, text.Hash)
+	require.NoError(t, text.Content.Close())
+
+	mockClient.AssertExpectations(t)
+	mockDB.AssertExpectations(t)
+}
+
+func TestGetActTextExpired(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry db.CacheEntry
+	}{
+		{"past the texts TTL", db.CacheEntry{FetchedAt: time.Now().Add(-31 * 24 * time.Hour), Outcome: db.OutcomeOK}},
+		{"invalidated", db.CacheEntry{FetchedAt: time.Now(), Outcome: db.OutcomeOK, Invalidated: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := new(MockSejmClient)
+			mockDB := new(MockDB)
+			srv := service.NewActServiceWithConfig(mockClient, mockDB, 5*time.Second, 24*time.Hour)
+			blobs, err := blob.NewStore(t.TempDir())
+			require.NoError(t, err)
+			srv.SetBlobStore(blobs)
+
+			hash, err := blobs.Put([]byte("<p>Art. 1. Stary tekst.</p>"))
+			require.NoError(t, err)
+			doc := &sejm.Document{ContentType: "text/html", Data: []byte("<p>Art. 1. Nowy tekst.</p>")}
+
+			// The act did not change, yet the text is downloaded again
+			cached := &db.ActText{ActID: "DU/2024/1", Name: sejm.TextHTML, Hash: hash, ChangeDate: "2024-01-02T10:00:00"}
+			entry := tt.entry
+			entry.Kind, entry.Key = db.CacheKindTexts, "DU/2024/1/text.html"
+			mockDB.On("GetActText", mock.Anything, "DU/2024/1", sejm.TextHTML).Return(cached, nil).Once()
+			mockDB.On("GetActDetails", mock.Anything, "DU/2024/1").Return(&sejm.ActDetails{ChangeDate: "2024-01-02T10:00:00"}, nil)
+			mockDB.On("GetCacheEntry", mock.Anything, db.CacheKindTexts, "DU/2024/1/text.html").Return(&entry, nil).Once()
+			mockClient.On("GetActText", mock.Anything, "DU/2024/1", sejm.TextHTML).Return(doc, nil).Once()
+			mockDB.On("StoreActText", mock.Anything, mock.Anything).Return(nil).Once()
+
+			text, err := srv.GetActText(context.Background(), mustParseELI(t, "DU/2024/1"), sejm.TextHTML)
+			require.NoError(t, err)
+			data, err := io.ReadAll(text.Content)
+			require.NoError(t, err)
+			assert.Equal(t, doc.Data, data)
+			require.NoError(t, text.Content.Close())
+
+			mockClient.AssertExpectations(t)
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
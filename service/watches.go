@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strings"
+	"ustawka/db"
+	"ustawka/notify"
+	"ustawka/sejm"
+	"ustawka/tracing"
+)
+
+// Notifier delivers act changes detected during background refreshes to watchers
+type Notifier interface {
+	Notify(ctx context.Context, events []notify.Event)
+}
+
+// ErrInvalidWatch is returned for watches with an unknown kind, a malformed target or URL
+var ErrInvalidWatch = errors.New("invalid watch")
+
+// yearTargetPattern matches year targets of watches and cache scopes such as DU/2024;
+// act targets are ELI IDs
+var yearTargetPattern = regexp.MustCompile(`^[A-Z]+/\d{4}$`)
+
+// SetNotifier enables change notifications for background refreshes
+func (s *ActService) SetNotifier(notifier Notifier) {
+	s.notifier = notifier
+}
+
+// AddWatch validates and stores a watch, generating a signing secret if none is given
+func (s *ActService) AddWatch(ctx context.Context, watch db.Watch) (_ *db.Watch, err error) {
+	ctx, span := tracer.Start(ctx, "service.AddWatch")
+	defer tracing.End(span, &err)
+
+	if err := normalizeWatch(&watch); err != nil {
+		return nil, err
+	}
+
+	if watch.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("failed to generate secret: %w", err)
+		}
+		watch.Secret = hex.EncodeToString(secret)
+	}
+
+	created, err := s.db.AddWatch(ctx, watch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add watch: %w", err)
+	}
+
+	return created, nil
+}
+
+// normalizeWatch checks a watch and brings its target to the canonical form
+func normalizeWatch(watch *db.Watch) error {
+	watch.Kind = strings.ToLower(strings.TrimSpace(watch.Kind))
+	watch.Target = strings.TrimSpace(watch.Target)
+
+	switch watch.Kind {
+	case db.WatchAct:
+		id, err := sejm.ParseELI(watch.Target)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidWatch, err)
+		}
+		watch.Target = id.String()
+	case db.WatchYear:
+		watch.Target = strings.ToUpper(watch.Target)
+		if !yearTargetPattern.MatchString(watch.Target) {
+			return fmt.Errorf("%w: malformed %s target %q", ErrInvalidWatch, watch.Kind, watch.Target)
+		}
+		if publisher, _, _ := strings.Cut(watch.Target, "/"); !sejm.IsValidPublisher(publisher) {
+			return fmt.Errorf("%w: %w: %s", ErrInvalidWatch, ErrUnknownPublisher, publisher)
+		}
+	case db.WatchKeyword:
+		if watch.Target == "" {
+			return fmt.Errorf("%w: keyword is required", ErrInvalidWatch)
+		}
+	default:
+		return fmt.Errorf("%w: unknown kind %q", ErrInvalidWatch, watch.Kind)
+	}
+
+	u, err := url.Parse(watch.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http(s) URL", ErrInvalidWatch)
+	}
+	if !publicHost(u.Hostname()) {
+		return fmt.Errorf("%w: url must not point at a loopback, link-local or private address", ErrInvalidWatch)
+	}
+
+	return nil
+}
+
+// publicHost reports whether a URL host may receive webhooks; names are resolved
+// and checked again on every delivery
+func publicHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return notify.PublicAddr(addr)
+	}
+	return true
+}
+
+// DeleteWatch removes a watch
+func (s *ActService) DeleteWatch(ctx context.Context, id int64) (err error) {
+	ctx, span := tracer.Start(ctx, "service.DeleteWatch")
+	defer tracing.End(span, &err)
+
+	return s.db.DeleteWatch(ctx, id)
+}
+
+// ListWatches returns all watches without their secrets
+func (s *ActService) ListWatches(ctx context.Context) (_ []db.Watch, err error) {
+	ctx, span := tracer.Start(ctx, "service.ListWatches")
+	defer tracing.End(span, &err)
+
+	watches, err := s.db.ListWatches(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list watches: %w", err)
+	}
+
+	for i := range watches {
+		watches[i].Secret = ""
+	}
+	return watches, nil
+}
+
+// watchedActs returns IDs of acts watched directly
+func (s *ActService) watchedActs(ctx context.Context) []string {
+	watches, err := s.db.ListWatches(ctx)
+	if err != nil {
+		slog.Error("Error listing watches", "error", err)
+		return nil
+	}
+
+	ids := make([]string, 0)
+	for _, watch := range watches {
+		if watch.Kind == db.WatchAct {
+			ids = append(ids, watch.Target)
+		}
+	}
+	return ids
+}
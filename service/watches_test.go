@@ -0,0 +1,337 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+	"ustawka/db"
+	"ustawka/notify"
+	"ustawka/sejm"
+	"ustawka/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockNotifier is a mock implementation of the change notifier
+type MockNotifier struct {
+	mock.Mock
+}
+
+func (m *MockNotifier) Notify(ctx context.Context, events []notify.Event) {
+	m.Called(ctx, events)
+}
+
+func TestAddWatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		watch   db.Watch
+		want    db.Watch
+		wantErr bool
+	}{
+		{
+			name:  "act",
+			watch: db.Watch{Kind: "act", Target: " du/2024/1 ", URL: "https://example.com/hook", Secret: "s3cret"},
+			want:  db.Watch{Kind: db.WatchAct, Target: "DU/2024/1", URL: "https://example.com/hook", Secret: "s3cret"},
+		},
+		{
+			name:  "act address",
+			watch: db.Watch{Kind: "act", Target: "WDU20240000001", URL: "https://example.com/hook", Secret: "s3cret"},
+			want:  db.Watch{Kind: db.WatchAct, Target: "DU/2024/1", URL: "https://example.com/hook", Secret: "s3cret"},
+		},
+		{
+			name:  "year",
+			watch: db.Watch{Kind: "YEAR", Target: "MP/2025", URL: "http://example.com/hook", Secret: "s3cret"},
+			want:  db.Watch{Kind: db.WatchYear, Target: "MP/2025", URL: "http://example.com/hook", Secret: "s3cret"},
+		},
+		{
+			name:  "keyword",
+			watch: db.Watch{Kind: "keyword", Target: "podatki", URL: "https://example.com/hook", Secret: "s3cret"},
+			want:  db.Watch{Kind: db.WatchKeyword, Target: "podatki", URL: "https://example.com/hook", Secret: "s3cret"},
+		},
+		{
+			name:    "unknown kind",
+			watch:   db.Watch{Kind: "status", Target: "uchylony", URL: "https://example.com/hook"},
+			wantErr: true,
+		},
+		{
+			name:    "malformed act",
+			watch:   db.Watch{Kind: "act", Target: "DU/2024", URL: "https://example.com/hook"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown publisher",
+			watch:   db.Watch{Kind: "year", Target: "XX/2024", URL: "https://example.com/hook"},
+			wantErr: true,
+		},
+		{
+			name:    "relative url",
+			watch:   db.Watch{Kind: "keyword", Target: "podatki", URL: "/hook"},
+			wantErr: true,
+		},
+		{
+			name:    "loopback url",
+			watch:   db.Watch{Kind: "keyword", Target: "podatki", URL: "http://127.0.0.1:8080/hook"},
+			wantErr: true,
+		},
+		{
+			name:    "ipv6 loopback url",
+			watch:   db.Watch{Kind: "keyword", Target: "podatki", URL: "http://[::1]/hook"},
+			wantErr: true,
+		},
+		{
+			name:    "localhost url",
+			watch:   db.Watch{Kind: "keyword", Target: "podatki", URL: "http://LOCALHOST./hook"},
+			wantErr: true,
+		},
+		{
+			name:    "link-local url",
+			watch:   db.Watch{Kind: "keyword", Target: "podatki", URL: "http://169.254.169.254/latest/meta-data"},
+			wantErr: true,
+		},
+		{
+			name:    "private url",
+			watch:   db.Watch{Kind: "keyword", Target: "podatki", URL: "https://10.1.2.3/hook"},
+			wantErr: true,
+		},
+		{
+			name:    "mapped private url",
+			watch:   db.Watch{Kind: "keyword", Target: "podatki", URL: "https://[::ffff:192.168.1.1]/hook"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			srv := service.NewActServiceWithConfig(new(MockSejmClient), mockDB, 5*time.Second, 24*time.Hour)
+
+			if !tt.wantErr {
+				mockDB.On("AddWatch", mock.Anything, tt.want).Return(&tt.want, nil).Once()
+			}
+
+			created, err := srv.AddWatch(context.Background(), tt.watch)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, service.ErrInvalidWatch)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, &tt.want, created)
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAddWatchGeneratesSecret(t *testing.T) {
+	mockDB := new(MockDB)
+	srv := service.NewActServiceWithConfig(new(MockSejmClient), mockDB, 5*time.Second, 24*time.Hour)
+
+	mockDB.On("AddWatch", mock.Anything, mock.MatchedBy(func(w db.Watch) bool {
+		return len(w.Secret) == 64
+	})).Return(&db.Watch{ID: 1}, nil).Once()
+
+	_, err := srv.AddWatch(context.Background(), db.Watch{Kind: "act", Target: "DU/2024/1", URL: "https://example.com"})
+	require.NoError(t, err)
+	mockDB.AssertExpectations(t)
+}
+
+func TestListWatchesHidesSecrets(t *testing.T) {
+	mockDB := new(MockDB)
+	srv := service.NewActServiceWithConfig(new(MockSejmClient), mockDB, 5*time.Second, 24*time.Hour)
+
+	mockDB.On("ListWatches", mock.Anything).Return([]db.Watch{{ID: 1, Secret: "s3cret"}}, nil).Once()
+
+	watches, err := srv.ListWatches(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []db.Watch{{ID: 1}}, watches)
+}
+
+func TestRefreshNotifiesWatchers(t *testing.T) {
+	mockClient := new(MockSejmClient)
+	mockDB := new(MockDB)
+	notifier := new(MockNotifier)
+	srv := service.NewActServiceWithConfig(mockClient, mockDB, 5*time.Second, 24*time.Hour)
+	srv.SetNotifier(notifier)
+	ctx := context.Background()
+
+	// Year listings report the status changes recorded when they are stored
+	fresh := []sejm.Act{{ID: "DU/2024/1", Status: "uchylony"}}
+	recorded := []db.ActChange{{ActID: "DU/2024/1", Field: "status", OldValue: "obowiązujący", NewValue: "uchylony"}}
+	mockClient.On("GetActs", mock.Anything, sejm.PublisherDU, 2024).Return(fresh, nil).Once()
+	mockDB.On("StoreActs", mock.Anything, sejm.PublisherDU, 2024, fresh).Return(recorded, nil).Once()
+	mockDB.On("GetActDetails", mock.Anything, "DU/2024/1").Return(&sejm.ActDetails{Keywords: []string{"podatki"}}, nil).Once()
+	notifier.On("Notify", mock.Anything, mock.MatchedBy(func(events []notify.Event) bool {
+		return len(events) == 1 && events[0].Type == notify.EventStatusChanged && events[0].NewValue == "uchylony" &&
+			assert.ObjectsAreEqual([]string{"podatki"}, events[0].Keywords)
+	})).Once()
+
+	require.NoError(t, srv.RefreshYear(ctx, sejm.PublisherDU, 2024))
+
+	// Details report amendments and consolidated texts, leaving status changes to listings
+	before := &sejm.ActDetails{ID: "DU/2024/1", Status: "obowiązujący"}
+	after := &sejm.ActDetails{ID: "DU/2024/1", Status: "uchylony", Texts: []sejm.Text{{FileName: "U2024.pdf", Type: "I"}}}
+	mockDB.On("GetActDetails", mock.Anything, "DU/2024/1").Return(before, nil).Once()
+	mockClient.On("GetActDetails", mock.Anything, "DU/2024/1").Return(after, nil).Once()
+	mockDB.On("StoreActDetails", mock.Anything, after).Return(nil).Once()
+	notifier.On("Notify", mock.Anything, mock.MatchedBy(func(events []notify.Event) bool {
+		return len(events) == 1 && events[0].Type == notify.EventConsolidatedText
+	})).Once()
+
+	require.NoError(t, srv.RefreshActDetails(ctx, "DU/2024/1"))
+
+	// Failed refreshes notify nobody
+	mockDB.On("GetActDetails", mock.Anything, "DU/2024/2").Return(nil, nil).Once()
+	mockClient.On("GetActDetails", mock.Anything, "DU/2024/2").Return(nil, errors.New("API error")).Once()
+	assert.Error(t, srv.RefreshActDetails(ctx, "DU/2024/2"))
+
+	mockClient.AssertExpectations(t)
+	mockDB.AssertExpectations(t)
+	notifier.AssertExpectations(t)
+}
+
+func TestExpiredDetailsNotifyWatchers(t *testing.T) {
+	mockClient := new(MockSejmClient)
+	mockDB := new(MockDB)
+	notifier := new(MockNotifier)
+	srv := service.NewActServiceWithConfig(mockClient, mockDB, 5*time.Second, 24*time.Hour)
+	srv.SetNotifier(notifier)
+
+	// Details fetched again for a user are compared with the cached ones like in a background refresh
+	cached := &sejm.ActDetails{ID: "DU/2024/1"}
+	fresh := &sejm.ActDetails{ID: "DU/2024/1", Texts: []sejm.Text{{FileName: "U2024.pdf", Type: "I"}}}
+	mockDB.On("GetActDetails", mock.Anything, "DU/2024/1").Return(cached, nil).Twice()
+	mockDB.On("GetCacheEntry", mock.Anything, db.CacheKindDetails, "DU/2024/1").Return(detailsEntry(8*24*time.Hour), nil).Once()
+	mockClient.On("GetActDetails", mock.Anything, "DU/2024/1").Return(fresh, nil).Once()
+	mockDB.On("StoreActDetails", mock.Anything, fresh).Return(nil).Once()
+	notifier.On("Notify", mock.Anything, mock.MatchedBy(func(events []notify.Event) bool {
+		return len(events) == 1 && events[0].Type == notify.EventConsolidatedText
+	})).Once()
+
+	got, err := srv.GetActDetails(context.Background(), mustParseELI(t, "DU/2024/1"))
+	require.NoError(t, err)
+	assert.Equal(t, fresh, got)
+
+	mockClient.AssertExpectations(t)
+	mockDB.AssertExpectations(t)
+	notifier.AssertExpectations(t)
+}
+
+// recordingNotifier collects notified events
+type recordingNotifier struct {
+	mu     sync.Mutex
+	events []notify.Event
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, events []notify.Event) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.events = append(n.events, events...)
+}
+
+func TestRefreshReportsStatusChangeOnce(t *testing.T) {
+	database, err := db.New(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if err := database.Close(); err != nil {
+			t.Errorf("Error closing database: %v", err)
+		}
+	})
+	ctx := context.Background()
+
+	act := sejm.Act{ID: "DU/2024/1", Publisher: sejm.PublisherDU, Year: 2024, Position: 1, Title: "Ustawa", Status: "obowiązujący"}
+	details := &sejm.ActDetails{ID: "DU/2024/1", Publisher: sejm.PublisherDU, Year: 2024, Position: 1, Title: "Ustawa", Status: "obowiązujący", Keywords: []string{"podatki"}}
+	_, err = database.StoreActs(ctx, sejm.PublisherDU, 2024, []sejm.Act{act})
+	require.NoError(t, err)
+	require.NoError(t, database.StoreActDetails(ctx, details))
+
+	repealedAct := act
+	repealedAct.Status = "uchylony"
+	repealedDetails := *details
+	repealedDetails.Status = "uchylony"
+
+	mockClient := new(MockSejmClient)
+	mockClient.On("GetActs", mock.Anything, sejm.PublisherDU, 2024).Return([]sejm.Act{repealedAct}, nil).Once()
+	mockClient.On("GetActDetails", mock.Anything, "DU/2024/1").Return(&repealedDetails, nil).Once()
+
+	notifier := new(recordingNotifier)
+	srv := service.NewActServiceWithConfig(mockClient, database, 5*time.Second, 24*time.Hour)
+	srv.SetNotifier(notifier)
+
+	require.NoError(t, srv.RefreshYear(ctx, sejm.PublisherDU, 2024))
+	require.NoError(t, srv.RefreshActDetails(ctx, "DU/2024/1"))
+
+	require.Len(t, notifier.events, 1)
+	assert.Equal(t, notify.EventStatusChanged, notifier.events[0].Type)
+	assert.Equal(t, "obowiązujący", notifier.events[0].OldValue)
+	assert.Equal(t, "uchylony", notifier.events[0].NewValue)
+	assert.Equal(t, []string{"podatki"}, notifier.events[0].Keywords)
+	mockClient.AssertExpectations(t)
+}
+
+func TestOverlappingRefreshesReportStatusChangeOnce(t *testing.T) {
+	database, err := db.New(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if err := database.Close(); err != nil {
+			t.Errorf("Error closing database: %v", err)
+		}
+	})
+	ctx := context.Background()
+
+	act := sejm.Act{ID: "DU/2024/1", Publisher: sejm.PublisherDU, Year: 2024, Position: 1, Title: "Ustawa", Status: "obowiązujący"}
+	_, err = database.StoreActs(ctx, sejm.PublisherDU, 2024, []sejm.Act{act})
+	require.NoError(t, err)
+	repealed := act
+	repealed.Status = "uchylony"
+
+	// Two replicas sharing the database fetch the changed listing at the same time
+	var fetching sync.WaitGroup
+	fetching.Add(2)
+	notifier := new(recordingNotifier)
+	replicas := make([]*service.ActService, 2)
+	for i := range replicas {
+		client := new(MockSejmClient)
+		client.On("GetActs", mock.Anything, sejm.PublisherDU, 2024).Run(func(mock.Arguments) {
+			fetching.Done()
+			fetching.Wait()
+		}).Return([]sejm.Act{repealed}, nil).Once()
+		replicas[i] = service.NewActServiceWithConfig(client, database, 5*time.Second, 24*time.Hour)
+		replicas[i].SetNotifier(notifier)
+	}
+
+	var refreshes sync.WaitGroup
+	for _, replica := range replicas {
+		refreshes.Add(1)
+		go func() {
+			defer refreshes.Done()
+			assert.NoError(t, replica.RefreshYear(ctx, sejm.PublisherDU, 2024))
+		}()
+	}
+	refreshes.Wait()
+
+	require.Len(t, notifier.events, 1)
+	assert.Equal(t, "uchylony", notifier.events[0].NewValue)
+}
+
+func TestActsToRefreshIncludesWatchedActs(t *testing.T) {
+	mockDB := new(MockDB)
+	srv := service.NewActServiceWithConfig(new(MockSejmClient), mockDB, 5*time.Second, 24*time.Hour)
+	ctx := context.Background()
+
+	mockDB.On("GetActDetails", mock.Anything, "DU/2024/1").Return(&sejm.ActDetails{ID: "DU/2024/1"}, nil).Once()
+	mockDB.On("GetCacheEntry", mock.Anything, db.CacheKindDetails, "DU/2024/1").Return(detailsEntry(time.Hour), nil).Once()
+	_, err := srv.GetActDetails(ctx, mustParseELI(t, "DU/2024/1"))
+	require.NoError(t, err)
+
+	mockDB.On("ListWatches", mock.Anything).Return([]db.Watch{
+		{Kind: db.WatchAct, Target: "DU/2024/1"},
+		{Kind: db.WatchAct, Target: "MP/2023/7"},
+		{Kind: db.WatchYear, Target: "DU/2024"},
+	}, nil).Once()
+
+	assert.Equal(t, []string{"DU/2024/1", "MP/2023/7"}, srv.ActsToRefresh(ctx))
+}
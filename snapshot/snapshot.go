@@ -0,0 +1,447 @@
+// Package snapshot exports the cache to a portable archive and imports it back, so that
+// a server can run offline from data fetched elsewhere. An archive is a tar file compressed
+// with zstd, holding acts, act details, text indexes and cache metadata as JSON lines next
+// to the downloaded texts, independent of the database it was exported from.
+package snapshot
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"ustawka/db"
+	"ustawka/sejm"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Version is the version of the archive format written by Export
+const Version = 1
+
+// Names of the entries of an archive, in the order they are written
+const (
+	manifestFile     = "manifest.json"
+	actsFile         = "acts.jsonl"
+	detailsFile      = "details.jsonl"
+	blobsDir         = "blobs/"
+	textsFile        = "texts.jsonl"
+	cacheEntriesFile = "cache_entries.jsonl"
+)
+
+// Manifest describes an archive
+type Manifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	Counts    Counts    `json:"counts"`
+}
+
+// Counts are the numbers of records in an archive
+type Counts struct {
+	Acts         int `json:"acts"`
+	Details      int `json:"details"`
+	Texts        int `json:"texts"`
+	CacheEntries int `json:"cacheEntries"`
+}
+
+// Source is a database a snapshot is exported from
+type Source interface {
+	EachAct(ctx context.Context, fn func(sejm.Act) error) error
+	EachActDetails(ctx context.Context, fn func(*sejm.ActDetails) error) error
+	EachActText(ctx context.Context, fn func(db.ActText) error) error
+	EachCacheEntry(ctx context.Context, fn func(db.CacheEntry) error) error
+}
+
+// Target is a database a snapshot is imported into
+type Target interface {
+	StoreActs(ctx context.Context, publisher string, year int, acts []sejm.Act) ([]db.ActChange, error)
+	StoreActDetails(ctx context.Context, details *sejm.ActDetails) error
+	StoreActText(ctx context.Context, text db.ActText) error
+	RestoreCacheEntry(ctx context.Context, entry db.CacheEntry) error
+	StoreSnapshot(ctx context.Context, createdAt time.Time) error
+}
+
+// BlobSource holds the downloaded texts being exported
+type BlobSource interface {
+	Open(hash string) (*os.File, error)
+}
+
+// BlobTarget keeps the texts being imported
+type BlobTarget interface {
+	Put(data []byte) (string, error)
+}
+
+// textRecord is the index entry of a text in an archive
+type textRecord struct {
+	ActID       string    `json:"actId"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"contentType"`
+	Hash        string    `json:"hash"`
+	Size        int64     `json:"size"`
+	ChangeDate  string    `json:"changeDate"`
+	FetchedAt   time.Time `json:"fetchedAt"`
+}
+
+// cacheEntryRecord is the cache metadata of a resource in an archive
+type cacheEntryRecord struct {
+	Kind        string    `json:"kind"`
+	Key         string    `json:"key"`
+	FetchedAt   time.Time `json:"fetchedAt"`
+	TotalCount  int       `json:"totalCount"`
+	ETag        string    `json:"etag,omitempty"`
+	Outcome     string    `json:"outcome"`
+	Invalidated bool      `json:"invalidated,omitempty"`
+}
+
+// Export writes an archive of everything cached in src to w. Texts whose blob is missing,
+// such as on a replica that never downloaded them, are left out together with their cache
+// metadata. The change history, watches and response validators are local state and are
+// not exported.
+func Export(ctx context.Context, w io.Writer, src Source, blobs BlobSource) (*Manifest, error) {
+	manifest := &Manifest{Version: Version, CreatedAt: time.Now().UTC()}
+
+	acts, err := spool(func(enc *json.Encoder) error {
+		return src.EachAct(ctx, func(act sejm.Act) error {
+			manifest.Counts.Acts++
+			return enc.Encode(act)
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to export acts: %w", err)
+	}
+	defer removeSpool(acts)
+
+	details, err := spool(func(enc *json.Encoder) error {
+		return src.EachActDetails(ctx, func(details *sejm.ActDetails) error {
+			manifest.Counts.Details++
+			return enc.Encode(details)
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to export act details: %w", err)
+	}
+	defer removeSpool(details)
+
+	var texts []textRecord
+	err = src.EachActText(ctx, func(text db.ActText) error {
+		texts = append(texts, textRecord{
+			ActID: text.ActID, Name: text.Name, ContentType: text.ContentType, Hash: text.Hash,
+			Size: text.Size, ChangeDate: text.ChangeDate, FetchedAt: text.FetchedAt,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to export texts: %w", err)
+	}
+
+	hashes, exported, err := findBlobs(texts, blobs)
+	if err != nil {
+		return nil, err
+	}
+	manifest.Counts.Texts = len(exported)
+
+	textsIndex, err := spool(func(enc *json.Encoder) error {
+		for _, text := range exported {
+			if err := enc.Encode(text); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to export texts: %w", err)
+	}
+	defer removeSpool(textsIndex)
+
+	exportedTexts := make(map[string]bool, len(exported))
+	for _, text := range exported {
+		exportedTexts[db.TextKey(text.ActID, text.Name)] = true
+	}
+	cacheEntries, err := spool(func(enc *json.Encoder) error {
+		return src.EachCacheEntry(ctx, func(entry db.CacheEntry) error {
+			if entry.Kind == db.CacheKindTexts && entry.Outcome == db.OutcomeOK && !exportedTexts[entry.Key] {
+				return nil
+			}
+			manifest.Counts.CacheEntries++
+			return enc.Encode(cacheEntryRecord(entry))
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to export cache metadata: %w", err)
+	}
+	defer removeSpool(cacheEntries)
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	zw, err := zstd.NewWriter(w)
+	if err != nil {
+		return nil, err
+	}
+	tw := tar.NewWriter(zw)
+	modTime := manifest.CreatedAt
+
+	if err := writeEntry(tw, manifestFile, int64(len(data)), modTime, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	if err := writeFile(tw, actsFile, acts, modTime); err != nil {
+		return nil, err
+	}
+	if err := writeFile(tw, detailsFile, details, modTime); err != nil {
+		return nil, err
+	}
+	for _, hash := range hashes {
+		if err := writeBlob(tw, blobs, hash, modTime); err != nil {
+			return nil, err
+		}
+	}
+	if err := writeFile(tw, textsFile, textsIndex, modTime); err != nil {
+		return nil, err
+	}
+	if err := writeFile(tw, cacheEntriesFile, cacheEntries, modTime); err != nil {
+		return nil, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// findBlobs returns the distinct hashes of the texts whose blob is in the store,
+// together with those texts
+func findBlobs(texts []textRecord, blobs BlobSource) ([]string, []textRecord, error) {
+	var hashes []string
+	var exported []textRecord
+	found := make(map[string]bool)
+	for _, text := range texts {
+		if !found[text.Hash] {
+			file, err := blobs.Open(text.Hash)
+			if errors.Is(err, os.ErrNotExist) {
+				slog.Warn("Skipping text without a downloaded blob", "act", text.ActID, "name", text.Name)
+				continue
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			closeFile(file)
+
+			found[text.Hash] = true
+			hashes = append(hashes, text.Hash)
+		}
+		exported = append(exported, text)
+	}
+	return hashes, exported, nil
+}
+
+// writeBlob writes a blob as an archive entry
+func writeBlob(tw *tar.Writer, blobs BlobSource, hash string, modTime time.Time) error {
+	file, err := blobs.Open(hash)
+	if err != nil {
+		return err
+	}
+	defer closeFile(file)
+
+	return writeFile(tw, blobsDir+hash, file, modTime)
+}
+
+// spool writes JSON lines to a temporary file, as tar entries need their size up front
+func spool(write func(enc *json.Encoder) error) (*os.File, error) {
+	file, err := os.CreateTemp("", "ustawka-snapshot-*.jsonl")
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bufio.NewWriter(file)
+	if err := write(json.NewEncoder(buf)); err != nil {
+		removeSpool(file)
+		return nil, err
+	}
+	if err := buf.Flush(); err != nil {
+		removeSpool(file)
+		return nil, err
+	}
+	return file, nil
+}
+
+// removeSpool closes and removes a temporary file
+func removeSpool(file *os.File) {
+	closeFile(file)
+	if err := os.Remove(file.Name()); err != nil {
+		slog.Error("Error removing temporary file", "file", file.Name(), "error", err)
+	}
+}
+
+// closeFile closes a file, logging failures
+func closeFile(file *os.File) {
+	if err := file.Close(); err != nil && !errors.Is(err, os.ErrClosed) {
+		slog.Error("Error closing file", "file", file.Name(), "error", err)
+	}
+}
+
+// writeFile writes the content of a file as an archive entry
+func writeFile(tw *tar.Writer, name string, file *os.File, modTime time.Time) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return writeEntry(tw, name, info.Size(), modTime, file)
+}
+
+// writeEntry writes an archive entry of the given size
+func writeEntry(tw *tar.Writer, name string, size int64, modTime time.Time, r io.Reader) error {
+	header := &tar.Header{Name: name, Mode: 0o644, Size: size, ModTime: modTime, Typeflag: tar.TypeReg}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	if _, err := io.Copy(tw, r); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// Import reads an archive written by Export from r into dst, storing the texts in blobs,
+// and records the snapshot as imported. Resources already cached are replaced by those
+// in the archive, recording changes of acts in the history like a refresh would.
+func Import(ctx context.Context, r io.Reader, dst Target, blobs BlobTarget) (*Manifest, error) {
+	zr, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	tr := tar.NewReader(zr)
+
+	header, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	if header.Name != manifestFile {
+		return nil, fmt.Errorf("not a snapshot: %s is missing", manifestFile)
+	}
+	var manifest Manifest
+	if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", manifestFile, err)
+	}
+	if manifest.Version != Version {
+		return nil, fmt.Errorf("unsupported snapshot version %d", manifest.Version)
+	}
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		switch name := header.Name; {
+		case name == actsFile:
+			err = importActs(ctx, tr, dst)
+		case name == detailsFile:
+			err = eachRecord(tr, func(details *sejm.ActDetails) error {
+				return dst.StoreActDetails(ctx, details)
+			})
+		case strings.HasPrefix(name, blobsDir):
+			err = importBlob(tr, strings.TrimPrefix(name, blobsDir), blobs)
+		case name == textsFile:
+			err = eachRecord(tr, func(text *textRecord) error {
+				return dst.StoreActText(ctx, db.ActText{
+					ActID: text.ActID, Name: text.Name, ContentType: text.ContentType, Hash: text.Hash,
+					Size: text.Size, ChangeDate: text.ChangeDate, FetchedAt: text.FetchedAt,
+				})
+			})
+		case name == cacheEntriesFile:
+			// Cache metadata comes last, replacing what storing the resources recorded
+			err = eachRecord(tr, func(entry *cacheEntryRecord) error {
+				return dst.RestoreCacheEntry(ctx, db.CacheEntry(*entry))
+			})
+		default:
+			err = fmt.Errorf("unexpected entry %s", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to import %s: %w", header.Name, err)
+		}
+	}
+
+	if err := dst.StoreSnapshot(ctx, manifest.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// importActs stores the acts of an archive a year at a time, relying on Export
+// writing them ordered by publisher and year
+func importActs(ctx context.Context, r io.Reader, dst Target) error {
+	var year []sejm.Act
+	flush := func() error {
+		if len(year) == 0 {
+			return nil
+		}
+		_, err := dst.StoreActs(ctx, year[0].Publisher, year[0].Year, year)
+		year = nil
+		return err
+	}
+
+	err := eachRecord(r, func(act *sejm.Act) error {
+		if len(year) > 0 && (act.Publisher != year[0].Publisher || act.Year != year[0].Year) {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		year = append(year, *act)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return flush()
+}
+
+// importBlob stores a text blob, checking that its content matches its hash
+func importBlob(r io.Reader, hash string, blobs BlobTarget) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	stored, err := blobs.Put(data)
+	if err != nil {
+		return err
+	}
+	if stored != hash {
+		return fmt.Errorf("content does not match hash %s", hash)
+	}
+	return nil
+}
+
+// eachRecord decodes JSON lines from r, calling fn for each record
+func eachRecord[T any](r io.Reader, fn func(*T) error) error {
+	dec := json.NewDecoder(r)
+	for {
+		var record T
+		if err := dec.Decode(&record); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := fn(&record); err != nil {
+			return err
+		}
+	}
+}
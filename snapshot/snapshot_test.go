@@ -0,0 +1,145 @@
+package snapshot_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ustawka/blob"
+	"ustawka/db"
+	"ustawka/sejm"
+	"ustawka/snapshot"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// store opens an empty database with a blob store next to it
+func store(t *testing.T) (*db.DB, *blob.Store) {
+	t.Helper()
+	dir := t.TempDir()
+	database, err := db.New(filepath.Join(dir, "acts.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = database.Close() })
+
+	blobs, err := blob.NewStore(filepath.Join(dir, "texts"))
+	require.NoError(t, err)
+	return database, blobs
+}
+
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+	source, sourceBlobs := store(t)
+
+	acts := []sejm.Act{
+		{ID: "DU/2024/1", Publisher: sejm.PublisherDU, Title: "Ustawa o podatku", Status: "obowiązujący", Position: 1, Year: 2024},
+		{ID: "DU/2024/2", Publisher: sejm.PublisherDU, Title: "Rozporządzenie", Status: "uchylony", Position: 2, Year: 2024},
+	}
+	mp := []sejm.Act{{ID: "MP/2023/5", Publisher: sejm.PublisherMP, Title: "Obwieszczenie", Position: 5, Year: 2023}}
+	_, err := source.StoreActs(ctx, sejm.PublisherDU, 2024, acts)
+	require.NoError(t, err)
+	_, err = source.StoreActs(ctx, sejm.PublisherMP, 2023, mp)
+	require.NoError(t, err)
+
+	details := &sejm.ActDetails{
+		ID: "DU/2024/1", Title: "Ustawa o podatku", Status: "obowiązujący", Position: 1, Year: 2024,
+		ChangeDate: "2024-03-01", Keywords: []string{"podatki"}, Texts: []sejm.Text{{FileName: "D20240001.pdf", Type: "O"}},
+		ETag: `"details"`,
+	}
+	require.NoError(t, source.StoreActDetails(ctx, details))
+
+	content := []byte("%PDF-1.4 ustawa")
+	hash, err := sourceBlobs.Put(content)
+	require.NoError(t, err)
+	text := db.ActText{ActID: "DU/2024/1", Name: sejm.TextPDF, ContentType: "application/pdf", Hash: hash, Size: int64(len(content)), ChangeDate: "2024-03-01"}
+	require.NoError(t, source.StoreActText(ctx, text))
+
+	// Texts without a downloaded blob are left out
+	missing := db.ActText{ActID: "DU/2024/2", Name: sejm.TextPDF, ContentType: "application/pdf", Hash: "0000000000000000000000000000000000000000000000000000000000000000", Size: 1}
+	require.NoError(t, source.StoreActText(ctx, missing))
+
+	// Cache metadata comes along as it was, including invalidations and missing resources
+	_, err = source.InvalidateCacheEntries(ctx, "MP/2023")
+	require.NoError(t, err)
+	require.NoError(t, source.StoreCacheEntry(ctx, db.CacheEntry{Kind: db.CacheKindDetails, Key: "DU/2024/9", Outcome: db.OutcomeNotFound}))
+
+	var archive bytes.Buffer
+	exported, err := snapshot.Export(ctx, &archive, source, sourceBlobs)
+	require.NoError(t, err)
+	assert.Equal(t, snapshot.Version, exported.Version)
+	assert.Equal(t, snapshot.Counts{Acts: 3, Details: 1, Texts: 1, CacheEntries: 5}, exported.Counts)
+
+	target, targetBlobs := store(t)
+	imported, err := snapshot.Import(ctx, &archive, target, targetBlobs)
+	require.NoError(t, err)
+	assert.Equal(t, exported.Counts, imported.Counts)
+	assert.True(t, exported.CreatedAt.Equal(imported.CreatedAt))
+
+	got, err := target.GetActs(ctx, sejm.PublisherDU, 2024)
+	require.NoError(t, err)
+	assert.Equal(t, acts, got)
+	got, err = target.GetActs(ctx, sejm.PublisherMP, 2023)
+	require.NoError(t, err)
+	assert.Equal(t, mp, got)
+
+	id := sejm.ELI{Publisher: sejm.PublisherDU, Year: 2024, Position: 1}
+	gotDetails, err := target.GetActDetails(ctx, id)
+	require.NoError(t, err)
+	expected := *details
+	expected.ETag = ""
+	assert.Equal(t, &expected, gotDetails)
+
+	gotText, err := target.GetActText(ctx, id, sejm.TextPDF)
+	require.NoError(t, err)
+	require.NotNil(t, gotText)
+	assert.Equal(t, hash, gotText.Hash)
+	file, err := targetBlobs.Open(hash)
+	require.NoError(t, err)
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+
+	gotMissing, err := target.GetActText(ctx, sejm.ELI{Publisher: sejm.PublisherDU, Year: 2024, Position: 2}, sejm.TextPDF)
+	require.NoError(t, err)
+	assert.Nil(t, gotMissing)
+
+	for _, key := range []struct{ kind, key string }{
+		{db.CacheKindActs, "DU/2024"},
+		{db.CacheKindActs, "MP/2023"},
+		{db.CacheKindDetails, "DU/2024/1"},
+		{db.CacheKindDetails, "DU/2024/9"},
+		{db.CacheKindTexts, "DU/2024/1/text.pdf"},
+	} {
+		want, err := source.GetCacheEntry(ctx, key.kind, key.key)
+		require.NoError(t, err)
+		entry, err := target.GetCacheEntry(ctx, key.kind, key.key)
+		require.NoError(t, err)
+		require.NotNil(t, entry, key.key)
+		assert.WithinDuration(t, want.FetchedAt, entry.FetchedAt, time.Millisecond, key.key)
+		entry.FetchedAt = want.FetchedAt
+		assert.Equal(t, want, entry)
+	}
+	entry, err := target.GetCacheEntry(ctx, db.CacheKindTexts, "DU/2024/2/text.pdf")
+	require.NoError(t, err)
+	assert.Nil(t, entry)
+
+	recorded, err := target.GetSnapshot(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, recorded)
+	assert.WithinDuration(t, exported.CreatedAt, recorded.CreatedAt, time.Millisecond)
+}
+
+func TestImportRejectsOtherArchives(t *testing.T) {
+	target, blobs := store(t)
+
+	_, err := snapshot.Import(context.Background(), bytes.NewReader([]byte("not a snapshot")), target, blobs)
+	assert.Error(t, err)
+
+	recorded, err := target.GetSnapshot(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, recorded)
+}
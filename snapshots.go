@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+	"ustawka/blob"
+	"ustawka/server"
+	"ustawka/snapshot"
+)
+
+// Usage errors of the snapshot subcommands
+var (
+	errExportUsage = errors.New("usage: ustawka export --out snapshot.tar.zst")
+	errImportUsage = errors.New("usage: ustawka import snapshot.tar.zst")
+)
+
+// runExport writes a snapshot of everything cached to the file given with --out
+func runExport(ctx context.Context, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	path := flags.String("out", "", "snapshot file to write")
+	if err := flags.Parse(args); err != nil || *path == "" || flags.NArg() > 0 {
+		return errExportUsage
+	}
+
+	database, err := server.NewStore()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = database.Close()
+	}()
+
+	blobs, err := blob.NewStore(server.TextDir())
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(*path)
+	if err != nil {
+		return err
+	}
+	manifest, err := snapshot.Export(ctx, file, database, blobs)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// A partial snapshot is of no use
+		_ = os.Remove(*path)
+		return err
+	}
+
+	_, err = fmt.Fprintf(out, "Exported %d acts, %d act details and %d texts to %s\n",
+		manifest.Counts.Acts, manifest.Counts.Details, manifest.Counts.Texts, *path)
+	return err
+}
+
+// runImport loads a snapshot written by export into the database, applying pending
+// schema migrations first
+func runImport(ctx context.Context, args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return errImportUsage
+	}
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	database, err := server.NewStore()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = database.Close()
+	}()
+
+	blobs, err := blob.NewStore(server.TextDir())
+	if err != nil {
+		return err
+	}
+
+	manifest, err := snapshot.Import(ctx, file, database, blobs)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(out, "Imported %d acts, %d act details and %d texts from the snapshot of %s\n",
+		manifest.Counts.Acts, manifest.Counts.Details, manifest.Counts.Texts, manifest.CreatedAt.Local().Format(time.DateTime))
+	return err
+}
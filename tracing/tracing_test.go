@@ -0,0 +1,166 @@
+package tracing_test
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+	"ustawka/db"
+	"ustawka/handlers"
+	"ustawka/sejm"
+	"ustawka/service"
+	"ustawka/tracing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	traceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	traceparent = "00-" + traceID + "-00f067aa0ba902b7-01"
+)
+
+// spanTree indexes recorded spans by name and by ID to look up their parents
+type spanTree struct {
+	byName map[string]tracetest.SpanStub
+	byID   map[trace.SpanID]tracetest.SpanStub
+}
+
+func newSpanTree(spans tracetest.SpanStubs) spanTree {
+	tree := spanTree{byName: make(map[string]tracetest.SpanStub), byID: make(map[trace.SpanID]tracetest.SpanStub)}
+	for _, span := range spans {
+		tree.byName[span.Name] = span
+		tree.byID[span.SpanContext.SpanID()] = span
+	}
+	return tree
+}
+
+// parent returns the name of the parent of the named span
+func (tree spanTree) parent(t *testing.T, name string) string {
+	t.Helper()
+	span, ok := tree.byName[name]
+	require.True(t, ok, "span %q not recorded", name)
+	return tree.byID[span.Parent.SpanID()].Name
+}
+
+func TestSpanTree(t *testing.T) {
+	// Global tracers delegate to the first provider set, so it is set once for all cases
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { _ = provider.Shutdown(t.Context()) })
+
+	var upstreamParent string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamParent = r.Header.Get("traceparent")
+		if r.URL.Path != "/acts/DU/2024/1" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ELI":"DU/2024/1","title":"Ustawa","publisher":"DU","year":2024,"pos":1}`))
+	}))
+	defer upstream.Close()
+
+	database, err := db.New(filepath.Join(t.TempDir(), "acts.db"))
+	require.NoError(t, err)
+	defer database.Close()
+
+	actService := service.NewActServiceWithConfig(sejm.NewClientWithURL(upstream.URL), database, time.Second, time.Hour)
+	templates := template.Must(template.ParseFiles("../templates/base.html", "../templates/act_details.html"))
+	handler := handlers.NewHandler(templates, actService)
+
+	r := chi.NewRouter()
+	r.Use(tracing.Middleware)
+	r.Get("/api/acts/{publisher}/{year}/{position}", handler.HandleActDetails)
+
+	t.Run("cache miss", func(t *testing.T) {
+		exporter.Reset()
+		req := httptest.NewRequest(http.MethodGet, "/api/acts/DU/2024/1", nil)
+		req.Header.Set("traceparent", traceparent)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		spans := exporter.GetSpans()
+		for _, span := range spans {
+			assert.Equal(t, traceID, span.SpanContext.TraceID().String(), span.Name)
+		}
+
+		tree := newSpanTree(spans)
+		route := "GET /api/acts/{publisher}/{year}/{position}"
+		assert.Equal(t, "00f067aa0ba902b7", tree.byName[route].Parent.SpanID().String())
+		assert.Equal(t, route, tree.parent(t, "service.GetActDetails"))
+		assert.Equal(t, "service.GetActDetails", tree.parent(t, "db.get_act_details"))
+		assert.Equal(t, "service.GetActDetails", tree.parent(t, "sejm.GetActDetails"))
+		assert.Equal(t, "service.GetActDetails", tree.parent(t, "db.store_act_details"))
+
+		// The instrumented transport adds a client span and propagates it upstream
+		var client tracetest.SpanStub
+		for _, span := range spans {
+			if span.SpanKind == trace.SpanKindClient && tree.byID[span.Parent.SpanID()].Name == "sejm.GetActDetails" {
+				client = span
+			}
+		}
+		require.True(t, client.SpanContext.IsValid(), "no HTTP client span under sejm.GetActDetails")
+		assert.Equal(t, "00-"+traceID+"-"+client.SpanContext.SpanID().String()+"-01", upstreamParent)
+	})
+
+	t.Run("cache hit rendered", func(t *testing.T) {
+		exporter.Reset()
+		req := httptest.NewRequest(http.MethodGet, "/api/acts/DU/2024/1", nil)
+		req.Header.Set("HX-Request", "true")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		tree := newSpanTree(exporter.GetSpans())
+		route := "GET /api/acts/{publisher}/{year}/{position}"
+		assert.False(t, tree.byName[route].Parent.IsValid())
+		assert.Equal(t, route, tree.parent(t, "template act_details"))
+		assert.Equal(t, route, tree.parent(t, "service.GetActHistory"))
+		assert.Equal(t, "service.GetActDetails", tree.parent(t, "db.get_act_details"))
+		assert.NotContains(t, tree.byName, "sejm.GetActDetails")
+	})
+
+	t.Run("upstream failure", func(t *testing.T) {
+		exporter.Reset()
+		req := httptest.NewRequest(http.MethodGet, "/api/acts/DU/2024/2", nil)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusNotFound, rec.Code)
+
+		tree := newSpanTree(exporter.GetSpans())
+		for _, name := range []string{"sejm.GetActDetails", "service.GetActDetails"} {
+			span := tree.byName[name]
+			assert.Equal(t, codes.Error, span.Status.Code, name)
+			assert.Contains(t, span.Status.Description, sejm.ErrActNotFound.Error(), name)
+			require.Len(t, span.Events, 1, name)
+			assert.Equal(t, "exception", span.Events[0].Name, name)
+		}
+		assert.Equal(t, codes.Unset, tree.byName["db.get_act_details"].Status.Code)
+	})
+
+	t.Run("database failure", func(t *testing.T) {
+		require.NoError(t, database.Close())
+		exporter.Reset()
+		req := httptest.NewRequest(http.MethodGet, "/api/acts/DU/2024/1", nil)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		tree := newSpanTree(exporter.GetSpans())
+		span := tree.byName["db.get_act_details"]
+		assert.Equal(t, codes.Error, span.Status.Code)
+		assert.Contains(t, span.Status.Description, "database is closed")
+	})
+}
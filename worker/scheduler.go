@@ -0,0 +1,209 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+	"ustawka/metrics"
+)
+
+// Refresher refreshes cached data from the Sejm API
+type Refresher interface {
+	RefreshYear(ctx context.Context, publisher string, year int) error
+	RefreshActDetails(ctx context.Context, actID string) error
+	ActsToRefresh(ctx context.Context) []string
+}
+
+// Config controls how often and how wide the cache is refreshed
+type Config struct {
+	// Interval between sync runs, zero disables the scheduler
+	Interval time.Duration
+	// Jitter is the maximum random delay added to each interval
+	Jitter time.Duration
+	// Concurrency is the number of refreshes running at the same time
+	Concurrency int
+	// Publishers whose year listings are refreshed
+	Publishers []string
+	// FirstYear is the earliest year refreshed, up to the current one
+	FirstYear int
+}
+
+// Default values
+const (
+	defaultInterval    = time.Hour
+	defaultJitter      = 5 * time.Minute
+	defaultConcurrency = 2
+)
+
+// ConfigFromEnv builds a configuration from SEJM_SYNC_* environment variables
+func ConfigFromEnv(publishers []string, firstYear int) Config {
+	cfg := Config{
+		Interval:    durationFromEnv("SEJM_SYNC_INTERVAL", defaultInterval),
+		Jitter:      durationFromEnv("SEJM_SYNC_JITTER", defaultJitter),
+		Concurrency: defaultConcurrency,
+		Publishers:  publishers,
+		FirstYear:   firstYear,
+	}
+
+	if value := os.Getenv("SEJM_SYNC_CONCURRENCY"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			cfg.Concurrency = n
+			slog.Info("Using custom sync concurrency", "concurrency", n)
+		} else {
+			slog.Warn("Invalid SEJM_SYNC_CONCURRENCY value, using default",
+				"value", value, "default", defaultConcurrency)
+		}
+	}
+
+	return cfg
+}
+
+// durationFromEnv reads a non-negative duration from the environment, falling back to a default
+func durationFromEnv(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		slog.Warn("Invalid "+name+" value, using default", "value", value, "default", fallback)
+		return fallback
+	}
+
+	slog.Info("Using custom "+name, "value", duration)
+	return duration
+}
+
+// Status describes a finished sync run
+type Status struct {
+	Started  time.Time
+	Duration time.Duration
+	Tasks    int
+	Failures int
+}
+
+// Scheduler periodically refreshes all years and recently viewed act details
+type Scheduler struct {
+	refresher Refresher
+	cfg       Config
+}
+
+// NewScheduler creates a scheduler for the refresher
+func NewScheduler(refresher Refresher, cfg Config) *Scheduler {
+	cfg.Concurrency = max(cfg.Concurrency, 1)
+	return &Scheduler{
+		refresher: refresher,
+		cfg:       cfg,
+	}
+}
+
+// Run pre-warms the cache and keeps refreshing it until the context is cancelled
+func (s *Scheduler) Run(ctx context.Context) {
+	if s.cfg.Interval <= 0 {
+		slog.Info("Background sync disabled")
+		return
+	}
+
+	slog.Info("Background sync started", "interval", s.cfg.Interval, "jitter", s.cfg.Jitter,
+		"concurrency", s.cfg.Concurrency)
+	for {
+		s.RunOnce(ctx)
+
+		timer := time.NewTimer(s.nextDelay())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			slog.Info("Background sync stopped")
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// nextDelay returns the interval with a random jitter added
+func (s *Scheduler) nextDelay() time.Duration {
+	if s.cfg.Jitter <= 0 {
+		return s.cfg.Interval
+	}
+	return s.cfg.Interval + rand.N(s.cfg.Jitter)
+}
+
+// RunOnce refreshes every year listing and then recently viewed act details
+func (s *Scheduler) RunOnce(ctx context.Context) Status {
+	status := Status{Started: time.Now()}
+	var failures atomic.Int64
+
+	years := make([]func(context.Context) error, 0)
+	for _, publisher := range s.cfg.Publishers {
+		for year := s.cfg.FirstYear; year <= status.Started.Year(); year++ {
+			years = append(years, func(ctx context.Context) error {
+				err := s.refresher.RefreshYear(ctx, publisher, year)
+				if err != nil {
+					slog.Warn("Background refresh of year failed", "publisher", publisher, "year", year, "error", err)
+				}
+				return err
+			})
+		}
+	}
+	status.Tasks += s.runTasks(ctx, years, &failures)
+
+	// Details go second so they see statuses refreshed by the year listings
+	details := make([]func(context.Context) error, 0)
+	for _, actID := range s.refresher.ActsToRefresh(ctx) {
+		details = append(details, func(ctx context.Context) error {
+			err := s.refresher.RefreshActDetails(ctx, actID)
+			if err != nil {
+				slog.Warn("Background refresh of act details failed", "act_id", actID, "error", err)
+			}
+			return err
+		})
+	}
+	status.Tasks += s.runTasks(ctx, details, &failures)
+
+	status.Failures = int(failures.Load())
+	status.Duration = time.Since(status.Started)
+	metrics.RecordSyncRun(status.Started, status.Duration, status.Failures)
+	slog.Info("Background sync finished", "tasks", status.Tasks, "failures", status.Failures,
+		"duration", status.Duration)
+	return status
+}
+
+// runTasks runs tasks on a bounded pool of workers, returning how many were started
+func (s *Scheduler) runTasks(ctx context.Context, tasks []func(context.Context) error, failures *atomic.Int64) int {
+	queue := make(chan func(context.Context) error)
+	var started atomic.Int64
+	var wg sync.WaitGroup
+
+	for range min(s.cfg.Concurrency, len(tasks)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range queue {
+				started.Add(1)
+				if err := task(ctx); err != nil {
+					failures.Add(1)
+				}
+			}
+		}()
+	}
+
+	for _, task := range tasks {
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case queue <- task:
+		case <-ctx.Done():
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	return int(started.Load())
+}
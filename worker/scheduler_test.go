@@ -0,0 +1,146 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"ustawka/metrics"
+	"ustawka/worker"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRefresher records refreshes and tracks how many run at the same time
+type fakeRefresher struct {
+	mu        sync.Mutex
+	refreshed []string
+	viewed    []string
+	failYear  int
+	delay     time.Duration
+
+	running    atomic.Int64
+	maxRunning atomic.Int64
+}
+
+func (f *fakeRefresher) track(key string) {
+	running := f.running.Add(1)
+	defer f.running.Add(-1)
+	for {
+		current := f.maxRunning.Load()
+		if running <= current || f.maxRunning.CompareAndSwap(current, running) {
+			break
+		}
+	}
+	time.Sleep(f.delay)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.refreshed = append(f.refreshed, key)
+}
+
+func (f *fakeRefresher) RefreshYear(_ context.Context, publisher string, year int) error {
+	f.track(fmt.Sprintf("%s/%d", publisher, year))
+	if year == f.failYear {
+		return errors.New("API error")
+	}
+	return nil
+}
+
+func (f *fakeRefresher) RefreshActDetails(_ context.Context, actID string) error {
+	f.track(actID)
+	return nil
+}
+
+func (f *fakeRefresher) ActsToRefresh(context.Context) []string {
+	return f.viewed
+}
+
+func (f *fakeRefresher) calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.refreshed...)
+}
+
+func TestRunOnce(t *testing.T) {
+	currentYear := time.Now().Year()
+	refresher := &fakeRefresher{
+		viewed:   []string{"DU/2024/5", "MP/2023/1"},
+		failYear: currentYear,
+		delay:    5 * time.Millisecond,
+	}
+	scheduler := worker.NewScheduler(refresher, worker.Config{
+		Concurrency: 3,
+		Publishers:  []string{"DU", "MP"},
+		FirstYear:   currentYear - 2,
+	})
+
+	status := scheduler.RunOnce(context.Background())
+
+	expected := []string{"DU/2024/5", "MP/2023/1"}
+	for year := currentYear - 2; year <= currentYear; year++ {
+		expected = append(expected, fmt.Sprintf("DU/%d", year), fmt.Sprintf("MP/%d", year))
+	}
+	assert.ElementsMatch(t, expected, refresher.calls())
+	assert.Equal(t, 8, status.Tasks)
+	assert.Equal(t, 2, status.Failures)
+	assert.LessOrEqual(t, refresher.maxRunning.Load(), int64(3))
+	assert.Greater(t, refresher.maxRunning.Load(), int64(1))
+
+	// Details are refreshed after all year listings
+	calls := refresher.calls()
+	assert.ElementsMatch(t, []string{"DU/2024/5", "MP/2023/1"}, calls[len(calls)-2:])
+
+	m := metrics.GetMetrics()
+	assert.Equal(t, uint64(status.Started.Unix()), m["sync_last_run_unix"])
+	assert.Equal(t, uint64(2), m["sync_last_failures"])
+}
+
+func TestRunStopsOnCancel(t *testing.T) {
+	refresher := &fakeRefresher{}
+	scheduler := worker.NewScheduler(refresher, worker.Config{
+		Interval:    time.Hour,
+		Concurrency: 1,
+		Publishers:  []string{"DU"},
+		FirstYear:   time.Now().Year(),
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		scheduler.Run(ctx)
+		close(done)
+	}()
+
+	// The cache is pre-warmed right away
+	assert.Eventually(t, func() bool { return len(refresher.calls()) == 1 }, time.Second, time.Millisecond)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Scheduler did not stop after cancellation")
+	}
+}
+
+func TestRunDisabled(t *testing.T) {
+	refresher := &fakeRefresher{}
+	scheduler := worker.NewScheduler(refresher, worker.Config{Publishers: []string{"DU"}, FirstYear: 2021})
+
+	scheduler.Run(context.Background())
+	assert.Empty(t, refresher.calls())
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("SEJM_SYNC_INTERVAL", "30m")
+	t.Setenv("SEJM_SYNC_JITTER", "bogus")
+	t.Setenv("SEJM_SYNC_CONCURRENCY", "4")
+
+	cfg := worker.ConfigFromEnv([]string{"DU"}, 2021)
+	assert.Equal(t, 30*time.Minute, cfg.Interval)
+	assert.Equal(t, 5*time.Minute, cfg.Jitter)
+	assert.Equal(t, 4, cfg.Concurrency)
+}